@@ -7,6 +7,7 @@ import (
 	"io"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -63,6 +64,95 @@ func processArgsWithStdin(args []string) (string, bool, error) {
 	return strings.Join(processedArgs, " "), true, nil
 }
 
+// maxContextFileSize is the maximum number of bytes of a context file included in the prompt
+const maxContextFileSize = 256 * 1024
+
+// withContextFiles prepends the contents of the given files to the user prompt, each one
+// between delimiters that include the file name. Files larger than maxContextFileSize
+// are truncated (with a note, so the LLM knows the content is incomplete).
+func withContextFiles(prompt string, paths []string) (string, error) {
+	if len(paths) == 0 {
+		return prompt, nil
+	}
+
+	var sb strings.Builder
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to open context file: %w", err)
+		}
+		content, err := io.ReadAll(io.LimitReader(f, maxContextFileSize+1))
+		_ = f.Close()
+		if err != nil {
+			return "", fmt.Errorf("failed to read context file %s: %w", path, err)
+		}
+
+		truncated := len(content) > maxContextFileSize
+		if truncated {
+			content = content[:maxContextFileSize]
+		}
+
+		fmt.Fprintf(&sb, "--- begin file: %s ---\n", path)
+		sb.Write(content)
+		if len(content) > 0 && content[len(content)-1] != '\n' {
+			sb.WriteString("\n")
+		}
+		if truncated {
+			fmt.Fprintf(&sb, "[... truncated to the first %d bytes ...]\n", maxContextFileSize)
+		}
+		fmt.Fprintf(&sb, "--- end file: %s ---\n\n", path)
+	}
+	sb.WriteString(prompt)
+
+	return sb.String(), nil
+}
+
+// stdinIsTerminal returns true if the standard input is an interactive terminal
+var stdinIsTerminal = func() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// selectModel asks the user to choose one of the given models, by number or by name
+func selectModel(models []agent.ModelConfig, in io.Reader, out io.Writer) (agent.ModelConfig, error) {
+	_, _ = fmt.Fprintln(out, "No model specified. Available models:")
+	for i, model := range models {
+		description := model.Model
+		if model.Name != "" && model.Name != model.Model {
+			description = fmt.Sprintf("%s (%s)", model.Name, model.Model)
+		}
+		if model.Class != "" {
+			description += fmt.Sprintf(" [%s]", model.Class)
+		}
+		_, _ = fmt.Fprintf(out, "  %d) %s\n", i+1, description)
+	}
+
+	scanner := bufio.NewScanner(in)
+	for {
+		_, _ = fmt.Fprintf(out, "Select a model [1-%d]: ", len(models))
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return agent.ModelConfig{}, err
+			}
+			return agent.ModelConfig{}, fmt.Errorf("no model selected")
+		}
+
+		choice := strings.TrimSpace(scanner.Text())
+		if n, err := strconv.Atoi(choice); err == nil && n >= 1 && n <= len(models) {
+			return models[n-1], nil
+		}
+		for _, model := range models {
+			if choice != "" && (choice == model.Name || choice == model.Model) {
+				return model, nil
+			}
+		}
+		_, _ = fmt.Fprintf(out, "Invalid choice: %q\n", choice)
+	}
+}
+
 // buildAgentConfig creates an AgentConfig by merging command-line flags with configuration file
 func buildAgentConfig() (agent.AgentConfig, error) {
 	// Load configuration from file
@@ -103,6 +193,40 @@ func buildAgentConfig() (agent.AgentConfig, error) {
 		}
 	}
 
+	// Without a model, use the one embedded in the tools configuration (if any)
+	var localConfigPath string
+	if modelConfig.Model == "" && len(toolsFiles) > 0 {
+		localConfigPath, _, err = toolsConfig.ResolveConfigPathsWithOverlay(toolsFiles, toolsEnv, logger)
+		if err != nil {
+			return agent.AgentConfig{}, fmt.Errorf("failed to resolve config paths: %w", err)
+		}
+
+		toolsModel, err := agent.GetToolsConfigModel(localConfigPath)
+		if err != nil {
+			return agent.AgentConfig{}, err
+		}
+		if toolsModel != nil {
+			modelConfig = *toolsModel
+			logger.Info("Using the model from the tools configuration: model=%s, class=%s, name=%s",
+				modelConfig.Model, modelConfig.Class, modelConfig.Name)
+		}
+	}
+
+	// Without a model, let the user choose one of the configured models (only in interactive mode)
+	if modelConfig.Model == "" {
+		models := config.ListModels()
+		if agentOnce || !stdinIsTerminal() || len(models) == 0 {
+			return agent.AgentConfig{}, fmt.Errorf("no model specified: use --model, set the MCPSHELL_AGENT_MODEL environment variable or configure a default model in the agent configuration or in the tools configuration")
+		}
+
+		selected, err := selectModel(models, os.Stdin, os.Stderr)
+		if err != nil {
+			return agent.AgentConfig{}, fmt.Errorf("failed to select a model: %w", err)
+		}
+		modelConfig = selected
+		logger.Info("Selected model: model=%s, class=%s, name=%s", modelConfig.Model, modelConfig.Class, modelConfig.Name)
+	}
+
 	// Merge system prompts from config file and command-line
 	if agentSystemPrompt != "" {
 		// Join system prompts from config with command-line system prompt
@@ -128,18 +252,24 @@ func buildAgentConfig() (agent.AgentConfig, error) {
 		modelConfig.APIURL = agentOpenAIApiURL
 	}
 
-	// Handle environment variable substitution for API key
-	if strings.HasPrefix(modelConfig.APIKey, "${") && strings.HasSuffix(modelConfig.APIKey, "}") {
-		envVar := strings.TrimSuffix(strings.TrimPrefix(modelConfig.APIKey, "${"), "}")
-		modelConfig.APIKey = os.Getenv(envVar)
-		logger.Debug("Substituted API key from environment variable: %s", envVar)
+	// Handle environment variable substitution (${VAR}) for the API key and URL
+	if expanded := common.ExpandEnv(modelConfig.APIKey); expanded != modelConfig.APIKey {
+		modelConfig.APIKey = expanded
+		logger.Debug("Substituted environment variables in API key")
+	}
+	if expanded := common.ExpandEnv(modelConfig.APIURL); expanded != modelConfig.APIURL {
+		modelConfig.APIURL = expanded
+		logger.Debug("Substituted environment variables in API URL: %s", modelConfig.APIURL)
 	}
 
-	// Handle environment variable substitution for API URL
-	if strings.HasPrefix(modelConfig.APIURL, "${") && strings.HasSuffix(modelConfig.APIURL, "}") {
-		envVar := strings.TrimSuffix(strings.TrimPrefix(modelConfig.APIURL, "${"), "}")
-		modelConfig.APIURL = os.Getenv(envVar)
-		logger.Debug("Substituted API URL from environment variable: %s = %s", envVar, modelConfig.APIURL)
+	// Check the model is usable before going any further (e.g. it has an API key when required)
+	if err := agent.ValidateModelConfig(modelConfig, logger); err != nil {
+		modelName := modelConfig.Name
+		if modelName == "" {
+			modelName = modelConfig.Model
+		}
+		logger.Error("Model '%s' is not usable: %v", modelName, err)
+		return agent.AgentConfig{}, fmt.Errorf("model '%s' is not usable: %w", modelName, err)
 	}
 
 	// Resolve multiple config files into a single merged config file
@@ -147,17 +277,28 @@ func buildAgentConfig() (agent.AgentConfig, error) {
 		return agent.AgentConfig{}, fmt.Errorf("tools configuration file(s) are required")
 	}
 
-	localConfigPath, _, err := toolsConfig.ResolveMultipleConfigPaths(toolsFiles, logger)
+	if localConfigPath == "" {
+		localConfigPath, _, err = toolsConfig.ResolveConfigPathsWithOverlay(toolsFiles, toolsEnv, logger)
+		if err != nil {
+			return agent.AgentConfig{}, fmt.Errorf("failed to resolve config paths: %w", err)
+		}
+	}
+
+	// Inline the context files in the user prompt
+	userPrompt, err := withContextFiles(agentUserPrompt, agentContextFiles)
 	if err != nil {
-		return agent.AgentConfig{}, fmt.Errorf("failed to resolve config paths: %w", err)
+		return agent.AgentConfig{}, err
 	}
 
 	return agent.AgentConfig{
-		ToolsFile:   localConfigPath,
-		UserPrompt:  agentUserPrompt,
-		Once:        agentOnce,
-		Version:     version,
-		ModelConfig: modelConfig,
+		ToolsFile:      localConfigPath,
+		UserPrompt:     userPrompt,
+		Once:           agentOnce,
+		Approve:        agentApprove,
+		HideDeprecated: agentHideDeprecated,
+		MaxTools:       agentMaxTools,
+		Version:        version,
+		ModelConfig:    modelConfig,
 	}, nil
 }
 
@@ -192,6 +333,11 @@ $ cat failure.log | mcpshell agent --tools kubectl-ro.yaml \
 
 When STDIN is used, the agent automatically runs in --once mode since STDIN is no longer available for interactive input.
 
+Files can be attached as context with --context-file (can be repeated), and their
+contents are included before the user prompt:
+
+$ mcpshell agent --tools kubectl-ro.yaml --context-file app.log "why is this crashing?"
+
 The agent will try to debug the issue with the given tools.
 `,
 	Args: cobra.ArbitraryArgs,
@@ -256,6 +402,7 @@ The agent will try to debug the issue with the given tools.
 		// Setup signal handling for graceful shutdown
 		signalChan := make(chan os.Signal, 1)
 		signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(signalChan)
 
 		var wg sync.WaitGroup
 		wg.Add(1)
@@ -324,6 +471,9 @@ The agent will try to debug the issue with the given tools.
 			fmt.Print(output)
 		}
 
+		// The agent is done (or was interrupted): stop the other goroutines right away
+		cancel()
+
 		// Wait for all goroutines with a timeout to prevent hanging
 		done := make(chan struct{})
 		go func() {
@@ -356,6 +506,11 @@ func init() {
 	agentCommand.PersistentFlags().StringVarP(&agentOpenAIApiKey, "openai-api-key", "k", "", "OpenAI API key (or set OPENAI_API_KEY environment variable)")
 	agentCommand.PersistentFlags().StringVarP(&agentOpenAIApiURL, "openai-api-url", "b", "", "Base URL for the OpenAI API (optional)")
 	agentCommand.PersistentFlags().BoolVarP(&agentOnce, "once", "o", false, "Exit after receiving a final response from the LLM (one-shot mode)")
+	agentCommand.PersistentFlags().StringVar(&agentApprove, "approve", agent.ApproveSafe, "Tool approval mode: 'safe' (auto-approve all the tools except the destructive ones) or 'auto' (auto-approve all the tools)")
+	agentCommand.PersistentFlags().BoolVar(&agentHideDeprecated, "hide-deprecated", false, "Do not expose the tools marked as deprecated to the LLM")
+	agentCommand.PersistentFlags().IntVar(&agentMaxTools, "max-tools", 0, "Maximum number of tools exposed to the LLM, keeping the ones with higher 'priority' first (0 for no limit)")
+	agentCommand.PersistentFlags().StringArrayVar(&agentContextFiles, "context-file", []string{}, "File whose contents are included as context before the user prompt (can be specified multiple times)")
+	agentCommand.PersistentFlags().StringVar(&agentAPIKeyMask, "api-key-mask", "", "How API keys are masked when displayed: 'full', or the number of characters shown at each end (can also set MCPSHELL_API_KEY_MASK env var)")
 
 	// Add config subcommand
 	agentCommand.AddCommand(agentConfigCommand)
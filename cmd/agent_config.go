@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 
 	"github.com/spf13/cobra"
 
@@ -12,8 +13,20 @@ import (
 	"github.com/inercia/MCPShell/pkg/utils"
 )
 
+const (
+	// apiKeyMaskEnv is the environment variable with the masking mode for API keys
+	apiKeyMaskEnv = "MCPSHELL_API_KEY_MASK"
+
+	// defaultAPIKeyVisibleChars is the number of characters shown at each end of a masked API key
+	defaultAPIKeyVisibleChars = 4
+
+	// minPartialMaskKeyLength is the minimum length of an API key for showing some of its characters
+	minPartialMaskKeyLength = 24
+)
+
 var (
 	agentConfigShowJSON bool
+	agentAPIKeyMask     string
 )
 
 // agentConfigCommand is the parent command for agent configuration subcommands
@@ -285,11 +298,46 @@ func outputConfigShowJSON(configPath string, config *agent.Config) error {
 }
 
 // Helper function to mask API keys for security
+//
+// The masking mode is taken from the --api-key-mask flag or the MCPSHELL_API_KEY_MASK
+// environment variable (see maskAPIKeyWithMode).
 func maskAPIKey(key string) string {
-	if len(key) <= 8 {
-		return "****"
+	mode := agentAPIKeyMask
+	if mode == "" {
+		mode = os.Getenv(apiKeyMaskEnv)
+	}
+	return maskAPIKeyWithMode(key, mode)
+}
+
+// maskAPIKeyWithMode masks an API key according to a masking mode:
+//
+//   - "full": the key is completely masked
+//   - a number N: the first and last N characters are shown
+//   - "" (default): the first and last 4 characters are shown
+//
+// Keys too short for hiding most of their characters are always fully masked.
+// An invalid mode also results in a full mask.
+func maskAPIKeyWithMode(key string, mode string) string {
+	const fullMask = "****"
+
+	visible := defaultAPIKeyVisibleChars
+	switch mode {
+	case "":
+	case "full":
+		return fullMask
+	default:
+		n, err := strconv.Atoi(mode)
+		if err != nil || n < 0 {
+			return fullMask
+		}
+		visible = n
+	}
+
+	// never reveal more than a third of the key
+	if visible == 0 || len(key) < minPartialMaskKeyLength || 2*visible*3 > len(key) {
+		return fullMask
 	}
-	return key[:4] + "****" + key[len(key)-4:]
+	return key[:visible] + fullMask + key[len(key)-visible:]
 }
 
 // Helper function to truncate long strings
@@ -0,0 +1,57 @@
+package root
+
+import (
+	"testing"
+)
+
+func TestMaskAPIKeyWithMode(t *testing.T) {
+	const longKey = "sk-abcdefghijklmnopqrstuvwxyz0123456789" // 39 chars
+	const shortKey = "sk-abcdefghijklmnop"                    // 19 chars
+
+	tests := []struct {
+		name string
+		key  string
+		mode string
+		want string
+	}{
+		{name: "empty key", key: "", mode: "", want: "****"},
+		{name: "very short key", key: "abcd1234", mode: "", want: "****"},
+		{name: "short key is fully masked by default", key: shortKey, mode: "", want: "****"},
+		{name: "long key shows 4 chars by default", key: longKey, mode: "", want: "sk-a****6789"},
+		{name: "full mode", key: longKey, mode: "full", want: "****"},
+		{name: "custom number of chars", key: longKey, mode: "2", want: "sk****89"},
+		{name: "zero chars", key: longKey, mode: "0", want: "****"},
+		{name: "too many chars for the key length", key: longKey, mode: "10", want: "****"},
+		{name: "custom chars on a short key", key: shortKey, mode: "2", want: "****"},
+		{name: "invalid mode", key: longKey, mode: "partial", want: "****"},
+		{name: "negative mode", key: longKey, mode: "-1", want: "****"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := maskAPIKeyWithMode(tt.key, tt.mode); got != tt.want {
+				t.Errorf("maskAPIKeyWithMode(%q, %q) = %q, want %q", tt.key, tt.mode, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaskAPIKey_ModeSources(t *testing.T) {
+	const key = "sk-abcdefghijklmnopqrstuvwxyz0123456789"
+
+	oldMask := agentAPIKeyMask
+	defer func() { agentAPIKeyMask = oldMask }()
+
+	// the environment variable is used when the flag is not set
+	agentAPIKeyMask = ""
+	t.Setenv(apiKeyMaskEnv, "full")
+	if got := maskAPIKey(key); got != "****" {
+		t.Errorf("maskAPIKey() with %s=full = %q, want %q", apiKeyMaskEnv, got, "****")
+	}
+
+	// the flag takes precedence over the environment variable
+	agentAPIKeyMask = "3"
+	if got := maskAPIKey(key); got != "sk-****789" {
+		t.Errorf("maskAPIKey() with flag=3 = %q, want %q", got, "sk-****789")
+	}
+}
@@ -3,6 +3,7 @@ package root
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -16,6 +17,7 @@ import (
 	"github.com/inercia/MCPShell/pkg/agent"
 	"github.com/inercia/MCPShell/pkg/common"
 	toolsConfig "github.com/inercia/MCPShell/pkg/config"
+	"github.com/inercia/MCPShell/pkg/server"
 	"github.com/inercia/MCPShell/pkg/utils"
 )
 
@@ -23,8 +25,13 @@ var (
 	agentInfoJSON           bool
 	agentInfoIncludePrompts bool
 	agentInfoCheck          bool
+	agentInfoCountTokens    bool
+	agentInfoCheckTimeout   time.Duration
 )
 
+// defaultCheckTimeout is the maximum time to wait for the LLM in the connectivity check
+const defaultCheckTimeout = 10 * time.Second
+
 // agentInfoCommand displays information about the agent configuration
 var agentInfoCommand = &cobra.Command{
 	Use:   "info",
@@ -35,6 +42,7 @@ Display information about the agent configuration including:
 - API configuration
 - System prompts (with --include-prompts)
 - LLM connectivity status (with --check)
+- Estimated number of tokens used by the tools schemas (with --count-tokens)
 
 The configuration is loaded from ~/.mcpshell/agent.yaml and merged with
 command-line flags (if provided).
@@ -47,8 +55,10 @@ $ mcpshell agent info
 $ mcpshell agent info --json
 $ mcpshell agent info --include-prompts
 $ mcpshell agent info --check
+$ mcpshell agent info --check --timeout 5s
 $ mcpshell agent info --model gpt-4o --json
 $ mcpshell agent info --tools examples/config.yaml
+$ mcpshell agent info --tools examples/config.yaml --count-tokens
 `,
 	Args: cobra.NoArgs,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
@@ -82,12 +92,24 @@ $ mcpshell agent info --tools examples/config.yaml
 		// Check LLM connectivity if requested
 		var checkResult *CheckResult
 		if agentInfoCheck {
-			checkResult = checkLLMConnectivity(orchestratorConfig, logger)
+			checkResult = checkLLMConnectivity(orchestratorConfig, agentInfoCheckTimeout, logger)
+		}
+
+		// Estimate the tokens used by the tools schemas if requested
+		var tokensEstimate *agent.TokensEstimate
+		if agentInfoCountTokens {
+			if agentConfig.ToolsFile == "" {
+				return fmt.Errorf("--count-tokens requires a tools configuration (use the --tools flag)")
+			}
+			tokensEstimate, err = estimateToolsSchemaTokens(agentConfig, logger)
+			if err != nil {
+				return fmt.Errorf("failed to estimate the tools tokens: %w", err)
+			}
 		}
 
 		// Output in JSON format if requested
 		if agentInfoJSON {
-			err := outputJSON(agentConfig, orchestratorConfig, toolRunnerConfig, checkResult)
+			err := outputJSON(agentConfig, orchestratorConfig, toolRunnerConfig, checkResult, tokensEstimate)
 			if err != nil {
 				return err
 			}
@@ -99,7 +121,7 @@ $ mcpshell agent info --tools examples/config.yaml
 		}
 
 		// Output in human-readable format
-		return outputHumanReadable(agentConfig, orchestratorConfig, toolRunnerConfig, checkResult)
+		return outputHumanReadable(agentConfig, orchestratorConfig, toolRunnerConfig, checkResult, tokensEstimate)
 	},
 }
 
@@ -120,6 +142,8 @@ type InfoOutput struct {
 	ToolRunner   ModelInfo    `json:"tool_runner"`
 	Check        *CheckResult `json:"check,omitempty"`
 	Prompts      *PromptsInfo `json:"prompts,omitempty"`
+
+	ToolsTokens *agent.TokensEstimate `json:"tools_tokens,omitempty"`
 }
 
 // ModelInfo holds model configuration details for JSON output
@@ -221,7 +245,7 @@ func buildAgentConfigForInfo() (agent.AgentConfig, error) {
 	toolsFile := ""
 	if len(toolsFiles) > 0 {
 		// Resolve tools configuration if provided
-		localConfigPath, _, err := toolsConfig.ResolveMultipleConfigPaths(toolsFiles, logger)
+		localConfigPath, _, err := toolsConfig.ResolveConfigPathsWithOverlay(toolsFiles, toolsEnv, logger)
 		if err != nil {
 			return agent.AgentConfig{}, fmt.Errorf("failed to resolve config paths: %w", err)
 		}
@@ -229,16 +253,19 @@ func buildAgentConfigForInfo() (agent.AgentConfig, error) {
 	}
 
 	return agent.AgentConfig{
-		ToolsFile:   toolsFile,
-		UserPrompt:  agentUserPrompt,
-		Once:        agentOnce,
-		Version:     version,
-		ModelConfig: modelConfig,
+		ToolsFile:      toolsFile,
+		UserPrompt:     agentUserPrompt,
+		Once:           agentOnce,
+		Approve:        agentApprove,
+		HideDeprecated: agentHideDeprecated,
+		MaxTools:       agentMaxTools,
+		Version:        version,
+		ModelConfig:    modelConfig,
 	}, nil
 }
 
-// checkLLMConnectivity tests if the LLM is responding
-func checkLLMConnectivity(modelConfig agent.ModelConfig, logger *common.Logger) *CheckResult {
+// checkLLMConnectivity tests if the LLM is responding within the given timeout
+func checkLLMConnectivity(modelConfig agent.ModelConfig, timeout time.Duration, logger *common.Logger) *CheckResult {
 	result := &CheckResult{
 		Model: modelConfig.Model,
 	}
@@ -254,7 +281,7 @@ func checkLLMConnectivity(modelConfig agent.ModelConfig, logger *common.Logger)
 	}
 
 	// Make a simple test request
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	startTime := time.Now()
@@ -273,6 +300,12 @@ func checkLLMConnectivity(modelConfig agent.ModelConfig, logger *common.Logger)
 	_, err = client.CreateChatCompletion(ctx, req)
 	elapsed := time.Since(startTime)
 
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		result.Success = false
+		result.Error = fmt.Sprintf("LLM did not respond within %s", timeout)
+		logger.Error("LLM connectivity check timed out after %s", timeout)
+		return result
+	}
 	if err != nil {
 		result.Success = false
 		result.Error = fmt.Sprintf("LLM request failed: %v", err)
@@ -287,8 +320,30 @@ func checkLLMConnectivity(modelConfig agent.ModelConfig, logger *common.Logger)
 	return result
 }
 
+// estimateToolsSchemaTokens loads the tools of the agent configuration and estimates
+// the number of tokens used by their schemas when sent to the orchestrator model
+func estimateToolsSchemaTokens(agentConfig agent.AgentConfig, logger *common.Logger) (*agent.TokensEstimate, error) {
+	srv := server.New(server.Config{
+		ConfigFile:     agentConfig.ToolsFile,
+		Logger:         logger,
+		Version:        version,
+		HideDeprecated: agentConfig.HideDeprecated,
+		MaxTools:       agentConfig.MaxTools,
+	})
+	if err := srv.CreateServer(); err != nil {
+		return nil, fmt.Errorf("failed to load the tools: %w", err)
+	}
+
+	tools, err := srv.GetOpenAITools()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the tools: %w", err)
+	}
+
+	return agent.EstimateToolsTokens(tools, agentConfig.Model)
+}
+
 // outputJSON outputs the configuration in JSON format
-func outputJSON(agentConfig agent.AgentConfig, orchestrator, toolRunner agent.ModelConfig, check *CheckResult) error {
+func outputJSON(agentConfig agent.AgentConfig, orchestrator, toolRunner agent.ModelConfig, check *CheckResult, tokens *agent.TokensEstimate) error {
 	// Get agent config file path
 	var configFile string
 	if mcpShellHome, err := utils.GetMCPShellHome(); err == nil {
@@ -313,7 +368,8 @@ func outputJSON(agentConfig agent.AgentConfig, orchestrator, toolRunner agent.Mo
 			APIURL: toolRunner.APIURL,
 			APIKey: maskAPIKey(toolRunner.APIKey),
 		},
-		Check: check,
+		Check:       check,
+		ToolsTokens: tokens,
 	}
 
 	// Include prompts if requested
@@ -330,7 +386,7 @@ func outputJSON(agentConfig agent.AgentConfig, orchestrator, toolRunner agent.Mo
 }
 
 // outputHumanReadable outputs the configuration in human-readable format
-func outputHumanReadable(agentConfig agent.AgentConfig, orchestrator, toolRunner agent.ModelConfig, check *CheckResult) error {
+func outputHumanReadable(agentConfig agent.AgentConfig, orchestrator, toolRunner agent.ModelConfig, check *CheckResult, tokens *agent.TokensEstimate) error {
 	fmt.Println(color.HiCyanString("Agent Configuration"))
 	fmt.Println(strings.Repeat("=", 50))
 	fmt.Println()
@@ -398,6 +454,16 @@ func outputHumanReadable(agentConfig agent.AgentConfig, orchestrator, toolRunner
 		fmt.Println()
 	}
 
+	// Tools tokens (if requested)
+	if tokens != nil {
+		fmt.Println(color.HiYellowString("Tools Schema Tokens (estimated, %s):", tokens.Encoding))
+		for _, tool := range tokens.Tools {
+			fmt.Printf("  %-30s %6d\n", tool.Name, tool.Tokens)
+		}
+		fmt.Printf("  %-30s %6d\n", "Total", tokens.Total)
+		fmt.Println()
+	}
+
 	// Check result (if performed)
 	if check != nil {
 		fmt.Println(color.HiYellowString("LLM Connectivity Check:"))
@@ -422,5 +488,7 @@ func init() {
 	// Add info-specific flags
 	agentInfoCommand.Flags().BoolVar(&agentInfoJSON, "json", false, "Output in JSON format (for easy parsing)")
 	agentInfoCommand.Flags().BoolVar(&agentInfoIncludePrompts, "include-prompts", false, "Include full prompts in the output")
+	agentInfoCommand.Flags().BoolVar(&agentInfoCountTokens, "count-tokens", false, "Estimate the number of tokens used by the tools schemas (requires --tools)")
 	agentInfoCommand.Flags().BoolVar(&agentInfoCheck, "check", false, "Check LLM connectivity (exits with error if LLM is not responding)")
+	agentInfoCommand.Flags().DurationVar(&agentInfoCheckTimeout, "timeout", defaultCheckTimeout, "Maximum time to wait for the LLM in the connectivity check (with --check)")
 }
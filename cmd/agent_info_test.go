@@ -0,0 +1,111 @@
+package root
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/inercia/MCPShell/pkg/agent"
+	"github.com/inercia/MCPShell/pkg/common"
+)
+
+func TestEstimateToolsSchemaTokens(t *testing.T) {
+	testConfigFile := filepath.Join(t.TempDir(), "config.yaml")
+	configContent := `mcp:
+  tools:
+    - name: "list_files"
+      description: "List the files in a directory, with their sizes and modification times"
+      params:
+        path:
+          type: string
+          description: "Path of the directory to list"
+          required: true
+      run:
+        command: "ls -la {{ .path }}"
+    - name: "uptime"
+      description: "Show the uptime"
+      run:
+        command: "uptime"
+`
+	if err := os.WriteFile(testConfigFile, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+	agentConfig := agent.AgentConfig{
+		ToolsFile:   testConfigFile,
+		ModelConfig: agent.ModelConfig{Model: "gpt-4o"},
+	}
+
+	estimate, err := estimateToolsSchemaTokens(agentConfig, logger)
+	if err != nil {
+		t.Fatalf("estimateToolsSchemaTokens() error = %v", err)
+	}
+
+	if estimate.Encoding != "o200k_base" {
+		t.Errorf("Expected the o200k_base encoding for gpt-4o, got %q", estimate.Encoding)
+	}
+	if len(estimate.Tools) != 2 {
+		t.Fatalf("Expected an estimate for 2 tools, got %+v", estimate.Tools)
+	}
+	if estimate.Total <= 0 {
+		t.Errorf("Expected a non-zero estimate, got %d", estimate.Total)
+	}
+	// the tools are sorted by cost, and the one with parameters is the most expensive
+	if estimate.Tools[0].Name != "list_files" || estimate.Tools[0].Tokens <= estimate.Tools[1].Tokens {
+		t.Errorf("Expected 'list_files' to be the most expensive tool, got %+v", estimate.Tools)
+	}
+	if estimate.Tools[0].Tokens+estimate.Tools[1].Tokens != estimate.Total {
+		t.Errorf("Expected the total to be the sum of the tools, got %+v", estimate)
+	}
+}
+
+func TestCheckLLMConnectivity_Timeout(t *testing.T) {
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+
+	newServer := func(delay time.Duration) *httptest.Server {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"id": "1", "object": "chat.completion", "model": "test-model",
+				"choices": [{"index": 0, "message": {"role": "assistant", "content": "OK"}, "finish_reason": "stop"}]}`))
+		}))
+		t.Cleanup(srv.Close)
+		return srv
+	}
+
+	t.Run("Slow LLM exceeding the timeout", func(t *testing.T) {
+		srv := newServer(time.Second)
+		modelConfig := agent.ModelConfig{Model: "test-model", APIKey: "test-key", APIURL: srv.URL}
+
+		start := time.Now()
+		result := checkLLMConnectivity(modelConfig, 100*time.Millisecond, logger)
+		if elapsed := time.Since(start); elapsed >= time.Second {
+			t.Errorf("Expected the check to give up after the timeout, took %s", elapsed)
+		}
+		if result.Success {
+			t.Fatal("Expected the check to fail")
+		}
+		if !strings.Contains(result.Error, "did not respond within 100ms") {
+			t.Errorf("Unexpected error: %q", result.Error)
+		}
+	})
+
+	t.Run("LLM responding within the timeout", func(t *testing.T) {
+		srv := newServer(0)
+		modelConfig := agent.ModelConfig{Model: "test-model", APIKey: "test-key", APIURL: srv.URL}
+
+		result := checkLLMConnectivity(modelConfig, 5*time.Second, logger)
+		if !result.Success {
+			t.Errorf("Expected the check to succeed, got error %q", result.Error)
+		}
+	})
+}
@@ -0,0 +1,183 @@
+package root
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/inercia/MCPShell/pkg/agent"
+	"github.com/inercia/MCPShell/pkg/utils"
+)
+
+func TestSelectModel(t *testing.T) {
+	models := []agent.ModelConfig{
+		{Model: "gpt-4o", Class: "openai"},
+		{Name: "local", Model: "llama3.1:8b", Class: "ollama"},
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "by number", input: "2\n", want: "llama3.1:8b"},
+		{name: "by model", input: "gpt-4o\n", want: "gpt-4o"},
+		{name: "by name", input: "local\n", want: "llama3.1:8b"},
+		{name: "retry after an invalid choice", input: "7\n1\n", want: "gpt-4o"},
+		{name: "no choice", input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			got, err := selectModel(models, strings.NewReader(tt.input), &out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("selectModel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got.Model != tt.want {
+				t.Errorf("selectModel() = %q, want %q", got.Model, tt.want)
+			}
+			if !strings.Contains(out.String(), "2) local (llama3.1:8b) [ollama]") {
+				t.Errorf("Expected the list of models in the output, got %q", out.String())
+			}
+		})
+	}
+}
+
+func TestBuildAgentConfig_NoModelNonInteractive(t *testing.T) {
+	dir := t.TempDir()
+	agentConfig := `agent:
+  orchestrator:
+    model: "gpt-4o"
+`
+	if err := os.WriteFile(filepath.Join(dir, "agent.yaml"), []byte(agentConfig), 0o644); err != nil {
+		t.Fatalf("Failed to write agent config: %v", err)
+	}
+	t.Setenv(utils.MCPShellDirEnv, dir)
+	t.Setenv("MCPSHELL_AGENT_MODEL", "")
+
+	oldModel, oldOnce, oldIsTerminal := agentModel, agentOnce, stdinIsTerminal
+	defer func() { agentModel, agentOnce, stdinIsTerminal = oldModel, oldOnce, oldIsTerminal }()
+	agentModel = ""
+
+	// in --once mode, the agent must fail even with an interactive terminal
+	agentOnce = true
+	stdinIsTerminal = func() bool { return true }
+	if _, err := buildAgentConfig(); err == nil || !strings.Contains(err.Error(), "no model specified") {
+		t.Errorf("buildAgentConfig() in once mode error = %v, want a 'no model specified' error", err)
+	}
+
+	// without a terminal, the agent must fail too
+	agentOnce = false
+	stdinIsTerminal = func() bool { return false }
+	if _, err := buildAgentConfig(); err == nil || !strings.Contains(err.Error(), "no model specified") {
+		t.Errorf("buildAgentConfig() without a terminal error = %v, want a 'no model specified' error", err)
+	}
+}
+
+func TestBuildAgentConfig_UnusableModel(t *testing.T) {
+	dir := t.TempDir()
+	agentConfig := `agent:
+  models:
+    - name: "main"
+      model: "gpt-4o"
+      class: "openai"
+      default: true
+`
+	if err := os.WriteFile(filepath.Join(dir, "agent.yaml"), []byte(agentConfig), 0o644); err != nil {
+		t.Fatalf("Failed to write agent config: %v", err)
+	}
+	t.Setenv(utils.MCPShellDirEnv, dir)
+	t.Setenv("MCPSHELL_AGENT_MODEL", "")
+
+	oldModel, oldAPIKey := agentModel, agentOpenAIApiKey
+	defer func() { agentModel, agentOpenAIApiKey = oldModel, oldAPIKey }()
+	agentModel, agentOpenAIApiKey = "", ""
+
+	_, err := buildAgentConfig()
+	if err == nil {
+		t.Fatal("buildAgentConfig() error = nil, want an error for the model without an API key")
+	}
+	for _, want := range []string{"model 'main'", "API key is required"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("buildAgentConfig() error = %q, want it to contain %q", err.Error(), want)
+		}
+	}
+}
+
+func TestBuildAgentConfig_ModelFromToolsConfig(t *testing.T) {
+	// no agent.yaml in the MCPShell home
+	t.Setenv(utils.MCPShellDirEnv, t.TempDir())
+	t.Setenv("MCPSHELL_AGENT_MODEL", "")
+
+	toolsFile := filepath.Join(t.TempDir(), "tools.yaml")
+	toolsConfig := `agent:
+  model:
+    name: "embedded"
+    model: "gpt-4o-mini"
+    class: "openai"
+    api-key: "sk-test"
+mcp:
+  tools:
+    - name: "hello"
+      description: "Says hello"
+      run:
+        command: "echo hello"
+`
+	if err := os.WriteFile(toolsFile, []byte(toolsConfig), 0o644); err != nil {
+		t.Fatalf("Failed to write tools config: %v", err)
+	}
+
+	oldModel, oldToolsFiles, oldOnce := agentModel, toolsFiles, agentOnce
+	defer func() { agentModel, toolsFiles, agentOnce = oldModel, oldToolsFiles, oldOnce }()
+	agentModel, toolsFiles, agentOnce = "", []string{toolsFile}, true
+
+	cfg, err := buildAgentConfig()
+	if err != nil {
+		t.Fatalf("buildAgentConfig() error = %v", err)
+	}
+	if cfg.ModelConfig.Model != "gpt-4o-mini" || cfg.ModelConfig.Name != "embedded" {
+		t.Errorf("buildAgentConfig() model = %+v, want the model of the tools configuration", cfg.ModelConfig)
+	}
+
+	// the tools configuration is still usable by the server
+	if cfg.ToolsFile == "" {
+		t.Error("buildAgentConfig() did not resolve the tools configuration")
+	}
+}
+
+func TestWithContextFiles(t *testing.T) {
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(logFile, []byte("panic: nil pointer dereference\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write context file: %v", err)
+	}
+	bigFile := filepath.Join(dir, "big.log")
+	if err := os.WriteFile(bigFile, bytes.Repeat([]byte("x"), maxContextFileSize+100), 0o644); err != nil {
+		t.Fatalf("Failed to write context file: %v", err)
+	}
+
+	prompt, err := withContextFiles("why is this crashing?", []string{logFile})
+	if err != nil {
+		t.Fatalf("withContextFiles() error = %v", err)
+	}
+	want := "--- begin file: " + logFile + " ---\npanic: nil pointer dereference\n--- end file: " + logFile + " ---\n\nwhy is this crashing?"
+	if prompt != want {
+		t.Errorf("withContextFiles() = %q, want %q", prompt, want)
+	}
+
+	prompt, err = withContextFiles("look at this", []string{bigFile})
+	if err != nil {
+		t.Fatalf("withContextFiles() error = %v", err)
+	}
+	if !strings.Contains(prompt, "truncated") || len(prompt) > maxContextFileSize+200 {
+		t.Errorf("Expected the large file to be truncated, got a prompt of %d bytes", len(prompt))
+	}
+
+	if _, err := withContextFiles("prompt", []string{filepath.Join(dir, "missing.log")}); err == nil {
+		t.Errorf("Expected an error for a missing context file")
+	}
+}
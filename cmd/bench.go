@@ -0,0 +1,219 @@
+package root
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/inercia/MCPShell/pkg/command"
+	"github.com/inercia/MCPShell/pkg/common"
+)
+
+var (
+	benchIterations  int
+	benchConcurrency int
+)
+
+// benchReport holds the aggregated results of a benchmark
+type benchReport struct {
+	Iterations  int
+	Concurrency int
+	Errors      int
+	Total       time.Duration
+	Min         time.Duration
+	Mean        time.Duration
+	P50         time.Duration
+	P95         time.Duration
+	P99         time.Duration
+	Max         time.Duration
+}
+
+// ErrorRate returns the fraction of executions that failed
+func (r benchReport) ErrorRate() float64 {
+	if r.Iterations == 0 {
+		return 0
+	}
+	return float64(r.Errors) / float64(r.Iterations)
+}
+
+// Print writes the report in a human-readable format
+func (r benchReport) Print(w io.Writer) {
+	_, _ = fmt.Fprintf(w, "Iterations:  %d\n", r.Iterations)
+	_, _ = fmt.Fprintf(w, "Concurrency: %d\n", r.Concurrency)
+	_, _ = fmt.Fprintf(w, "Total time:  %v\n", r.Total)
+	_, _ = fmt.Fprintf(w, "Errors:      %d (%.2f%%)\n", r.Errors, r.ErrorRate()*100)
+	_, _ = fmt.Fprintf(w, "Latency:\n")
+	_, _ = fmt.Fprintf(w, "  min:  %v\n", r.Min)
+	_, _ = fmt.Fprintf(w, "  mean: %v\n", r.Mean)
+	_, _ = fmt.Fprintf(w, "  p50:  %v\n", r.P50)
+	_, _ = fmt.Fprintf(w, "  p95:  %v\n", r.P95)
+	_, _ = fmt.Fprintf(w, "  p99:  %v\n", r.P99)
+	_, _ = fmt.Fprintf(w, "  max:  %v\n", r.Max)
+}
+
+// runBenchmark runs the function the given number of times, with up to `concurrency`
+// executions in parallel, and aggregates the latencies and errors
+func runBenchmark(iterations, concurrency int, run func() error) benchReport {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if concurrency > iterations {
+		concurrency = iterations
+	}
+
+	latencies := make([]time.Duration, iterations)
+	failed := make([]bool, iterations)
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				begin := time.Now()
+				err := run()
+				latencies[i] = time.Since(begin)
+				failed[i] = err != nil
+			}
+		}()
+	}
+	for i := 0; i < iterations; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	report := benchReport{
+		Iterations:  iterations,
+		Concurrency: concurrency,
+		Total:       time.Since(start),
+	}
+	if iterations == 0 {
+		return report
+	}
+
+	var sum time.Duration
+	for i, latency := range latencies {
+		sum += latency
+		if failed[i] {
+			report.Errors++
+		}
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	report.Min = latencies[0]
+	report.Max = latencies[len(latencies)-1]
+	report.Mean = sum / time.Duration(len(latencies))
+	report.P50 = percentile(latencies, 50)
+	report.P95 = percentile(latencies, 95)
+	report.P99 = percentile(latencies, 99)
+	return report
+}
+
+// percentile returns the p-th percentile (nearest-rank method) of the sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
+
+// benchCommand is a command that measures the execution latency of a MCP tool
+var benchCommand = &cobra.Command{
+	Use:   "bench",
+	Short: "Measure the execution latency of a MCP tool",
+	Long: `
+Benchmark the execution of a MCP tool.
+
+This command executes a MCP tool repeatedly, with the given parameters,
+following the same process as the "exe" command (constraint evaluation,
+runner selection and tool execution), and reports the latency percentiles
+and the error rate.
+
+For example, you can run:
+
+$ mcpshell bench --tools examples/config.yaml --iterations 100 --concurrency 4 "hello_world" "name=John"
+
+and it will run the "hello_world" tool 100 times, with 4 executions in parallel.
+`,
+	Args: cobra.MinimumNArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// Initialize logger
+		logger, err := initLogger()
+		if err != nil {
+			return err
+		}
+
+		// Check if config file is provided
+		if len(toolsFiles) == 0 {
+			logger.Error("Tools configuration file(s) are required")
+			return fmt.Errorf("tools configuration file(s) are required. Use --tools flag to specify the path(s)")
+		}
+
+		if benchIterations < 1 {
+			return fmt.Errorf("the number of iterations must be at least 1")
+		}
+		if benchConcurrency < 1 {
+			return fmt.Errorf("the concurrency must be at least 1")
+		}
+
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Get the logger
+		logger := common.GetLogger()
+
+		// Setup panic handler
+		defer common.RecoverPanic()
+
+		toolName := args[0]
+		logger.Info("Benchmarking tool '%s': %d iterations, concurrency %d", toolName, benchIterations, benchConcurrency)
+
+		handler, params, err := newToolHandler(toolName, args[1:], nil, nil, logger)
+		if err != nil {
+			return err
+		}
+
+		// Measure the executions of the command, not the hits of the cache
+		handler.DisableCache()
+
+		defer command.StopPersistentContainers(logger)
+
+		report := runBenchmark(benchIterations, benchConcurrency, func() error {
+			_, err := handler.ExecuteCommand(params)
+			if err != nil {
+				logger.Debug("Tool execution failed: %v", err)
+			}
+			return err
+		})
+
+		report.Print(os.Stdout)
+		return nil
+	},
+}
+
+// init adds the bench command to the root command
+func init() {
+	rootCmd.AddCommand(benchCommand)
+
+	benchCommand.Flags().IntVarP(&benchIterations, "iterations", "n", 10, "Number of times the tool is executed")
+	benchCommand.Flags().IntVarP(&benchConcurrency, "concurrency", "c", 1, "Number of executions running in parallel")
+
+	// Mark required flags
+	_ = benchCommand.MarkFlagRequired("tools")
+}
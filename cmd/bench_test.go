@@ -0,0 +1,109 @@
+package root
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/inercia/MCPShell/pkg/common"
+)
+
+func TestPercentile(t *testing.T) {
+	var latencies []time.Duration
+	for i := 1; i <= 100; i++ {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{p: 50, want: 50 * time.Millisecond},
+		{p: 95, want: 95 * time.Millisecond},
+		{p: 99, want: 99 * time.Millisecond},
+		{p: 100, want: 100 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := percentile(latencies, tt.p); got != tt.want {
+			t.Errorf("percentile(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+
+	if got := percentile([]time.Duration{7 * time.Millisecond}, 99); got != 7*time.Millisecond {
+		t.Errorf("percentile() with a single sample = %v, want 7ms", got)
+	}
+}
+
+func TestRunBenchmarkErrors(t *testing.T) {
+	count := 0
+	report := runBenchmark(4, 1, func() error {
+		count++
+		if count%2 == 0 {
+			return errors.New("failed")
+		}
+		return nil
+	})
+
+	if report.Errors != 2 {
+		t.Errorf("Expected 2 errors, got %d", report.Errors)
+	}
+	if report.ErrorRate() != 0.5 {
+		t.Errorf("Expected an error rate of 0.5, got %v", report.ErrorRate())
+	}
+}
+
+func TestBenchTool(t *testing.T) {
+	testConfigFile := filepath.Join(t.TempDir(), "config.yaml")
+	configContent := `mcp:
+  tools:
+    - name: "fast_tool"
+      description: "A fast tool"
+      params:
+        name:
+          type: string
+          description: "Name to echo"
+          required: true
+      run:
+        command: "echo {{ .name }}"
+`
+	if err := os.WriteFile(testConfigFile, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+
+	oldToolsFiles := toolsFiles
+	toolsFiles = []string{testConfigFile}
+	defer func() { toolsFiles = oldToolsFiles }()
+
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+	handler, params, err := newToolHandler("fast_tool", []string{"name=bench"}, nil, nil, logger)
+	if err != nil {
+		t.Fatalf("newToolHandler() error = %v", err)
+	}
+
+	report := runBenchmark(5, 2, func() error {
+		_, err := handler.ExecuteCommand(params)
+		return err
+	})
+
+	if report.Iterations != 5 || report.Concurrency != 2 {
+		t.Errorf("Expected 5 iterations with concurrency 2, got %d and %d", report.Iterations, report.Concurrency)
+	}
+	if report.Errors != 0 {
+		t.Errorf("Expected no errors, got %d", report.Errors)
+	}
+	if report.Min <= 0 || report.Min > report.P50 || report.P50 > report.P95 || report.P95 > report.P99 || report.P99 > report.Max {
+		t.Errorf("Expected ordered latencies, got %+v", report)
+	}
+
+	var out bytes.Buffer
+	report.Print(&out)
+	for _, field := range []string{"p50:", "p95:", "p99:", "Errors:      0 (0.00%)"} {
+		if !strings.Contains(out.String(), field) {
+			t.Errorf("Expected the report to include %q, got:\n%s", field, out.String())
+		}
+	}
+}
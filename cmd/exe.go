@@ -1,8 +1,11 @@
 package root
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"strings"
+	"time"
 
 	"github.com/inercia/MCPShell/pkg/command"
 	"github.com/inercia/MCPShell/pkg/common"
@@ -10,6 +13,25 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	exeSafe    bool
+	exeParams  []string
+	exeTimeout string
+	exeJSON    bool
+)
+
+// paramTypeAliases maps the short type names accepted by --param to the parameter types
+var paramTypeAliases = map[string]string{
+	"str":     "string",
+	"string":  "string",
+	"int":     "integer",
+	"integer": "integer",
+	"float":   "number",
+	"number":  "number",
+	"bool":    "boolean",
+	"boolean": "boolean",
+}
+
 // exeCommand is a command that executes a MCP tool
 var exeCommand = &cobra.Command{
 	Use:   "exe",
@@ -21,7 +43,8 @@ This command will just execute a MCP tool, with the given parameters.
 Sometimes it is difficult to debug the execution of a MCP tool.
 This command will help you to debug the tool by executing it with
 the given parameters, following the whole process of constraint
-evaluation, tool selection and tool execution.
+evaluation, tool selection and tool execution (with the defaults and
+global constraints of the configuration applied, like the server does).
 
 For example, you can run:
 
@@ -36,6 +59,21 @@ $ mcpshell exe --tools file1.yaml,file2.yaml "hello_world" "name=John"
 Any error in the constraint evaluation, tool selection or tool execution
 will be reported.
 
+With --safe, tools marked as destructive are refused, so it can be used
+safely in demos or CI pipelines:
+
+$ mcpshell exe --safe --tools examples/config.yaml "delete_file" "path=/tmp/foo"
+
+Parameters can also be passed with --param, with an explicit type, so they
+do not depend on the type declared in the tool:
+
+$ mcpshell exe --tools examples/config.yaml "repeat" --param "count:int=5" --param "verbose:bool=true"
+
+With --stdin-json, the arguments are read from the standard input as a JSON object,
+for running tools from scripts and other programs (other parameters take precedence):
+
+$ echo '{"count": 5, "verbose": true}' | mcpshell exe --tools examples/config.yaml "repeat" --stdin-json
+
 `,
 	Args: cobra.MinimumNArgs(1),
 	PreRunE: func(cmd *cobra.Command, args []string) error {
@@ -69,119 +107,238 @@ will be reported.
 		toolName := args[0]
 		logger.Debug("Executing tool: %s", toolName)
 
-		// Load the configuration file(s) (local or remote)
-		localConfigPath, cleanup, err := config.ResolveMultipleConfigPaths(toolsFiles, logger)
-		if err != nil {
-			logger.Error("Failed to load configuration: %v", err)
-			return fmt.Errorf("failed to load configuration: %w", err)
+		// Read the arguments from the standard input, if requested
+		var jsonArgs map[string]interface{}
+		if exeJSON {
+			var err error
+			jsonArgs, err = readJSONArgs(cmd.InOrStdin())
+			if err != nil {
+				logger.Error("Invalid arguments in the standard input: %v", err)
+				return err
+			}
 		}
 
-		// Ensure temporary files are cleaned up
-		defer cleanup()
-
-		// Load the configuration
-		cfg, err := config.NewConfigFromFile(localConfigPath)
+		result, err := executeTool(toolName, args[1:], exeParams, jsonArgs, exeSafe, exeTimeout, logger)
 		if err != nil {
-			logger.Error("Failed to load configuration: %v", err)
-			return fmt.Errorf("failed to load configuration: %w", err)
+			return err
 		}
 
-		// Find the requested tool in the configuration
-		var targetTool *config.MCPToolConfig
-		for _, toolConfig := range cfg.MCP.Tools {
-			if toolConfig.Name == toolName {
-				targetTool = &toolConfig
-				break
-			}
-		}
+		// Print the result
+		fmt.Println(result)
+		return nil
+	},
+}
+
+// readJSONArgs reads the arguments of a tool from a JSON object
+func readJSONArgs(r io.Reader) (map[string]interface{}, error) {
+	var args map[string]interface{}
+	decoder := json.NewDecoder(r)
+	if err := decoder.Decode(&args); err != nil {
+		return nil, fmt.Errorf("failed to parse the JSON arguments (expected a JSON object): %w", err)
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("failed to parse the JSON arguments: unexpected data after the JSON object")
+	}
+	return args, nil
+}
 
-		if targetTool == nil {
-			logger.Error("Tool not found: %s", toolName)
-			return fmt.Errorf("tool not found: %s", toolName)
+// executeTool executes a tool with the given "name=value" and "name:type=value" parameters
+// (and the JSON arguments, if any), returning its output. In safe mode, tools marked as
+// destructive are refused. A non-empty timeout (like "5m", or "0" for no timeout) overrides
+// the one of the tool.
+func executeTool(toolName string, paramArgs []string, typedParamArgs []string, jsonArgs map[string]interface{}, safe bool, timeout string, logger *common.Logger) (string, error) {
+	handler, params, err := newToolHandler(toolName, paramArgs, typedParamArgs, jsonArgs, logger)
+	if err != nil {
+		return "", err
+	}
+
+	if timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err != nil || d < 0 {
+			logger.Error("Invalid timeout: %s", timeout)
+			return "", fmt.Errorf("invalid timeout format '%s'", timeout)
 		}
+		handler.SetTimeout(d)
+	}
 
-		// Parse parameters from the remaining arguments
-		params := make(map[string]interface{})
-		for _, arg := range args[1:] {
-			parts := strings.SplitN(arg, "=", 2)
-			if len(parts) != 2 {
-				logger.Error("Invalid parameter format: %s (expected name=value)", arg)
-				return fmt.Errorf("invalid parameter format: %s (expected name=value)", arg)
-			}
-			paramName := parts[0]
-			paramValue := parts[1]
-
-			// Check if parameter is defined in the tool
-			paramConfig, exists := targetTool.Params[paramName]
-			if !exists {
-				logger.Error("Parameter not defined in tool: %s", paramName)
-				return fmt.Errorf("parameter not defined in tool: %s", paramName)
-			}
+	if disableCache {
+		handler.DisableCache()
+	}
 
-			// Convert parameter value to appropriate type based on parameter config
-			typedValue, err := common.ConvertStringToType(paramValue, paramConfig.Type)
-			if err != nil {
-				logger.Error("Failed to convert parameter value: %v", err)
-				return fmt.Errorf("failed to convert parameter value: %w", err)
-			}
+	// Remove the persistent containers started for running the tool, if any
+	defer command.StopPersistentContainers(logger)
 
-			params[paramName] = typedValue
-		}
+	if safe && handler.IsDestructive() {
+		logger.Error("Refusing to run destructive tool '%s' in safe mode", toolName)
+		return "", fmt.Errorf("refusing to run tool '%s': it is marked as destructive and --safe is enabled", toolName)
+	}
 
-		// Apply default values for parameters that aren't provided but have defaults
-		for paramName, paramConfig := range targetTool.Params {
-			if _, exists := params[paramName]; !exists && paramConfig.Default != nil {
-				logger.Info("Using default value for parameter '%s': %v", paramName, paramConfig.Default)
-				params[paramName] = paramConfig.Default
-			}
-		}
+	// Execute the command directly
+	result, err := handler.ExecuteCommand(params)
+	if err != nil {
+		logger.Error("Command execution failed: %v", err)
+		return "", fmt.Errorf("command execution failed: %w", err)
+	}
 
-		// Check required parameters
-		for paramName, paramConfig := range targetTool.Params {
-			if paramConfig.Required {
-				if _, exists := params[paramName]; !exists {
-					logger.Error("Required parameter missing: %s", paramName)
-					return fmt.Errorf("required parameter missing: %s", paramName)
-				}
-			}
-		}
+	return result, nil
+}
 
-		// Use shell from config if present
-		shell := cfg.MCP.Run.Shell
-		if shell == "" {
-			shell = "sh"
+// newToolHandler loads the tools configuration, finds the given tool and creates a command
+// handler for it, with the defaults of the configuration (like the global constraints)
+// applied, checking its requirements and selecting its runner. The parameters are parsed
+// from "name=value" arguments (converted to the type declared in the tool) and from
+// "name:type=value" arguments (converted to the given type), on top of the JSON arguments
+// (decoded from a JSON object), with defaults applied and required ones checked. Parameters
+// not declared in the tool are handled by its 'unknown_params' policy when it is run.
+func newToolHandler(toolName string, paramArgs []string, typedParamArgs []string, jsonArgs map[string]interface{}, logger *common.Logger) (*command.CommandHandler, map[string]interface{}, error) {
+	// Load the configuration file(s) (local or remote)
+	localConfigPath, cleanup, err := config.ResolveConfigPathsWithOverlay(toolsFiles, toolsEnv, logger)
+	if err != nil {
+		logger.Error("Failed to load configuration: %v", err)
+		return nil, nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	// Ensure temporary files are cleaned up
+	defer cleanup()
+
+	// Load the configuration
+	cfg, err := config.NewConfigFromFile(localConfigPath)
+	if err != nil {
+		logger.Error("Failed to load configuration: %v", err)
+		return nil, nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	// Find the requested tool in the configuration
+	var targetTool *config.MCPToolConfig
+	for _, toolConfig := range cfg.MCP.Tools {
+		if toolConfig.Name == toolName {
+			targetTool = &toolConfig
+			break
 		}
-
-		// Create a Tool and check requirements to select the appropriate runner
-		tool := config.Tool{
-			MCPTool: config.CreateMCPTool(*targetTool),
-			Config:  *targetTool,
+	}
+
+	if targetTool == nil {
+		logger.Error("Tool not found: %s", toolName)
+		return nil, nil, fmt.Errorf("tool not found: %s", toolName)
+	}
+
+	// Start with the JSON arguments, that have the types of the arguments of MCP clients
+	params := make(map[string]interface{})
+	for paramName, value := range jsonArgs {
+		params[paramName] = value
+	}
+
+	// Parse parameters from the remaining arguments
+	for _, arg := range paramArgs {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 {
+			logger.Error("Invalid parameter format: %s (expected name=value)", arg)
+			return nil, nil, fmt.Errorf("invalid parameter format: %s (expected name=value)", arg)
 		}
-
-		// Check tool requirements and select runner
-		if !tool.CheckToolRequirements() {
-			logger.Error("Tool requirements not met - no suitable runner found")
-			return fmt.Errorf("tool requirements not met - no suitable runner found")
+		paramName := parts[0]
+		paramValue := parts[1]
+
+		// Parameters not defined in the tool are kept as strings, and the 'unknown_params'
+		// policy of the tool decides what to do with them (like for MCP clients)
+		paramConfig, exists := targetTool.Params[paramName]
+		if !exists {
+			params[paramName] = paramValue
+			continue
 		}
 
-		// Create a command handler
-		handler, err := command.NewCommandHandler(tool, targetTool.Params, shell, logger)
+		// Convert parameter value to appropriate type based on parameter config
+		typedValue, err := common.ConvertStringToParam(paramValue, paramConfig)
 		if err != nil {
-			logger.Error("Failed to create command handler: %v", err)
-			return fmt.Errorf("failed to create command handler: %w", err)
+			logger.Error("Failed to convert parameter value: %v", err)
+			return nil, nil, fmt.Errorf("failed to convert parameter value: %w", err)
 		}
 
-		// Execute the command directly
-		result, err := handler.ExecuteCommand(params)
+		params[paramName] = typedValue
+	}
+
+	// Parse the typed parameters, that take precedence over the untyped ones
+	for _, arg := range typedParamArgs {
+		paramName, typedValue, err := parseTypedParam(arg)
 		if err != nil {
-			logger.Error("Command execution failed: %v", err)
-			return fmt.Errorf("command execution failed: %w", err)
+			logger.Error("Invalid parameter: %v", err)
+			return nil, nil, err
+		}
+		params[paramName] = typedValue
+	}
+
+	// Apply default values for parameters that aren't provided but have defaults
+	for paramName, paramConfig := range targetTool.Params {
+		if _, exists := params[paramName]; !exists && paramConfig.Default != nil {
+			logger.Info("Using default value for parameter '%s': %v", paramName, paramConfig.Default)
+			params[paramName] = paramConfig.Default
+		}
+	}
+
+	// Check required parameters
+	for paramName, paramConfig := range targetTool.Params {
+		if paramConfig.Required {
+			if _, exists := params[paramName]; !exists {
+				logger.Error("Required parameter missing: %s", paramName)
+				return nil, nil, fmt.Errorf("required parameter missing: %s", paramName)
+			}
+		}
+	}
+
+	// Use shell from config if present
+	shell := cfg.MCP.Run.Shell
+	if shell == "" {
+		shell = "sh"
+	}
+
+	// Get the tool like the server does, with the defaults of the configuration (like the
+	// global constraints or the timeout) applied, and with its requirements checked
+	var tool *config.Tool
+	for _, t := range cfg.GetTools() {
+		if t.Config.Name == toolName {
+			tool = &t
+			break
 		}
+	}
+	if tool == nil {
+		logger.Error("Tool '%s' is disabled or its requirements are not met", toolName)
+		return nil, nil, fmt.Errorf("tool '%s' is disabled (by its 'enabled_if') or its requirements are not met - no suitable runner found", toolName)
+	}
+
+	// Create a command handler
+	handler, err := command.NewCommandHandler(*tool, tool.Config.Params, shell, logger)
+	if err != nil {
+		logger.Error("Failed to create command handler: %v", err)
+		return nil, nil, fmt.Errorf("failed to create command handler: %w", err)
+	}
+
+	return handler, params, nil
+}
 
-		// Print the result
-		fmt.Println(result)
-		return nil
-	},
+// parseTypedParam parses a "name:type=value" argument, converting the value to the given type.
+// When the type is omitted ("name=value"), the value is kept as a string.
+func parseTypedParam(arg string) (string, interface{}, error) {
+	parts := strings.SplitN(arg, "=", 2)
+	if len(parts) != 2 {
+		return "", nil, fmt.Errorf("invalid parameter format: %s (expected name:type=value)", arg)
+	}
+
+	paramName, paramType := parts[0], "string"
+	if name, typeName, found := strings.Cut(parts[0], ":"); found {
+		alias, ok := paramTypeAliases[strings.ToLower(typeName)]
+		if !ok {
+			return "", nil, fmt.Errorf("invalid type '%s' for parameter %s (must be string, int, float or bool)", typeName, name)
+		}
+		paramName, paramType = name, alias
+	}
+	if paramName == "" {
+		return "", nil, fmt.Errorf("invalid parameter format: %s (expected name:type=value)", arg)
+	}
+
+	value, err := common.ConvertStringToType(parts[1], paramType)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to convert parameter %s: %w", paramName, err)
+	}
+	return paramName, value, nil
 }
 
 // init adds the exe command to the root command
@@ -189,6 +346,12 @@ func init() {
 	// Add exe command to root
 	rootCmd.AddCommand(exeCommand)
 
+	exeCommand.Flags().BoolVar(&exeSafe, "safe", false, "Refuse to run tools marked as destructive")
+	exeCommand.Flags().StringVar(&exeTimeout, "timeout", "", "Timeout for the execution of the tool, like '30s' or '5m' ('0' for no timeout, default: the timeout of the tool, or 60s)")
+	exeCommand.Flags().BoolVar(&exeJSON, "stdin-json", false, "Read the arguments of the tool from the standard input, as a JSON object")
+	exeCommand.Flags().BoolVar(&disableCache, "no-cache", false, "Disable the cache of the tool output, always running the command")
+	exeCommand.Flags().StringArrayVar(&exeParams, "param", nil, "Typed parameter in the form name:type=value, with type string, int, float or bool (can be repeated)")
+
 	// Mark required flags
 	_ = exeCommand.MarkFlagRequired("tools")
 }
@@ -0,0 +1,317 @@
+package root
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/inercia/MCPShell/pkg/command"
+	"github.com/inercia/MCPShell/pkg/common"
+)
+
+func TestExecuteToolSafeMode(t *testing.T) {
+	testConfigFile := filepath.Join(t.TempDir(), "config.yaml")
+	configContent := `mcp:
+  tools:
+    - name: "remove_cache"
+      description: "Removes the cache"
+      destructive: true
+      run:
+        command: "echo removed"
+    - name: "show_cache"
+      description: "Shows the cache"
+      read_only: true
+      run:
+        command: "echo cached"
+`
+	if err := os.WriteFile(testConfigFile, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+
+	oldToolsFiles := toolsFiles
+	toolsFiles = []string{testConfigFile}
+	defer func() { toolsFiles = oldToolsFiles }()
+
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+
+	// destructive tools are blocked in safe mode...
+	if _, err := executeTool("remove_cache", nil, nil, nil, true, "", logger); err == nil || !strings.Contains(err.Error(), "destructive") {
+		t.Errorf("executeTool() in safe mode error = %v, want a destructive tool error", err)
+	}
+
+	// ... but run without it
+	output, err := executeTool("remove_cache", nil, nil, nil, false, "", logger)
+	if err != nil {
+		t.Fatalf("executeTool() error = %v", err)
+	}
+	if output != "removed" {
+		t.Errorf("executeTool() = %q, want %q", output, "removed")
+	}
+
+	// other tools run in safe mode
+	output, err = executeTool("show_cache", nil, nil, nil, true, "", logger)
+	if err != nil {
+		t.Fatalf("executeTool() in safe mode error = %v", err)
+	}
+	if output != "cached" {
+		t.Errorf("executeTool() = %q, want %q", output, "cached")
+	}
+}
+
+func TestParseTypedParam(t *testing.T) {
+	tests := []struct {
+		arg       string
+		wantName  string
+		wantValue interface{}
+		wantErr   bool
+	}{
+		{arg: "count:int=5", wantName: "count", wantValue: int64(5)},
+		{arg: "ratio:float=0.5", wantName: "ratio", wantValue: 0.5},
+		{arg: "verbose:bool=true", wantName: "verbose", wantValue: true},
+		{arg: "name:string=a:b=c", wantName: "name", wantValue: "a:b=c"},
+		{arg: "name=John", wantName: "name", wantValue: "John"},
+		{arg: "count:int=five", wantErr: true},
+		{arg: "count:decimal=5", wantErr: true},
+		{arg: "count", wantErr: true},
+		{arg: ":int=5", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			name, value, err := parseTypedParam(tt.arg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTypedParam(%q) error = %v, wantErr %v", tt.arg, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if name != tt.wantName || value != tt.wantValue {
+				t.Errorf("parseTypedParam(%q) = %q, %#v, want %q, %#v", tt.arg, name, value, tt.wantName, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestExecuteToolTypedParams(t *testing.T) {
+	testConfigFile := filepath.Join(t.TempDir(), "config.yaml")
+	configContent := `mcp:
+  tools:
+    - name: "repeat"
+      description: "Repeats a word"
+      params:
+        count:
+          type: integer
+          description: "Number of repetitions"
+          required: true
+      constraints:
+        - "count > 3.0 && count < 10.0"
+      run:
+        command: "seq {{ .count }} | wc -l"
+`
+	if err := os.WriteFile(testConfigFile, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+
+	oldToolsFiles := toolsFiles
+	toolsFiles = []string{testConfigFile}
+	defer func() { toolsFiles = oldToolsFiles }()
+
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+
+	output, err := executeTool("repeat", nil, []string{"count:int=5"}, nil, false, "", logger)
+	if err != nil {
+		t.Fatalf("executeTool() error = %v", err)
+	}
+	if strings.TrimSpace(output) != "5" {
+		t.Errorf("executeTool() = %q, want %q", output, "5")
+	}
+
+	// the typed value is still checked by the constraints
+	if _, err := executeTool("repeat", nil, []string{"count:int=50"}, nil, false, "", logger); err == nil {
+		t.Error("executeTool() with count=50 error = nil, want a constraint error")
+	}
+}
+
+func TestExecuteToolTimeout(t *testing.T) {
+	testConfigFile := filepath.Join(t.TempDir(), "config.yaml")
+	configContent := `mcp:
+  tools:
+    - name: "slow"
+      description: "Takes its time"
+      run:
+        command: "sleep 5 && echo done"
+`
+	if err := os.WriteFile(testConfigFile, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+
+	oldToolsFiles := toolsFiles
+	toolsFiles = []string{testConfigFile}
+	defer func() { toolsFiles = oldToolsFiles }()
+
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+
+	start := time.Now()
+	_, err := executeTool("slow", nil, nil, nil, false, "1s", logger)
+	if !errors.Is(err, command.ErrTimeout) {
+		t.Fatalf("executeTool() with a short timeout error = %v, want a timeout error", err)
+	}
+	if elapsed := time.Since(start); elapsed > 4*time.Second {
+		t.Errorf("executeTool() took %s, the timeout was not applied", elapsed)
+	}
+
+	if _, err := executeTool("slow", nil, nil, nil, false, "soon", logger); err == nil || !strings.Contains(err.Error(), "invalid timeout") {
+		t.Errorf("executeTool() with an invalid timeout error = %v, want an invalid timeout error", err)
+	}
+}
+
+func TestExecuteToolGlobalDefaults(t *testing.T) {
+	testConfigFile := filepath.Join(t.TempDir(), "config.yaml")
+	configContent := `mcp:
+  run:
+    timeout: "1s"
+  global_constraints:
+    - "!path.contains('..')"
+  tools:
+    - name: "cat_file"
+      description: "Shows a file"
+      params:
+        path:
+          type: string
+          description: "Path of the file"
+          required: true
+      run:
+        command: "echo {{ .path }}"
+    - name: "slow"
+      description: "Takes its time"
+      run:
+        command: "sleep 5 && echo done"
+`
+	if err := os.WriteFile(testConfigFile, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+
+	oldToolsFiles := toolsFiles
+	toolsFiles = []string{testConfigFile}
+	defer func() { toolsFiles = oldToolsFiles }()
+
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+
+	output, err := executeTool("cat_file", []string{"path=notes.txt"}, nil, nil, false, "", logger)
+	if err != nil || output != "notes.txt" {
+		t.Fatalf("executeTool() = %q, %v, want %q", output, err, "notes.txt")
+	}
+
+	// the global constraints are applied...
+	if _, err := executeTool("cat_file", []string{"path=../secrets"}, nil, nil, false, "", logger); !errors.Is(err, command.ErrConstraintBlocked) {
+		t.Errorf("executeTool() with a blocked path error = %v, want a constraint error", err)
+	}
+
+	// ... and the default timeout too
+	start := time.Now()
+	if _, err := executeTool("slow", nil, nil, nil, false, "", logger); !errors.Is(err, command.ErrTimeout) {
+		t.Errorf("executeTool() error = %v, want a timeout error", err)
+	}
+	if elapsed := time.Since(start); elapsed > 4*time.Second {
+		t.Errorf("executeTool() took %s, the default timeout was not applied", elapsed)
+	}
+}
+
+func TestExecuteToolStdinJSON(t *testing.T) {
+	testConfigFile := filepath.Join(t.TempDir(), "config.yaml")
+	configContent := `mcp:
+  tools:
+    - name: "greet"
+      description: "Greets someone several times"
+      params:
+        name:
+          type: string
+          description: "Name of the person"
+          required: true
+        count:
+          type: integer
+          description: "Number of greetings"
+          required: true
+        loud:
+          type: boolean
+          description: "Whether to shout"
+      constraints:
+        - "count > 0.0 && count < 5.0"
+      run:
+        command: "echo '{{ .name }} x{{ .count }}{{ if .loud }}!{{ end }}'"
+    - name: "strict_echo"
+      description: "Echoes a message, rejecting other arguments"
+      unknown_params: strict
+      params:
+        message:
+          type: string
+          required: true
+      run:
+        command: "echo '{{ .message }}'"
+`
+	if err := os.WriteFile(testConfigFile, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+
+	oldToolsFiles := toolsFiles
+	toolsFiles = []string{testConfigFile}
+	defer func() { toolsFiles = oldToolsFiles }()
+
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+
+	jsonArgs, err := readJSONArgs(strings.NewReader(`{"name": "John", "count": 3, "loud": true}`))
+	if err != nil {
+		t.Fatalf("readJSONArgs() error = %v", err)
+	}
+
+	output, err := executeTool("greet", nil, nil, jsonArgs, false, "", logger)
+	if err != nil {
+		t.Fatalf("executeTool() error = %v", err)
+	}
+	if output != "John x3!" {
+		t.Errorf("executeTool() = %q, want %q", output, "John x3!")
+	}
+
+	// the other parameters take precedence over the JSON arguments
+	output, err = executeTool("greet", []string{"name=Jane"}, nil, jsonArgs, false, "", logger)
+	if err != nil {
+		t.Fatalf("executeTool() error = %v", err)
+	}
+	if output != "Jane x3!" {
+		t.Errorf("executeTool() = %q, want %q", output, "Jane x3!")
+	}
+
+	// the JSON arguments are checked like any other arguments
+	if _, err := executeTool("greet", nil, nil, map[string]interface{}{"name": "John", "count": 7.0}, false, "", logger); err == nil {
+		t.Error("executeTool() with count=7 error = nil, want a constraint error")
+	}
+
+	// the arguments not declared in the tool follow its 'unknown_params' policy
+	// (passed through by default)
+	output, err = executeTool("greet", []string{"extra=1"}, []string{"more:int=2"}, map[string]interface{}{"name": "John", "count": 1.0, "other": 1.0}, false, "", logger)
+	if err != nil || output != "John x1" {
+		t.Errorf("executeTool() with unknown arguments = %q, %v, want %q", output, err, "John x1")
+	}
+	for _, tt := range []struct {
+		params, typedParams []string
+		jsonArgs            map[string]interface{}
+	}{
+		{params: []string{"message=hi", "other=1"}},
+		{params: []string{"message=hi"}, typedParams: []string{"other:int=1"}},
+		{jsonArgs: map[string]interface{}{"message": "hi", "other": 1.0}},
+	} {
+		if _, err := executeTool("strict_echo", tt.params, tt.typedParams, tt.jsonArgs, false, "", logger); err == nil ||
+			!strings.Contains(err.Error(), "unknown parameters for tool 'strict_echo': other") {
+			t.Errorf("executeTool() with an unknown argument %+v error = %v", tt, err)
+		}
+	}
+
+	for _, input := range []string{"", "[1, 2]", "{invalid", `{"name": "John"} {"count": 3}`, `{"name": "John"} trailing`} {
+		if _, err := readJSONArgs(strings.NewReader(input)); err == nil {
+			t.Errorf("readJSONArgs(%q) error = nil, want an error", input)
+		}
+	}
+}
@@ -8,15 +8,20 @@ import (
 
 	"github.com/inercia/MCPShell/pkg/common"
 	"github.com/inercia/MCPShell/pkg/config"
+	"github.com/inercia/MCPShell/pkg/embedded"
 	"github.com/inercia/MCPShell/pkg/server"
 	"github.com/inercia/MCPShell/pkg/utils"
 	"github.com/spf13/cobra"
 )
 
 var (
-	useHTTP  bool
-	httpPort int
-	daemon   bool
+	useHTTP    bool
+	httpPort   int
+	daemon     bool
+	envFile    string
+	transport  string
+	listenAddr string
+	watchTools bool
 )
 
 // mcpCommand represents the run command which starts the MCP server
@@ -32,8 +37,16 @@ and expooses the tools defined in a MCP configuration file.
 The server loads tool definitions from a MCP configuration file and makes them
 available to AI applications via the MCP protocol.
 
-When using --http mode, you can also use --daemon to run the server in the background
-and ignore SIGHUP signals.
+By default the server talks to a single client through stdio. Use --transport sse
+(with --listen for the address) for serving multiple clients over HTTP with
+Server-Sent Events.
+
+When using --http mode or the sse transport, you can also use --daemon to run the
+server in the background and ignore SIGHUP signals.
+
+With --watch, the server reloads the tools when the local tools configuration files
+change, without restarting (clients are notified that the list of tools changed).
+When the new configuration is not valid, the server keeps the previous tools.
 `,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
 		// Initialize logger
@@ -46,8 +59,8 @@ and ignore SIGHUP signals.
 
 		logger.Info("Starting MCPShell")
 
-		// Check if config files are provided
-		if len(toolsFiles) == 0 {
+		// Check if config files are provided (they are optional with the built-in or embedded tools)
+		if len(toolsFiles) == 0 && !enableBuiltins && !embeddedConfig {
 			logger.Error("Tools configuration file(s) are required")
 			return fmt.Errorf("tools configuration file(s) are required. Use --tools flag to specify the path(s)")
 		}
@@ -58,10 +71,27 @@ and ignore SIGHUP signals.
 			return fmt.Errorf("failed to ensure tools directory: %w", err)
 		}
 
-		// Daemon mode is only supported with HTTP mode
-		if daemon && !useHTTP {
-			logger.Error("Daemon mode is only supported with HTTP mode")
-			return fmt.Errorf("daemon mode is only supported with HTTP mode (use --http flag)")
+		if transport != server.TransportStdio && transport != server.TransportSSE {
+			logger.Error("Unknown transport: %s", transport)
+			return fmt.Errorf("unknown transport '%s' (must be '%s' or '%s')", transport, server.TransportStdio, server.TransportSSE)
+		}
+
+		// HTTP mode has its own endpoints, so it cannot be combined with the sse transport
+		if useHTTP && transport == server.TransportSSE {
+			logger.Error("HTTP mode cannot be used with the sse transport")
+			return fmt.Errorf("--http cannot be used with --transport %s (use one of them)", server.TransportSSE)
+		}
+
+		// Daemon mode is only supported when serving over HTTP
+		if daemon && !useHTTP && transport != server.TransportSSE {
+			logger.Error("Daemon mode is only supported with HTTP mode or the sse transport")
+			return fmt.Errorf("daemon mode is only supported with HTTP mode or the sse transport (use --http or --transport sse)")
+		}
+
+		// Only local configuration files can be watched
+		if watchTools && len(config.LocalConfigPaths(toolsFiles)) == 0 {
+			logger.Error("Watch mode requires local tools configuration files")
+			return fmt.Errorf("watch mode requires local tools configuration files (use --tools with local files or directories)")
 		}
 
 		return nil
@@ -79,15 +109,50 @@ and ignore SIGHUP signals.
 			logger.Info("Daemonized successfully")
 		}
 
+		// Load the env file before the configuration, so tools can pass its variables through
+		if envFile != "" {
+			if err := utils.LoadEnvFile(envFile); err != nil {
+				logger.Error("Failed to load env file: %v", err)
+				return fmt.Errorf("failed to load env file: %w", err)
+			}
+			logger.Info("Loaded environment variables from %s", envFile)
+		}
+
 		// Load the configuration file(s) (local or remote)
-		localConfigPath, cleanup, err := config.ResolveMultipleConfigPaths(toolsFiles, logger)
-		if err != nil {
-			logger.Error("Failed to load configuration: %v", err)
-			return fmt.Errorf("failed to load configuration: %w", err)
+		localConfigPath := ""
+		if len(toolsFiles) == 0 && embeddedConfig {
+			var cleanup func()
+			var err error
+			localConfigPath, cleanup, err = config.ResolveEmbeddedConfig(embedded.Tools(), logger)
+			if err != nil {
+				logger.Error("Failed to load embedded configuration: %v", err)
+				return fmt.Errorf("failed to load embedded configuration (the binary must be built with '-tags embedded_tools'): %w", err)
+			}
+
+			// Ensure temporary files are cleaned up
+			defer cleanup()
+		} else if len(toolsFiles) > 0 {
+			var cleanup func()
+			var err error
+			localConfigPath, cleanup, err = config.ResolveConfigPathsWithOverlay(toolsFiles, toolsEnv, logger)
+			if err != nil {
+				logger.Error("Failed to load configuration: %v", err)
+				return fmt.Errorf("failed to load configuration: %w", err)
+			}
+
+			// Ensure temporary files are cleaned up
+			defer cleanup()
 		}
 
-		// Ensure temporary files are cleaned up
-		defer cleanup()
+		// Watch the local files the configuration is resolved from, resolving it again on changes
+		var watchPaths []string
+		var resolveConfig func() (string, func(), error)
+		if watchTools {
+			watchPaths = config.LocalConfigPaths(append(append([]string{}, toolsFiles...), toolsEnv))
+			resolveConfig = func() (string, func(), error) {
+				return config.ResolveConfigPathsWithOverlay(toolsFiles, toolsEnv, logger)
+			}
+		}
 
 		// Create and start the server
 		srv := server.New(server.Config{
@@ -97,20 +162,32 @@ and ignore SIGHUP signals.
 			Descriptions:        description,
 			DescriptionFiles:    descriptionFile,
 			DescriptionOverride: descriptionOverride,
+			HideDeprecated:      hideDeprecated,
+			EnableBuiltins:      enableBuiltins,
+			OutputDir:           outputDir,
+			DisableCache:        disableCache,
+			Transport:           transport,
+			ListenAddr:          listenAddr,
+			Watch:               watchTools,
+			WatchPaths:          watchPaths,
+			ResolveConfig:       resolveConfig,
 		})
 
+		// Set up SIGHUP handling for daemon mode and for reopening the log file
+		if daemon || logger.FilePath() != "" {
+			setupSIGHUPHandler(logger)
+		}
+
 		if useHTTP {
-			// Set up SIGHUP handling for daemon mode
-			if daemon {
-				setupSIGHUPHandler(logger)
-			}
 			return srv.StartHTTP(httpPort)
 		}
 		return srv.Start()
 	},
 }
 
-// setupSIGHUPHandler sets up signal handling to ignore SIGHUP in daemon mode
+// setupSIGHUPHandler sets up signal handling for SIGHUP: instead of terminating
+// the process, the log file (if any) is reopened, so external log rotators can
+// move it away and signal MCPShell to start a new one.
 func setupSIGHUPHandler(logger *common.Logger) {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGHUP)
@@ -118,9 +195,18 @@ func setupSIGHUPHandler(logger *common.Logger) {
 	go func() {
 		for {
 			sig := <-sigChan
-			if sig == syscall.SIGHUP {
+			if sig != syscall.SIGHUP {
+				continue
+			}
+			if logger.FilePath() == "" {
 				logger.Info("Received SIGHUP, ignoring in daemon mode")
+				continue
+			}
+			if err := logger.Reopen(); err != nil {
+				logger.Error("Received SIGHUP, but failed to reopen the log file: %v", err)
+				continue
 			}
+			logger.Info("Received SIGHUP, reopened log file: %s", logger.FilePath())
 		}
 	}()
 }
@@ -133,11 +219,22 @@ func init() {
 	mcpCommand.Flags().StringSliceVarP(&description, "description", "d", []string{}, "MCP server description (optional, can be specified multiple times)")
 	mcpCommand.Flags().StringSliceVarP(&descriptionFile, "description-file", "", []string{}, "Read the MCP server description from files (optional, can be specified multiple times)")
 	mcpCommand.Flags().BoolVarP(&descriptionOverride, "description-override", "", false, "Override the description found in the config file")
+	mcpCommand.Flags().BoolVar(&hideDeprecated, "hide-deprecated", false, "Do not register the tools marked as deprecated")
+	mcpCommand.Flags().StringVar(&outputDir, "output-dir", "", "Directory where the per-call output directories of the tools are created (default: the temporary directory)")
+	mcpCommand.Flags().BoolVar(&disableCache, "no-cache", false, "Disable the caches of the tool outputs, always running the commands")
+	mcpCommand.Flags().BoolVar(&embeddedConfig, "embedded-config", false, "Load the tools configuration embedded in the binary when no --tools are given")
+	mcpCommand.Flags().BoolVar(&enableBuiltins, "enable-builtins", false, "Register the built-in diagnostic tools (__echo, __sleep and __env), making the tools configuration optional")
+	mcpCommand.Flags().BoolVar(&watchTools, "watch", false, "Reload the tools when the local tools configuration files change")
+	mcpCommand.Flags().StringVar(&envFile, "env-file", "", "Load environment variables from a .env file (existing variables are not overridden)")
 
 	// Add HTTP server flags
 	mcpCommand.Flags().BoolVar(&useHTTP, "http", false, "Enable HTTP server mode (serve MCP over HTTP/SSE instead of stdio)")
 	mcpCommand.Flags().IntVar(&httpPort, "port", 8080, "Port for HTTP server (default: 8080, only used with --http)")
-	mcpCommand.Flags().BoolVar(&daemon, "daemon", false, "Run in daemon mode (background process, ignores SIGHUP, only works with --http)")
+	mcpCommand.Flags().BoolVar(&daemon, "daemon", false, "Run in daemon mode (background process, ignores SIGHUP, only works with --http or --transport sse)")
+
+	// Add transport flags
+	mcpCommand.Flags().StringVar(&transport, "transport", server.TransportStdio, "Transport for serving clients: 'stdio' or 'sse'")
+	mcpCommand.Flags().StringVar(&listenAddr, "listen", server.DefaultListenAddr, "Address to listen on (only used with --transport sse)")
 
 	// Mark required flags
 	_ = mcpCommand.MarkFlagRequired("tools")
@@ -3,6 +3,7 @@ package root
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/inercia/MCPShell/pkg/common"
@@ -82,3 +83,32 @@ func TestFindToolName(t *testing.T) {
 	// and belongs to the server package
 	t.Skip("findToolByName functionality is now tested in the server package")
 }
+
+func TestMCPCommandHTTPWithSSETransport(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	oldLogLevel, oldToolsFiles, oldUseHTTP, oldTransport := logLevel, toolsFiles, useHTTP, transport
+	defer func() {
+		logLevel, toolsFiles, useHTTP, transport = oldLogLevel, oldToolsFiles, oldUseHTTP, oldTransport
+	}()
+	logLevel, toolsFiles = "none", []string{"config.yaml"}
+
+	for _, tt := range []struct {
+		useHTTP   bool
+		transport string
+		wantErr   bool
+	}{
+		{useHTTP: true, transport: server.TransportStdio},
+		{useHTTP: false, transport: server.TransportSSE},
+		{useHTTP: true, transport: server.TransportSSE, wantErr: true},
+	} {
+		useHTTP, transport = tt.useHTTP, tt.transport
+		err := mcpCommand.PreRunE(mcpCommand, nil)
+		if tt.wantErr && (err == nil || !strings.Contains(err.Error(), "--http cannot be used with --transport sse")) {
+			t.Errorf("PreRunE() with --http and --transport %s error = %v, want an error", tt.transport, err)
+		}
+		if !tt.wantErr && err != nil {
+			t.Errorf("PreRunE() with --http=%v and --transport %s error = %v", tt.useHTTP, tt.transport, err)
+		}
+	}
+}
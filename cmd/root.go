@@ -19,22 +19,34 @@ const ApplicationName = "mcpshell"
 var (
 	// Common flags
 	toolsFiles []string
+	toolsEnv   string
 	logFile    string
 	logLevel   string
 	verbose    bool
 
+	// Flags shared by the commands running tools
+	disableCache bool
+
 	// MCP server flags
 	description         []string
 	descriptionFile     []string
 	descriptionOverride bool
+	hideDeprecated      bool
+	enableBuiltins      bool
+	embeddedConfig      bool
+	outputDir           string
 
 	// Agent-specific flags
-	agentModel        string
-	agentSystemPrompt string
-	agentUserPrompt   string
-	agentOpenAIApiKey string
-	agentOpenAIApiURL string
-	agentOnce         bool
+	agentModel          string
+	agentSystemPrompt   string
+	agentUserPrompt     string
+	agentOpenAIApiKey   string
+	agentOpenAIApiURL   string
+	agentOnce           bool
+	agentApprove        string
+	agentHideDeprecated bool
+	agentMaxTools       int
+	agentContextFiles   []string
 
 	// Application version (can be overridden at build time)
 	version = "1.0.0"
@@ -66,6 +78,10 @@ When multiple configuration files are provided, they are merged with:
 - Prompts concatenated from all files
 - Tools combined from all files  
 - MCP description and run config taken from the first file
+
+Use --env to deep-merge an environment-specific overlay on top of the tools
+configuration (e.g. --tools base.yaml --env prod, for a prod.yaml overlay).
+The overlay can override any field: tools (and runners) are merged by name.
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		// If no subcommand is specified, show the help
@@ -89,6 +105,7 @@ func Execute() {
 func init() {
 	// Add common persistent flags
 	rootCmd.PersistentFlags().StringSliceVar(&toolsFiles, "tools", []string{}, "Path(s) to the tools configuration file(s).\nSupports multiple files via --tools=file1 --tools=file2 or --tools=file1,file2.\nEach path supports relative paths and auto .yaml extension.\nDefault look path from MCPSHELL_TOOLS_DIR")
+	rootCmd.PersistentFlags().StringVar(&toolsEnv, "env", "", "Overlay configuration deep-merged on top of the tools configuration (e.g. 'prod' for prod.yaml).\nIt is resolved like the --tools paths")
 	rootCmd.PersistentFlags().StringVarP(&logFile, "logfile", "l", "", "Path to the log file (optional)")
 	rootCmd.PersistentFlags().StringVarP(&logLevel, "log-level", "", "info", "Log level: none, error, info, debug")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose logging (sets log level to debug)")
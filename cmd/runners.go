@@ -0,0 +1,74 @@
+package root
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/inercia/MCPShell/pkg/command"
+)
+
+var runnersListJSON bool
+
+// runnersCommand is the parent command for the runners introspection commands
+var runnersCommand = &cobra.Command{
+	Use:   "runners",
+	Short: "Inspect the runners available for executing tools",
+}
+
+// runnersListCommand lists the types of runners, with their options and availability
+var runnersListCommand = &cobra.Command{
+	Use:   "list",
+	Short: "List the types of runners, their options and whether they can be used here",
+	Long: `
+List all the types of runners that can be used in the 'run.runners' of a tool,
+with the options each of them accepts, and whether it can be used in this system
+(for example, 'firejail' needs Linux and the firejail executable).
+
+$ mcpshell runners list
+$ mcpshell runners list --json
+`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger, err := initLogger()
+		if err != nil {
+			return err
+		}
+
+		return printRunners(cmd.OutOrStdout(), command.ListRunners(logger), runnersListJSON)
+	},
+}
+
+// printRunners writes the information about the runners, as JSON or as human-readable text
+func printRunners(w io.Writer, runners []command.RunnerInfo, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(runners)
+	}
+
+	for _, runner := range runners {
+		availability := "available"
+		if !runner.Available {
+			availability = "not available: " + runner.Reason
+		}
+		fmt.Fprintf(w, "%s (%s)\n", runner.Type, availability)
+
+		options := "(none)"
+		if len(runner.Options) > 0 {
+			options = strings.Join(runner.Options, ", ")
+		}
+		fmt.Fprintf(w, "  options: %s\n", options)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(runnersCommand)
+	runnersCommand.AddCommand(runnersListCommand)
+
+	runnersListCommand.Flags().BoolVar(&runnersListJSON, "json", false, "Output in JSON format (for easy parsing)")
+}
@@ -0,0 +1,40 @@
+package root
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/inercia/MCPShell/pkg/command"
+)
+
+func TestPrintRunners(t *testing.T) {
+	runners := command.ListRunners(nil)
+
+	var out bytes.Buffer
+	if err := printRunners(&out, runners, false); err != nil {
+		t.Fatalf("printRunners() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "exec (available)\n  options: shell, temp_dir\n") {
+		t.Errorf("Expected the exec runner to be listed as available, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := printRunners(&out, runners, true); err != nil {
+		t.Fatalf("printRunners() error = %v", err)
+	}
+	var decoded []command.RunnerInfo
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("Failed to decode the JSON output: %v\n%s", err, out.String())
+	}
+	found := false
+	for _, runner := range decoded {
+		if runner.Type == command.RunnerTypeExec {
+			found = runner.Available
+		}
+	}
+	if !found {
+		t.Errorf("Expected the exec runner to be available in the JSON output, got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,119 @@
+package root
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cobra"
+
+	"github.com/inercia/MCPShell/pkg/common"
+	"github.com/inercia/MCPShell/pkg/config"
+)
+
+// jsonSchemaDialect is the JSON Schema version of the documents generated for the tools
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// schemaCommand is a command that prints the JSON Schema of the arguments of the tools
+var schemaCommand = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of the arguments of the tools in a configuration",
+	Long: `
+Print the arguments of the tools in a configuration as JSON Schema documents.
+
+The output is a JSON object with a JSON Schema document for each tool (by the name
+of the tool), with the same input schema the MCP server sends to clients: the types,
+descriptions and validation keywords (like enum, minimum, maximum or pattern) of
+the parameters, and the required ones. It can be used for documentation or for
+validating the arguments in clients. For example:
+
+$ mcpshell schema --tools examples/config.yaml
+
+Tools that would not be registered by the server (because their prerequisites
+are not met in this system) are not included.
+`,
+	Args: cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// Initialize logger
+		logger, err := initLogger()
+		if err != nil {
+			return err
+		}
+
+		// Check if config file is provided
+		if len(toolsFiles) == 0 {
+			logger.Error("Tools configuration file(s) are required")
+			return fmt.Errorf("tools configuration file(s) are required. Use --tools flag to specify the path(s)")
+		}
+
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Get the logger
+		logger := common.GetLogger()
+
+		// Setup panic handler
+		defer common.RecoverPanic()
+
+		// Load the configuration file(s) (local or remote)
+		localConfigPath, cleanup, err := config.ResolveConfigPathsWithOverlay(toolsFiles, toolsEnv, logger)
+		if err != nil {
+			logger.Error("Failed to load configuration: %v", err)
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+
+		// Ensure temporary files are cleaned up
+		defer cleanup()
+
+		cfg, err := config.NewConfigFromFile(localConfigPath)
+		if err != nil {
+			logger.Error("Failed to load configuration: %v", err)
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+
+		return printToolSchemas(cmd.OutOrStdout(), cfg.GetTools())
+	},
+}
+
+// printToolSchemas writes a JSON object with the JSON Schema of the arguments of each tool
+func printToolSchemas(w io.Writer, tools []config.Tool) error {
+	schemas := make(map[string]interface{}, len(tools))
+	for _, tool := range tools {
+		schema, err := toolSchema(tool.MCPTool)
+		if err != nil {
+			return fmt.Errorf("failed to create the schema of tool '%s': %w", tool.MCPTool.Name, err)
+		}
+		schemas[tool.MCPTool.Name] = schema
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(schemas)
+}
+
+// toolSchema returns the input schema of a MCP tool as a standalone JSON Schema
+// document, with the name and description of the tool as its title and description
+func toolSchema(tool mcp.Tool) (map[string]interface{}, error) {
+	data, err := json.Marshal(tool.InputSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := map[string]interface{}{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, err
+	}
+
+	schema["$schema"] = jsonSchemaDialect
+	schema["title"] = tool.Name
+	if tool.Description != "" {
+		schema["description"] = tool.Description
+	}
+	return schema, nil
+}
+
+// init adds the schema command to the root command
+func init() {
+	rootCmd.AddCommand(schemaCommand)
+}
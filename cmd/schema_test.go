@@ -0,0 +1,77 @@
+package root
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/inercia/MCPShell/pkg/config"
+)
+
+func TestPrintToolSchemas(t *testing.T) {
+	testConfigFile := filepath.Join(t.TempDir(), "config.yaml")
+	configContent := `mcp:
+  tools:
+    - name: "greet"
+      description: "Greets someone"
+      params:
+        name:
+          type: string
+          description: "Name of the person"
+          required: true
+          max_length: 10
+        times:
+          type: number
+          min: 1
+          max: 5
+      run:
+        command: "echo hello {{ .name }}"
+`
+	if err := os.WriteFile(testConfigFile, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+
+	cfg, err := config.NewConfigFromFile(testConfigFile)
+	if err != nil {
+		t.Fatalf("NewConfigFromFile() error = %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := printToolSchemas(&out, cfg.GetTools()); err != nil {
+		t.Fatalf("printToolSchemas() error = %v", err)
+	}
+
+	var schemas map[string]struct {
+		Schema      string                            `json:"$schema"`
+		Title       string                            `json:"title"`
+		Description string                            `json:"description"`
+		Type        string                            `json:"type"`
+		Properties  map[string]map[string]interface{} `json:"properties"`
+		Required    []string                          `json:"required"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &schemas); err != nil {
+		t.Fatalf("Failed to decode the JSON output: %v\n%s", err, out.String())
+	}
+
+	schema, ok := schemas["greet"]
+	if !ok {
+		t.Fatalf("Expected a schema for the 'greet' tool, got:\n%s", out.String())
+	}
+	if schema.Schema != jsonSchemaDialect || schema.Title != "greet" || schema.Description != "Greets someone" || schema.Type != "object" {
+		t.Errorf("Unexpected schema header, got:\n%s", out.String())
+	}
+	if len(schema.Required) != 1 || schema.Required[0] != "name" {
+		t.Errorf("Expected 'name' to be required, got %v", schema.Required)
+	}
+
+	name := schema.Properties["name"]
+	if name["type"] != "string" || name["description"] != "Name of the person" || name["maxLength"] != float64(10) {
+		t.Errorf("Unexpected schema for 'name': %v", name)
+	}
+	times := schema.Properties["times"]
+	if times["type"] != "number" || times["minimum"] != float64(1) || times["maximum"] != float64(5) {
+		t.Errorf("Unexpected schema for 'times': %v", times)
+	}
+}
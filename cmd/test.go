@@ -0,0 +1,212 @@
+package root
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/inercia/MCPShell/pkg/command"
+	"github.com/inercia/MCPShell/pkg/common"
+	"github.com/inercia/MCPShell/pkg/config"
+)
+
+// exampleResult is the result of running one of the examples of a tool
+type exampleResult struct {
+	Tool        string
+	Index       int
+	Description string
+
+	// Passed is true when the outcome of the example was the expected one
+	Passed bool
+
+	// Problem explains why the example did not pass
+	Problem string
+}
+
+// String returns a one-line summary of the result
+func (r exampleResult) String() string {
+	name := fmt.Sprintf("%s #%d", r.Tool, r.Index+1)
+	if r.Description != "" {
+		name += " (" + r.Description + ")"
+	}
+	if r.Passed {
+		return "PASS " + name
+	}
+	return "FAIL " + name + ": " + r.Problem
+}
+
+// testCommand is a command that runs the examples of the tools in a configuration
+var testCommand = &cobra.Command{
+	Use:   "test",
+	Short: "Run the examples of the tools in a configuration",
+	Long: `
+Test a tools configuration against its examples.
+
+Tools can declare example calls, with the arguments and the expected outcome:
+
+  examples:
+    - args: { path: "/tmp/foo" }
+      expect_output: "foo"
+    - description: "paths outside /tmp are rejected"
+      args: { path: "/etc/passwd" }
+      expect_error: true
+
+This command executes every example, following the same process as the "exe"
+command (constraint evaluation, runner selection and tool execution, with the
+defaults and global constraints of the configuration applied), and checks the
+outcome is the expected one. For example, you can run:
+
+$ mcpshell test --tools examples/config.yaml
+
+The command fails when any example does not pass, so it can be used in CI pipelines.
+`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// Initialize logger
+		logger, err := initLogger()
+		if err != nil {
+			return err
+		}
+
+		// Setup panic handler
+		defer common.RecoverPanic()
+
+		logger.Info("Testing MCP tools examples")
+
+		// Check if config file is provided
+		if len(toolsFiles) == 0 {
+			logger.Error("Tools configuration file(s) are required")
+			return fmt.Errorf("tools configuration file(s) are required. Use --tools flag to specify the path(s)")
+		}
+
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Get the logger
+		logger := common.GetLogger()
+
+		// Setup panic handler
+		defer common.RecoverPanic()
+
+		return testToolExamples(os.Stdout, logger)
+	},
+}
+
+// testToolExamples loads the tools configuration, runs the examples of all the tools
+// and prints the results, returning an error when any of them does not pass
+func testToolExamples(w io.Writer, logger *common.Logger) error {
+	// Load the configuration file(s) (local or remote)
+	localConfigPath, cleanup, err := config.ResolveConfigPathsWithOverlay(toolsFiles, toolsEnv, logger)
+	if err != nil {
+		logger.Error("Failed to load configuration: %v", err)
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	// Ensure temporary files are cleaned up
+	defer cleanup()
+
+	cfg, err := config.NewConfigFromFile(localConfigPath)
+	if err != nil {
+		logger.Error("Failed to load configuration: %v", err)
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	// Remove the persistent containers started for running the examples, if any
+	defer command.StopPersistentContainers(logger)
+
+	results := runToolExamples(cfg, logger)
+
+	failed := 0
+	for _, result := range results {
+		_, _ = fmt.Fprintln(w, result)
+		if !result.Passed {
+			failed++
+		}
+	}
+	_, _ = fmt.Fprintf(w, "\n%d examples, %d passed, %d failed\n", len(results), len(results)-failed, failed)
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d examples failed", failed, len(results))
+	}
+	return nil
+}
+
+// runToolExamples runs the examples of the tools available in the configuration
+// (with the defaults and global constraints of the configuration applied)
+func runToolExamples(cfg *config.ToolsConfig, logger *common.Logger) []exampleResult {
+	shell := cfg.MCP.Run.Shell
+	if shell == "" {
+		shell = "sh"
+	}
+
+	var results []exampleResult
+	for _, tool := range cfg.GetTools() {
+		if len(tool.Config.Examples) == 0 {
+			continue
+		}
+
+		handler, err := command.NewCommandHandler(tool, tool.Config.Params, shell, logger)
+		if err == nil && disableCache {
+			handler.DisableCache()
+		}
+		for i, example := range tool.Config.Examples {
+			result := exampleResult{Tool: tool.Config.Name, Index: i, Description: example.Description}
+			if err != nil {
+				result.Problem = fmt.Sprintf("failed to create command handler: %v", err)
+			} else {
+				result.Passed, result.Problem = runToolExample(handler, example)
+			}
+			results = append(results, result)
+		}
+	}
+
+	return results
+}
+
+// runToolExample executes an example with the handler of its tool, returning whether
+// its outcome was the expected one and, when it was not, the reason
+func runToolExample(handler *command.CommandHandler, example config.ToolExample) (bool, string) {
+	args, err := exampleArgs(example.Args)
+	if err != nil {
+		return false, fmt.Sprintf("invalid arguments: %v", err)
+	}
+
+	output, err := handler.ExecuteCommand(args)
+	switch {
+	case err != nil && !example.ExpectError:
+		if errors.Is(err, command.ErrConstraintBlocked) {
+			return false, fmt.Sprintf("unexpectedly blocked by constraints: %v", err)
+		}
+		return false, fmt.Sprintf("unexpected error: %v", err)
+	case err == nil && example.ExpectError:
+		return false, fmt.Sprintf("expected an error, got output %q", output)
+	case err == nil && !strings.Contains(output, example.ExpectOutput):
+		return false, fmt.Sprintf("expected output containing %q, got %q", example.ExpectOutput, output)
+	}
+	return true, ""
+}
+
+// exampleArgs converts the arguments of an example to the types they have when they
+// come from a MCP client (e.g., numbers are float64), as they are decoded from YAML
+func exampleArgs(args map[string]interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+	converted := map[string]interface{}{}
+	if err := json.Unmarshal(data, &converted); err != nil {
+		return nil, err
+	}
+	return converted, nil
+}
+
+// init adds the test command to the root command
+func init() {
+	rootCmd.AddCommand(testCommand)
+
+	testCommand.Flags().BoolVar(&disableCache, "no-cache", false, "Disable the caches of the tool outputs, always running the commands")
+}
@@ -0,0 +1,114 @@
+package root
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/inercia/MCPShell/pkg/common"
+)
+
+func TestTestToolExamples(t *testing.T) {
+	testConfigFile := filepath.Join(t.TempDir(), "config.yaml")
+	configContent := `mcp:
+  tools:
+    - name: "show_file"
+      description: "Shows a file name"
+      params:
+        path:
+          type: string
+          description: "Path of the file"
+          required: true
+      constraints:
+        - "path.startsWith('/tmp/')"
+      run:
+        command: "echo {{ .path }}"
+      examples:
+        - args: { path: "/tmp/foo" }
+          expect_output: "/tmp/foo"
+        - description: "paths outside /tmp are rejected"
+          args: { path: "/etc/passwd" }
+          expect_error: true
+`
+	if err := os.WriteFile(testConfigFile, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+
+	oldToolsFiles := toolsFiles
+	toolsFiles = []string{testConfigFile}
+	defer func() { toolsFiles = oldToolsFiles }()
+
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+
+	var out bytes.Buffer
+	if err := testToolExamples(&out, logger); err != nil {
+		t.Fatalf("testToolExamples() error = %v\n%s", err, out.String())
+	}
+
+	for _, want := range []string{
+		"PASS show_file #1",
+		"PASS show_file #2 (paths outside /tmp are rejected)",
+		"2 examples, 2 passed, 0 failed",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("testToolExamples() output = %q, want it to contain %q", out.String(), want)
+		}
+	}
+
+	// the blocked example fails when it is not expected to be blocked
+	configContent = strings.Replace(configContent, "expect_error: true", "expect_error: false", 1)
+	if err := os.WriteFile(testConfigFile, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+
+	out.Reset()
+	if err := testToolExamples(&out, logger); err == nil {
+		t.Fatalf("testToolExamples() error = nil, want an error\n%s", out.String())
+	}
+	for _, want := range []string{
+		"PASS show_file #1",
+		"FAIL show_file #2 (paths outside /tmp are rejected): unexpectedly blocked by constraints",
+		"2 examples, 1 passed, 1 failed",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("testToolExamples() output = %q, want it to contain %q", out.String(), want)
+		}
+	}
+}
+
+func TestTestCommand_DisableCache(t *testing.T) {
+	dir := t.TempDir()
+	testConfigFile := filepath.Join(dir, "config.yaml")
+	configContent := `mcp:
+  tools:
+    - name: "count_runs"
+      description: "Count the runs of the tool"
+      cache:
+        ttl: "1m"
+      run:
+        command: "echo run >> ` + filepath.Join(dir, "runs") + ` && echo runs=$(wc -l < ` + filepath.Join(dir, "runs") + `)"
+      examples:
+        - expect_output: "runs=1"
+        - expect_output: "runs=2"
+`
+	if err := os.WriteFile(testConfigFile, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+
+	oldToolsFiles, oldDisableCache := toolsFiles, disableCache
+	toolsFiles, disableCache = []string{testConfigFile}, true
+	defer func() { toolsFiles, disableCache = oldToolsFiles, oldDisableCache }()
+
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+
+	// the second example would get the output of the first one from the cache
+	var out bytes.Buffer
+	if err := testToolExamples(&out, logger); err != nil {
+		t.Fatalf("testToolExamples() error = %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "2 examples, 2 passed, 0 failed") {
+		t.Errorf("testToolExamples() output = %q, want both examples to pass", out.String())
+	}
+}
@@ -9,6 +9,12 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	failOnWarnings bool
+	strictValidate bool
+	validateTool   string
+)
+
 // validateCommand represents the validate command which checks a configuration file
 var validateCommand = &cobra.Command{
 	Use:   "validate",
@@ -20,7 +26,16 @@ This command checks the configuration file for errors including:
 - File format and schema validation
 - Tool parameter definitions
 - Constraint expression syntax
-- Command template syntax`,
+- Constraints that can never be true (or are always true), reported as warnings
+- Command template syntax
+
+Use --fail-on-warnings to make validation fail when any warning is found.
+
+Use --strict to also check the options of every runner against the runner's schema
+(e.g. a Docker runner without an 'image'), rejecting unknown option keys.
+
+Use --tool to validate only one tool, ignoring the rest of the tools in the
+configuration. This is useful when iterating on a tool in a big configuration.`,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
 		// Initialize logger
 		logger, err := initLogger()
@@ -57,7 +72,7 @@ This command checks the configuration file for errors including:
 		}()
 
 		// Load the configuration file(s) (local or remote)
-		localConfigPath, cleanup, err := config.ResolveMultipleConfigPaths(toolsFiles, logger)
+		localConfigPath, cleanup, err := config.ResolveConfigPathsWithOverlay(toolsFiles, toolsEnv, logger)
 		if err != nil {
 			logger.Error("Failed to load configuration: %v", err)
 			return fmt.Errorf("failed to load configuration: %w", err)
@@ -68,10 +83,13 @@ This command checks the configuration file for errors including:
 
 		// Create server instance for validation only
 		srv := server.New(server.Config{
-			ConfigFile:   localConfigPath,
-			Logger:       logger,
-			Version:      version,
-			Descriptions: description,
+			ConfigFile:     localConfigPath,
+			Logger:         logger,
+			Version:        version,
+			Descriptions:   description,
+			FailOnWarnings: failOnWarnings,
+			Strict:         strictValidate,
+			ValidateTool:   validateTool,
 		})
 
 		// Validate the configuration
@@ -90,6 +108,10 @@ func init() {
 	// Add validate command to root
 	rootCmd.AddCommand(validateCommand)
 
+	validateCommand.Flags().BoolVar(&strictValidate, "strict", false, "Check the options of every runner against the runner's schema, rejecting unknown option keys")
+	validateCommand.Flags().StringVar(&validateTool, "tool", "", "Validate only the tool with this name")
+	validateCommand.Flags().BoolVar(&failOnWarnings, "fail-on-warnings", false, "Fail validation if any warning is found (e.g. constraints that can never be true)")
+
 	// Mark required flags
 	_ = validateCommand.MarkFlagRequired("tools")
 }
@@ -7,11 +7,14 @@ package agent
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/docker/cagent/pkg/runtime"
+	cagentTools "github.com/docker/cagent/pkg/tools"
 	"github.com/fatih/color"
+	"github.com/inercia/MCPShell/pkg/command"
 	"github.com/inercia/MCPShell/pkg/common"
 	"github.com/inercia/MCPShell/pkg/server"
 )
@@ -19,13 +22,23 @@ import (
 // AgentConfig holds the configuration for the agent including tools file location,
 // user prompts, execution mode, and embedded model configuration (API keys, model name, etc.)
 type AgentConfig struct {
-	ToolsFile   string // Path to the YAML configuration file defining available tools
-	UserPrompt  string // Initial user prompt to send to the LLM
-	Once        bool   // Whether to run in one-shot mode (exit after first response)
-	Version     string // Version information for the agent
-	ModelConfig        // Embedded model configuration (Model, APIKey, APIURL, Prompts)
+	ToolsFile      string // Path to the YAML configuration file defining available tools
+	UserPrompt     string // Initial user prompt to send to the LLM
+	Once           bool   // Whether to run in one-shot mode (exit after first response)
+	Approve        string // Tool approval mode (ApproveSafe or ApproveAuto)
+	HideDeprecated bool   // Whether deprecated tools are hidden from the LLM
+	MaxTools       int    // Maximum number of tools exposed to the LLM, by priority (0 for no limit)
+	Version        string // Version information for the agent
+	ModelConfig           // Embedded model configuration (Model, APIKey, APIURL, Prompts)
 }
 
+const (
+	// ApproveSafe auto-approves all the tools except the destructive ones, which are rejected
+	ApproveSafe = "safe"
+	// ApproveAuto auto-approves all the tools, including the destructive ones
+	ApproveAuto = "auto"
+)
+
 // Agent represents an MCP agent
 type Agent struct {
 	config AgentConfig
@@ -48,6 +61,14 @@ func (a *Agent) Validate() error {
 		return fmt.Errorf("tools configuration file is required")
 	}
 
+	// Check the tool approval mode
+	switch a.config.Approve {
+	case "", ApproveSafe, ApproveAuto:
+	default:
+		a.logger.Error("Invalid approval mode: %s", a.config.Approve)
+		return fmt.Errorf("invalid approval mode '%s' (must be '%s' or '%s')", a.config.Approve, ApproveSafe, ApproveAuto)
+	}
+
 	// Validate model configuration using the model manager
 	if err := ValidateModelConfig(a.config.ModelConfig, a.logger); err != nil {
 		a.logger.Error("Model configuration validation failed: %v", err)
@@ -107,7 +128,7 @@ func (a *Agent) Run(ctx context.Context, userInput chan string, agentOutput chan
 	}
 
 	// Create cagent runtime with multi-agent system
-	cagentRT, err := CreateCagentRuntime(ctx, srv, orchestratorConfig, toolRunnerConfig, a.config.UserPrompt, a.logger)
+	cagentRT, err := CreateCagentRuntime(ctx, srv, orchestratorConfig, toolRunnerConfig, config.Agent.Compaction, a.config.UserPrompt, a.logger)
 	if err != nil {
 		a.logger.Error("Failed to create cagent runtime: %v", err)
 		agentOutput <- fmt.Sprintf("Error: Failed to create cagent runtime: %v", err)
@@ -120,24 +141,19 @@ func (a *Agent) Run(ctx context.Context, userInput chan string, agentOutput chan
 		a.logger.Debug("Starting cagent event stream")
 		events := cagentRT.RunStream(ctx)
 
-		// Process events and send output
-		eventCount := 0
-		for event := range events {
-			eventCount++
-			a.logger.Debug("Received event #%d: %T", eventCount, event)
-
-			// Handle tool call confirmations - auto-approve tools
-			if _, ok := event.(*runtime.ToolCallConfirmationEvent); ok {
-				a.logger.Debug("Auto-approving tool execution")
-				cagentRT.Runtime().Resume(ctx, "approve-session")
-			}
-
-			if err := a.handleCagentEvent(event, agentOutput); err != nil {
-				a.logger.Error("Error handling event: %v", err)
-				// Continue processing other events
+		// Process events and send output, stopping as soon as the agent is interrupted
+		resume := func(decision runtime.ResumeType) {
+			cagentRT.Runtime().Resume(ctx, string(decision))
+		}
+		if err := a.processEvents(ctx, events, resume, agentOutput); err != nil {
+			// End the partial response, so the message is not mixed with it
+			message := "Interrupted"
+			if errors.Is(err, context.DeadlineExceeded) {
+				message = "Timed out"
 			}
+			agentOutput <- color.New(color.FgRed).Sprintf("\n\n✗ %s\n", message)
+			return err
 		}
-		a.logger.Debug("Event stream completed, processed %d events", eventCount)
 
 		// In one-shot mode, exit after first response
 		if a.config.Once {
@@ -145,6 +161,11 @@ func (a *Agent) Run(ctx context.Context, userInput chan string, agentOutput chan
 			return nil
 		}
 
+		// Compact the conversation before it grows too large
+		if cagentRT.CompactIfNeeded(ctx) {
+			agentOutput <- color.New(color.FgMagenta).Sprint("\n[conversation compacted]\n")
+		}
+
 		// In interactive mode, wait for user input to continue
 		a.logger.Debug("Waiting for user input to continue conversation...")
 		promptColor := color.New(color.Bold, color.FgHiCyan)
@@ -176,6 +197,66 @@ func (a *Agent) Run(ctx context.Context, userInput chan string, agentOutput chan
 	}
 }
 
+// processEvents handles the events of a cagent stream until the stream ends, or until
+// the context is cancelled (without waiting for the stream to end, as it can take a while).
+// It returns the error of the context when it was cancelled.
+func (a *Agent) processEvents(ctx context.Context, events <-chan runtime.Event, resume func(runtime.ResumeType), agentOutput chan string) error {
+	eventCount := 0
+	for {
+		select {
+		case <-ctx.Done():
+			a.logger.Info("Event stream interrupted after %d events: %v", eventCount, ctx.Err())
+
+			// Keep draining the stream, so it is not blocked while it finishes
+			go func() {
+				for range events {
+				}
+			}()
+			return ctx.Err()
+
+		case event, ok := <-events:
+			if !ok {
+				a.logger.Debug("Event stream completed, processed %d events", eventCount)
+				return nil
+			}
+			eventCount++
+			a.logger.Debug("Received event #%d: %T", eventCount, event)
+
+			// Handle tool call confirmations - auto-approve tools, unless they are destructive
+			if e, ok := event.(*runtime.ToolCallConfirmationEvent); ok {
+				decision := confirmationDecision(e.ToolDefinition, a.config.Approve)
+				if decision == runtime.ResumeTypeReject {
+					a.logger.Info("Rejecting destructive tool '%s' (use --approve auto to allow it)", e.ToolCall.Function.Name)
+					agentOutput <- color.New(color.FgRed).Sprintf("\n✗ Tool '%s' is destructive and was not approved (use --approve %s to allow it)\n",
+						e.ToolCall.Function.Name, ApproveAuto)
+				} else {
+					a.logger.Debug("Auto-approving tool execution")
+				}
+				resume(decision)
+			}
+
+			if err := a.handleCagentEvent(event, agentOutput); err != nil {
+				a.logger.Error("Error handling event: %v", err)
+				// Continue processing other events
+			}
+		}
+	}
+}
+
+// confirmationDecision decides how a tool call confirmation is resolved for the given approval mode.
+// In auto mode all the tools are approved for the whole session. Otherwise tools are approved one
+// call at a time (so the confirmation is requested again for the next one), and destructive tools
+// are rejected.
+func confirmationDecision(tool cagentTools.Tool, approve string) runtime.ResumeType {
+	if approve == ApproveAuto {
+		return runtime.ResumeTypeApproveSession
+	}
+	if tool.Annotations.DestructiveHint != nil && *tool.Annotations.DestructiveHint {
+		return runtime.ResumeTypeReject
+	}
+	return runtime.ResumeTypeApprove
+}
+
 // handleCagentEvent processes a single cagent event and sends appropriate output
 func (a *Agent) handleCagentEvent(event interface{}, agentOutput chan string) error {
 	a.logger.Debug("Handling event type: %T", event)
@@ -292,14 +373,18 @@ func mergeModelConfig(base, override ModelConfig) ModelConfig {
 func (a *Agent) setupServer(ctx context.Context) (*server.Server, func(), error) {
 	// Use the already resolved configuration file path (no need to resolve again)
 	localConfigPath := a.config.ToolsFile
-	cleanup := func() {} // No cleanup needed since path was already resolved
+
+	// Remove the persistent containers of the docker runners when done
+	cleanup := func() { command.StopPersistentContainers(a.logger) }
 
 	// Initialize MCP server to get tools
 	a.logger.Info("Initializing MCP server")
 	srv := server.New(server.Config{
-		ConfigFile: localConfigPath,
-		Logger:     a.logger,
-		Version:    a.config.Version,
+		ConfigFile:     localConfigPath,
+		Logger:         a.logger,
+		Version:        a.config.Version,
+		HideDeprecated: a.config.HideDeprecated,
+		MaxTools:       a.config.MaxTools,
 	})
 
 	// Create the server instance (but don't start it)
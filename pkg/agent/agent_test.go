@@ -1,11 +1,19 @@
 package agent
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/docker/cagent/pkg/runtime"
+	cagentTools "github.com/docker/cagent/pkg/tools"
 
 	"github.com/inercia/MCPShell/pkg/common"
+	"github.com/inercia/MCPShell/pkg/config"
 	"github.com/inercia/MCPShell/pkg/utils"
 )
 
@@ -128,6 +136,19 @@ func TestValidate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "model configuration validation failed: API key is required for OpenAI models (set API key environment variable or pass via config/flags)",
 		},
+		{
+			name: "invalid approval mode",
+			config: AgentConfig{
+				ToolsFile: "test.yaml",
+				Approve:   "always",
+				ModelConfig: ModelConfig{
+					Model: "llama2",
+					Class: "ollama",
+				},
+			},
+			wantErr: true,
+			errMsg:  "invalid approval mode 'always' (must be 'safe' or 'auto')",
+		},
 	}
 
 	for _, tt := range tests {
@@ -150,6 +171,57 @@ func TestValidate(t *testing.T) {
 	}
 }
 
+func TestConfirmationDecision(t *testing.T) {
+	tests := []struct {
+		name     string
+		tool     config.MCPToolConfig
+		approve  string
+		expected runtime.ResumeType
+	}{
+		{
+			name:     "regular tool is approved for each call",
+			tool:     config.MCPToolConfig{Name: "list_files"},
+			approve:  ApproveSafe,
+			expected: runtime.ResumeTypeApprove,
+		},
+		{
+			name:     "destructive tool is rejected by default",
+			tool:     config.MCPToolConfig{Name: "delete_files", Destructive: true},
+			approve:  "",
+			expected: runtime.ResumeTypeReject,
+		},
+		{
+			name:     "destructive tool is rejected in safe mode",
+			tool:     config.MCPToolConfig{Name: "delete_files", Destructive: true},
+			approve:  ApproveSafe,
+			expected: runtime.ResumeTypeReject,
+		},
+		{
+			name:     "destructive tool is approved in auto mode",
+			tool:     config.MCPToolConfig{Name: "delete_files", Destructive: true},
+			approve:  ApproveAuto,
+			expected: runtime.ResumeTypeApproveSession,
+		},
+		{
+			name:     "read-only tool is approved",
+			tool:     config.MCPToolConfig{Name: "read_file", ReadOnly: true},
+			approve:  ApproveSafe,
+			expected: runtime.ResumeTypeApprove,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mcpTool := config.CreateMCPTool(tt.tool)
+			tool := cagentTools.Tool{Name: mcpTool.Name, Annotations: convertMCPAnnotations(mcpTool.Annotations)}
+
+			if got := confirmationDecision(tool, tt.approve); got != tt.expected {
+				t.Errorf("confirmationDecision() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
 // Note: setupConversation and initializeModelClient tests removed
 // as these methods are now internal to cagent runtime
 
@@ -275,3 +347,66 @@ agent:
 
 	return configFile
 }
+
+func TestProcessEventsInterrupted(t *testing.T) {
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+	a := New(AgentConfig{Once: true}, logger)
+
+	// a stream that is still going on when the agent is interrupted
+	events := make(chan runtime.Event)
+	agentOutput := make(chan string, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- a.processEvents(ctx, events, func(runtime.ResumeType) {}, agentOutput)
+	}()
+
+	events <- runtime.AgentChoice("root", "partial answ")
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("processEvents() error = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("processEvents() did not return promptly after the interruption")
+	}
+
+	// the partial output was delivered
+	select {
+	case output := <-agentOutput:
+		if !strings.Contains(output, "partial answ") {
+			t.Errorf("Unexpected output %q", output)
+		}
+	default:
+		t.Error("Expected the partial output of the agent")
+	}
+
+	// the stream is drained, so it is not blocked
+	select {
+	case events <- runtime.AgentChoice("root", "more"):
+	case <-time.After(time.Second):
+		t.Error("The stream was not drained after the interruption")
+	}
+	close(events)
+}
+
+func TestProcessEventsCompleted(t *testing.T) {
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+	a := New(AgentConfig{Once: true}, logger)
+
+	events := make(chan runtime.Event, 1)
+	events <- runtime.AgentChoice("root", "answer")
+	close(events)
+
+	agentOutput := make(chan string, 10)
+	if err := a.processEvents(context.Background(), events, func(runtime.ResumeType) {}, agentOutput); err != nil {
+		t.Errorf("processEvents() error = %v", err)
+	}
+	if output := <-agentOutput; !strings.Contains(output, "answer") {
+		t.Errorf("Unexpected output %q", output)
+	}
+}
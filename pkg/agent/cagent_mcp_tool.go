@@ -110,6 +110,24 @@ func (m *MCPToolSet) convertMCPToolToCagent(mcpTool mcp.Tool) cagentTools.Tool {
 		Name:        mcpTool.Name,
 		Description: mcpTool.Description,
 		Parameters:  json.RawMessage(schemaJSON),
+		Annotations: convertMCPAnnotations(mcpTool.Annotations),
 		Handler:     handler,
 	}
 }
+
+// convertMCPAnnotations converts the MCP tool annotations to cagent annotations,
+// so cagent (and the agent approval logic) can see read-only and destructive tools
+func convertMCPAnnotations(annotations mcp.ToolAnnotation) cagentTools.ToolAnnotations {
+	result := cagentTools.ToolAnnotations{
+		Title:           annotations.Title,
+		DestructiveHint: annotations.DestructiveHint,
+		OpenWorldHint:   annotations.OpenWorldHint,
+	}
+	if annotations.ReadOnlyHint != nil {
+		result.ReadOnlyHint = *annotations.ReadOnlyHint
+	}
+	if annotations.IdempotentHint != nil {
+		result.IdempotentHint = *annotations.IdempotentHint
+	}
+	return result
+}
@@ -14,6 +14,8 @@ import (
 	"github.com/docker/cagent/pkg/runtime"
 	"github.com/docker/cagent/pkg/session"
 	"github.com/docker/cagent/pkg/team"
+	"github.com/docker/cagent/pkg/tools"
+	"github.com/docker/cagent/pkg/tools/builtin"
 
 	"github.com/inercia/MCPShell/pkg/common"
 	"github.com/inercia/MCPShell/pkg/server"
@@ -24,29 +26,61 @@ var defaultOrchestratorPrompt string
 
 // CagentRuntime wraps the cagent runtime and session
 type CagentRuntime struct {
-	runtime runtime.Runtime
-	session *session.Session
-	logger  *common.Logger
+	runtime         runtime.Runtime
+	session         *session.Session
+	compactAtTokens int // compact the conversation at this number of tokens (0 when disabled)
+	logger          *common.Logger
+}
+
+// newRuntime creates the cagent runtime for a team. It is a variable so tests
+// can check how the runtime is created.
+var newRuntime = func(agentTeam *team.Team, sessionCompaction bool) (runtime.Runtime, error) {
+	return runtime.New(agentTeam, runtime.WithSessionCompaction(sessionCompaction))
 }
 
 // CreateCagentRuntime creates and configures a cagent runtime
-// Uses a single agent approach for better tool execution continuity
+// Uses a single agent approach for better tool execution continuity, unless the
+// tool-runner has its own system prompt (see buildAgentTeam)
 func CreateCagentRuntime(
 	ctx context.Context,
 	srv *server.Server,
 	orchestratorConfig ModelConfig,
 	toolRunnerConfig ModelConfig,
+	compaction CompactionConfig,
 	userPrompt string,
 	logger *common.Logger,
 ) (*CagentRuntime, error) {
-	logger.Debug("Creating cagent single-agent runtime")
+	logger.Debug("Creating cagent runtime")
+
+	// Add the system prompt files of the models, rendered with the server context
+	if orchestratorConfig.SystemPromptFile != "" || toolRunnerConfig.SystemPromptFile != "" {
+		serverContext, err := srv.GetContext()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get server context: %w", err)
+		}
+		if orchestratorConfig, err = loadSystemPromptFile(orchestratorConfig, serverContext); err != nil {
+			return nil, fmt.Errorf("failed to load orchestrator prompt: %w", err)
+		}
+		if toolRunnerConfig, err = loadSystemPromptFile(toolRunnerConfig, serverContext); err != nil {
+			return nil, fmt.Errorf("failed to load tool-runner prompt: %w", err)
+		}
+	}
 
-	// Use orchestrator config for the single agent
+	// Use orchestrator config for the root agent
 	agentLLM, err := initializeCagentModel(ctx, orchestratorConfig, logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize agent model: %w", err)
 	}
 
+	// Only initialize the tool-runner model when it is used by a separate agent
+	var toolRunnerLLM provider.Provider
+	if usesSeparateToolRunner(orchestratorConfig, toolRunnerConfig) {
+		toolRunnerLLM, err = initializeCagentModel(ctx, toolRunnerConfig, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize tool-runner model: %w", err)
+		}
+	}
+
 	// Create MCP tool set
 	mcpToolSet := NewMCPToolSet(srv, logger)
 	tools, err := mcpToolSet.GetTools()
@@ -54,42 +88,17 @@ func CreateCagentRuntime(
 		return nil, fmt.Errorf("failed to get MCP tools: %w", err)
 	}
 
-	logger.Debug("Creating single agent with %d MCP tools", len(tools))
-
-	// Get system prompts - use tool-runner prompt since this agent will execute tools
-	// Use config prompts if provided, otherwise use embedded default
-	agentSysPrompt := orchestratorConfig.Prompts.GetSystemPrompts()
-	if agentSysPrompt == "" {
-		logger.Debug("Using default embedded prompt for agent")
-		agentSysPrompt = defaultOrchestratorPrompt
-	} else {
-		logger.Debug("Using custom prompt from config for agent")
-	}
-	logger.Debug("Agent prompt (first 200 chars): %s", func() string {
-		if len(agentSysPrompt) > 200 {
-			return agentSysPrompt[:200] + "..."
-		}
-		return agentSysPrompt
-	}())
-
-	// Create a single agent with all tools
-	agent := cagentAgent.New(
-		"root",
-		agentSysPrompt,
-		cagentAgent.WithModel(agentLLM),
-		cagentAgent.WithDescription("An agent that executes tools to accomplish user tasks"),
-		cagentAgent.WithTools(tools...),
-		cagentAgent.WithMaxIterations(50), // Allow up to 50 tool calls
-	)
+	agentTeam := buildAgentTeam(agentLLM, toolRunnerLLM, orchestratorConfig, toolRunnerConfig, tools, logger)
 
-	// Create the team with just the one agent
-	agentTeam := team.New(team.WithAgents(agent))
+	return newCagentRuntime(agentTeam, compaction, userPrompt, logger)
+}
 
-	// Create the runtime with session compaction enabled
-	rt, err := runtime.New(
-		agentTeam,
-		runtime.WithSessionCompaction(true), // Auto-summarize when approaching context limit
-	)
+// newCagentRuntime creates the runtime for the team and the session with the user prompt
+func newCagentRuntime(agentTeam *team.Team, compaction CompactionConfig, userPrompt string, logger *common.Logger) (*CagentRuntime, error) {
+	// Create the runtime, with session compaction (auto-summarize when approaching
+	// the context limit) unless disabled
+	logger.Debug("Session compaction enabled: %v", compaction.IsEnabled())
+	rt, err := newRuntime(agentTeam, compaction.IsEnabled())
 	if err != nil {
 		logger.Error("Failed to create cagent runtime: %v", err)
 		return nil, fmt.Errorf("failed to create cagent runtime: %w", err)
@@ -103,15 +112,105 @@ Remember: This is a multi-step investigation. Keep calling tools iteratively unt
 
 	sess := session.New(session.WithUserMessage("", enhancedPrompt))
 
-	logger.Debug("Cagent single-agent runtime created successfully")
+	compactAtTokens := 0
+	if compaction.IsEnabled() {
+		compactAtTokens = compaction.AtTokens
+	}
+
+	logger.Debug("Cagent runtime created successfully")
 
 	return &CagentRuntime{
-		runtime: rt,
-		session: sess,
-		logger:  logger,
+		runtime:         rt,
+		session:         sess,
+		compactAtTokens: compactAtTokens,
+		logger:          logger,
 	}, nil
 }
 
+// usesSeparateToolRunner returns true if the tool-runner should be a separate agent,
+// which happens when it has its own system prompt, different from the orchestrator's one
+func usesSeparateToolRunner(orchestratorConfig, toolRunnerConfig ModelConfig) bool {
+	if !toolRunnerConfig.Prompts.HasSystemPrompts() {
+		return false
+	}
+	return toolRunnerConfig.Prompts.GetSystemPrompts() != orchestratorConfig.Prompts.GetSystemPrompts()
+}
+
+// buildAgentTeam creates the team of agents used by the runtime.
+//
+// By default, a single agent with the orchestrator prompt executes all the tools.
+// When the tool-runner has its own system prompt, the roles are split: the root
+// orchestrator plans and delegates work (with the transfer_task tool) to a
+// "tool-runner" sub-agent that has the tool-runner model, prompt and the MCP tools.
+func buildAgentTeam(
+	orchestratorLLM provider.Provider,
+	toolRunnerLLM provider.Provider,
+	orchestratorConfig ModelConfig,
+	toolRunnerConfig ModelConfig,
+	agentTools []tools.Tool,
+	logger *common.Logger,
+) *team.Team {
+	// Use config prompts if provided, otherwise use embedded default
+	orchestratorSysPrompt := orchestratorConfig.Prompts.GetSystemPrompts()
+	if orchestratorSysPrompt == "" {
+		logger.Debug("Using default embedded prompt for agent")
+		orchestratorSysPrompt = defaultOrchestratorPrompt
+	} else {
+		logger.Debug("Using custom prompt from config for agent")
+	}
+	logger.Debug("Agent prompt (first 200 chars): %s", truncatePrompt(orchestratorSysPrompt))
+
+	if !usesSeparateToolRunner(orchestratorConfig, toolRunnerConfig) {
+		logger.Debug("Creating single agent with %d MCP tools", len(agentTools))
+
+		// Create a single agent with all tools
+		agent := cagentAgent.New(
+			"root",
+			orchestratorSysPrompt,
+			cagentAgent.WithModel(orchestratorLLM),
+			cagentAgent.WithDescription("An agent that executes tools to accomplish user tasks"),
+			cagentAgent.WithTools(agentTools...),
+			cagentAgent.WithMaxIterations(50), // Allow up to 50 tool calls
+		)
+
+		// Create the team with just the one agent
+		return team.New(team.WithAgents(agent))
+	}
+
+	toolRunnerSysPrompt := toolRunnerConfig.Prompts.GetSystemPrompts()
+	logger.Debug("Creating orchestrator and tool-runner agents, with %d MCP tools", len(agentTools))
+	logger.Debug("Tool-runner prompt (first 200 chars): %s", truncatePrompt(toolRunnerSysPrompt))
+
+	toolRunner := cagentAgent.New(
+		"tool-runner",
+		toolRunnerSysPrompt,
+		cagentAgent.WithModel(toolRunnerLLM),
+		cagentAgent.WithDescription("An agent that executes tools and reports their results"),
+		cagentAgent.WithTools(agentTools...),
+		cagentAgent.WithMaxIterations(50), // Allow up to 50 tool calls
+	)
+
+	orchestrator := cagentAgent.New(
+		"root",
+		orchestratorSysPrompt,
+		cagentAgent.WithModel(orchestratorLLM),
+		cagentAgent.WithDescription("An agent that plans tasks and delegates tool executions"),
+		cagentAgent.WithSubAgents(toolRunner),
+		cagentAgent.WithToolSets(builtin.NewTransferTaskTool()),
+		cagentAgent.WithMaxIterations(50),
+	)
+
+	return team.New(team.WithAgents(orchestrator, toolRunner))
+}
+
+// truncatePrompt shortens a prompt for logging purposes
+func truncatePrompt(prompt string) string {
+	if len(prompt) > 200 {
+		return prompt[:200] + "..."
+	}
+	return prompt
+}
+
 // RunStream starts the streaming runtime and returns the event channel
 func (cr *CagentRuntime) RunStream(ctx context.Context) <-chan runtime.Event {
 	cr.logger.Debug("Starting cagent runtime stream")
@@ -135,6 +234,32 @@ func (cr *CagentRuntime) ContinueConversation(userMessage string) error {
 	return nil
 }
 
+// CompactIfNeeded summarizes the conversation when it has reached the configured number
+// of tokens, returning true if it was compacted. The runtime compacts the conversation
+// by itself when approaching the context limit of the model: this allows doing it earlier.
+func (cr *CagentRuntime) CompactIfNeeded(ctx context.Context) bool {
+	if cr.compactAtTokens <= 0 {
+		return false
+	}
+
+	usedTokens := cr.session.InputTokens + cr.session.OutputTokens
+	if usedTokens < cr.compactAtTokens {
+		return false
+	}
+
+	cr.logger.Info("Compacting the conversation (%d tokens, threshold %d)", usedTokens, cr.compactAtTokens)
+	events := make(chan runtime.Event)
+	go func() {
+		defer close(events)
+		cr.runtime.Summarize(ctx, cr.session, events)
+	}()
+	for range events {
+		// drain the compaction events
+	}
+
+	return true
+}
+
 // initializeCagentModel creates a cagent-compatible model provider from our ModelConfig
 func initializeCagentModel(ctx context.Context, config ModelConfig, logger *common.Logger) (provider.Provider, error) {
 	// Create cagent model configuration
@@ -179,6 +304,11 @@ func initializeCagentModel(ctx context.Context, config ModelConfig, logger *comm
 	logger.Debug("Initializing cagent model: provider=%s, model=%s",
 		cagentModelConfig.Provider, cagentModelConfig.Model)
 
+	// Trust the custom CA bundle (if any) in the HTTP clients created by cagent
+	if err := trustCACertGlobally(config, logger); err != nil {
+		return nil, fmt.Errorf("failed to configure CA certificate: %w", err)
+	}
+
 	// Create environment provider for API keys
 	// Set API key from config into environment if provided
 	if config.APIKey != "" {
@@ -0,0 +1,159 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/docker/cagent/pkg/runtime"
+	"github.com/docker/cagent/pkg/team"
+	"github.com/docker/cagent/pkg/tools"
+	"gopkg.in/yaml.v3"
+
+	"github.com/inercia/MCPShell/pkg/common"
+)
+
+func TestBuildAgentTeam(t *testing.T) {
+	logger, err := common.NewLogger("", "", common.LogLevelNone, false)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	agentTools := []tools.Tool{{Name: "test_tool"}}
+
+	t.Run("single agent when the tool-runner has no prompt of its own", func(t *testing.T) {
+		orchestratorConfig := ModelConfig{
+			Model:   "gpt-4o",
+			Prompts: common.PromptsConfig{System: []string{"You are the orchestrator."}},
+		}
+		toolRunnerConfig := orchestratorConfig
+
+		agentTeam := buildAgentTeam(nil, nil, orchestratorConfig, toolRunnerConfig, agentTools, logger)
+
+		if agentTeam.Size() != 1 {
+			t.Fatalf("Expected 1 agent, got %d (%v)", agentTeam.Size(), agentTeam.AgentNames())
+		}
+		root := agentTeam.Agent("root")
+		if root == nil {
+			t.Fatal("Expected a 'root' agent")
+		}
+		if root.Instruction() != "You are the orchestrator." {
+			t.Errorf("Expected the orchestrator prompt, got %q", root.Instruction())
+		}
+	})
+
+	t.Run("default prompt for the single agent", func(t *testing.T) {
+		agentTeam := buildAgentTeam(nil, nil, ModelConfig{}, ModelConfig{}, agentTools, logger)
+
+		root := agentTeam.Agent("root")
+		if root == nil || root.Instruction() != defaultOrchestratorPrompt {
+			t.Error("Expected the root agent to use the default orchestrator prompt")
+		}
+	})
+
+	t.Run("separate tool-runner with its own prompt", func(t *testing.T) {
+		orchestratorConfig := ModelConfig{
+			Model:   "gpt-4o",
+			Prompts: common.PromptsConfig{System: []string{"You are the orchestrator."}},
+		}
+		toolRunnerConfig := ModelConfig{
+			Model:   "gpt-4o-mini",
+			Prompts: common.PromptsConfig{System: []string{"You are the tool runner.", "Only run tools."}},
+		}
+
+		agentTeam := buildAgentTeam(nil, nil, orchestratorConfig, toolRunnerConfig, agentTools, logger)
+
+		if agentTeam.Size() != 2 {
+			t.Fatalf("Expected 2 agents, got %d (%v)", agentTeam.Size(), agentTeam.AgentNames())
+		}
+
+		root := agentTeam.Agent("root")
+		if root == nil {
+			t.Fatal("Expected a 'root' agent")
+		}
+		if root.Instruction() != "You are the orchestrator." {
+			t.Errorf("Expected the orchestrator prompt for the root agent, got %q", root.Instruction())
+		}
+
+		toolRunner := agentTeam.Agent("tool-runner")
+		if toolRunner == nil {
+			t.Fatal("Expected a 'tool-runner' agent")
+		}
+		expectedPrompt := toolRunnerConfig.Prompts.GetSystemPrompts()
+		if toolRunner.Instruction() != expectedPrompt {
+			t.Errorf("Expected the tool-runner prompt %q, got %q", expectedPrompt, toolRunner.Instruction())
+		}
+
+		subAgents := root.SubAgents()
+		if len(subAgents) != 1 || subAgents[0] != toolRunner {
+			t.Errorf("Expected the tool-runner to be the only sub-agent of the root agent")
+		}
+	})
+}
+
+func TestNewCagentRuntime_Compaction(t *testing.T) {
+	logger, err := common.NewLogger("", "", common.LogLevelNone, false)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	// Record how the runtime is created
+	var gotCompaction []bool
+	originalNewRuntime := newRuntime
+	newRuntime = func(agentTeam *team.Team, sessionCompaction bool) (runtime.Runtime, error) {
+		gotCompaction = append(gotCompaction, sessionCompaction)
+		return originalNewRuntime(agentTeam, sessionCompaction)
+	}
+	defer func() { newRuntime = originalNewRuntime }()
+
+	tests := []struct {
+		name           string
+		configYAML     string
+		wantCompaction bool
+		wantCompactAt  int
+	}{
+		{
+			name:           "Compaction enabled by default",
+			configYAML:     "agent:\n  models: []\n",
+			wantCompaction: true,
+		},
+		{
+			name:           "Compaction at a number of tokens",
+			configYAML:     "agent:\n  compaction:\n    enabled: true\n    at-tokens: 8000\n",
+			wantCompaction: true,
+			wantCompactAt:  8000,
+		},
+		{
+			name:           "Compaction disabled",
+			configYAML:     "agent:\n  compaction:\n    enabled: false\n    at-tokens: 8000\n",
+			wantCompaction: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var config Config
+			if err := yaml.Unmarshal([]byte(tt.configYAML), &config); err != nil {
+				t.Fatalf("Failed to parse config: %v", err)
+			}
+
+			gotCompaction = nil
+			agentTeam := buildAgentTeam(nil, nil, ModelConfig{}, ModelConfig{}, nil, logger)
+			cr, err := newCagentRuntime(agentTeam, config.Agent.Compaction, "hello", logger)
+			if err != nil {
+				t.Fatalf("newCagentRuntime() error = %v", err)
+			}
+
+			if len(gotCompaction) != 1 || gotCompaction[0] != tt.wantCompaction {
+				t.Errorf("Expected the runtime to be created with compaction=%v, got %v", tt.wantCompaction, gotCompaction)
+			}
+			if cr.compactAtTokens != tt.wantCompactAt {
+				t.Errorf("Expected compaction at %d tokens, got %d", tt.wantCompactAt, cr.compactAtTokens)
+			}
+
+			// Below the threshold (or without one), the conversation is not compacted
+			if cr.CompactIfNeeded(context.Background()) {
+				t.Error("Expected no compaction for an empty conversation")
+			}
+		})
+	}
+}
@@ -24,7 +24,12 @@ type ModelConfig struct {
 	Default bool                 `yaml:"default,omitempty"` // Whether this is the default model
 	APIKey  string               `yaml:"api-key,omitempty"` // API key, optional
 	APIURL  string               `yaml:"api-url,omitempty"` // API URL, optional
+	CACert  string               `yaml:"ca-cert,omitempty"` // Path to a CA bundle trusted for the API URL, optional
 	Prompts common.PromptsConfig `yaml:"prompts,omitempty"` // Prompts configuration, optional
+
+	// SystemPromptFile is a file with a system prompt for this model, appended to the
+	// system prompts after expanding ${VAR} references and rendering it as a template
+	SystemPromptFile string `yaml:"system-prompt-file,omitempty"`
 }
 
 // AgentConfigFile holds the agent configuration from file
@@ -34,6 +39,20 @@ type AgentConfigFile struct {
 	// Role-based configuration for multi-agent system
 	Orchestrator *ModelConfig `yaml:"orchestrator,omitempty"` // Root agent that plans and orchestrates
 	ToolRunner   *ModelConfig `yaml:"tool-runner,omitempty"`  // Sub-agent that executes tools
+
+	// Compaction of the conversation when it grows too large
+	Compaction CompactionConfig `yaml:"compaction,omitempty"`
+}
+
+// CompactionConfig controls when the conversation is compacted (summarized)
+type CompactionConfig struct {
+	Enabled  *bool `yaml:"enabled,omitempty"`   // Whether the conversation is compacted at all (defaults to true)
+	AtTokens int   `yaml:"at-tokens,omitempty"` // Compact once the conversation reaches this number of tokens, optional
+}
+
+// IsEnabled returns true if the conversation must be compacted (the default)
+func (c CompactionConfig) IsEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
 }
 
 // Config holds the complete agent configuration
@@ -70,6 +89,32 @@ func GetConfig() (*Config, error) {
 	return &config, nil
 }
 
+// toolsConfigAgent is the agent configuration that can be embedded in a tools configuration
+type toolsConfigAgent struct {
+	Agent struct {
+		Model *ModelConfig `yaml:"model"`
+	} `yaml:"agent"`
+}
+
+// GetToolsConfigModel returns the model defined in the "agent.model" section of a
+// tools configuration file, or nil when it does not define one
+func GetToolsConfigModel(toolsFile string) (*ModelConfig, error) {
+	data, err := os.ReadFile(toolsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tools configuration %s: %w", toolsFile, err)
+	}
+
+	var config toolsConfigAgent
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse tools configuration %s: %w", toolsFile, err)
+	}
+
+	if config.Agent.Model == nil || config.Agent.Model.Model == "" {
+		return nil, nil
+	}
+	return config.Agent.Model, nil
+}
+
 // GetDefaultModel returns the model configuration that has default=true
 // If no default is found, returns the first model in the list
 // If no models are configured, returns nil
@@ -89,6 +134,28 @@ func (c *Config) GetDefaultModel() *ModelConfig {
 	return &c.Agent.Models[0]
 }
 
+// ListModels returns all the models in the configuration: the models list,
+// followed by the orchestrator and tool-runner models (if they are not in the list)
+func (c *Config) ListModels() []ModelConfig {
+	models := append([]ModelConfig{}, c.Agent.Models...)
+	for _, roleModel := range []*ModelConfig{c.Agent.Orchestrator, c.Agent.ToolRunner} {
+		if roleModel == nil || roleModel.Model == "" {
+			continue
+		}
+		duplicated := false
+		for _, model := range models {
+			if model.Model == roleModel.Model && model.Name == roleModel.Name && model.Class == roleModel.Class {
+				duplicated = true
+				break
+			}
+		}
+		if !duplicated {
+			models = append(models, *roleModel)
+		}
+	}
+	return models
+}
+
 // GetModelByName returns the model configuration with the specified name
 func (c *Config) GetModelByName(name string) *ModelConfig {
 	for i := range c.Agent.Models {
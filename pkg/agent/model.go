@@ -87,6 +87,10 @@ func (p *OpenAIProvider) InitializeClient(config ModelConfig, logger *common.Log
 		clientConfig.BaseURL = config.APIURL
 	}
 
+	if err := applyCACert(&clientConfig, config, logger); err != nil {
+		return nil, err
+	}
+
 	client := openai.NewClientWithConfig(clientConfig)
 	logger.Info("Initialized OpenAI client with model: %s", config.Model)
 	return client, nil
@@ -123,6 +127,10 @@ func (p *OllamaProvider) InitializeClient(config ModelConfig, logger *common.Log
 		clientConfig.BaseURL = config.APIURL
 	}
 
+	if err := applyCACert(&clientConfig, config, logger); err != nil {
+		return nil, err
+	}
+
 	client := openai.NewClientWithConfig(clientConfig)
 	logger.Info("Initialized Ollama client with model: %s", config.Model)
 	return client, nil
@@ -162,6 +170,10 @@ func (p *GenericProvider) InitializeClient(config ModelConfig, logger *common.Lo
 		clientConfig.BaseURL = config.APIURL
 	}
 
+	if err := applyCACert(&clientConfig, config, logger); err != nil {
+		return nil, err
+	}
+
 	client := openai.NewClientWithConfig(clientConfig)
 	logger.Info("Initialized OpenAI-compatible (%s) client with model: %s", p.class, config.Model)
 	return client, nil
@@ -0,0 +1,43 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/inercia/MCPShell/pkg/common"
+)
+
+// loadSystemPromptFile returns the model configuration with the content of its system
+// prompt file (if any) appended to the system prompts. The ${VAR} references in the
+// file are replaced by environment variables, and then the file is rendered as a
+// template where only the server context (`{{ .context.stage }}`) and the model
+// (`{{ .model }}` and `{{ .class }}`) are available.
+func loadSystemPromptFile(config ModelConfig, serverContext map[string]string) (ModelConfig, error) {
+	if config.SystemPromptFile == "" {
+		return config, nil
+	}
+
+	content, err := os.ReadFile(config.SystemPromptFile)
+	if err != nil {
+		return config, fmt.Errorf("failed to read system prompt file: %w", err)
+	}
+
+	if serverContext == nil {
+		serverContext = map[string]string{}
+	}
+
+	prompt, err := common.ProcessTemplate(common.ExpandEnv(string(content)), map[string]interface{}{
+		"context": serverContext,
+		"model":   config.Model,
+		"class":   config.Class,
+	})
+	if err != nil {
+		return config, fmt.Errorf("failed to render system prompt file '%s': %w", config.SystemPromptFile, err)
+	}
+
+	// copy the prompts, so the configuration we got is not modified
+	config.Prompts.System = append(append([]string{}, config.Prompts.System...), prompt)
+	config.SystemPromptFile = ""
+
+	return config, nil
+}
@@ -0,0 +1,70 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/cagent/pkg/tools"
+
+	"github.com/inercia/MCPShell/pkg/common"
+)
+
+func TestLoadSystemPromptFile(t *testing.T) {
+	logger, err := common.NewLogger("", "", common.LogLevelNone, false)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	t.Setenv("REGION", "eu-west-1")
+
+	promptFile := filepath.Join(t.TempDir(), "prompt.md")
+	content := "You operate in ${REGION} ({{ .context.stage }}), using {{ .model }}. Costs are in $USD."
+	if err := os.WriteFile(promptFile, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write prompt file: %v", err)
+	}
+
+	config := ModelConfig{
+		Model:            "gpt-4o",
+		Prompts:          common.PromptsConfig{System: []string{"You are the orchestrator."}},
+		SystemPromptFile: promptFile,
+	}
+
+	loaded, err := loadSystemPromptFile(config, map[string]string{"stage": "prod"})
+	if err != nil {
+		t.Fatalf("Failed to load the system prompt file: %v", err)
+	}
+
+	expected := "You are the orchestrator.\nYou operate in eu-west-1 (prod), using gpt-4o. Costs are in $USD."
+	if got := loaded.Prompts.GetSystemPrompts(); got != expected {
+		t.Errorf("Expected system prompts %q, got %q", expected, got)
+	}
+	if len(config.Prompts.System) != 1 {
+		t.Errorf("The original configuration should not be modified, got %v", config.Prompts.System)
+	}
+
+	// the expanded prompt is the one used by the agent
+	agentTeam := buildAgentTeam(nil, nil, loaded, loaded, []tools.Tool{{Name: "test_tool"}}, logger)
+	if root := agentTeam.Agent("root"); root == nil || !strings.Contains(root.Instruction(), "eu-west-1") {
+		t.Errorf("Expected the root agent to use the expanded prompt")
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		config := ModelConfig{SystemPromptFile: filepath.Join(t.TempDir(), "missing.md")}
+		if _, err := loadSystemPromptFile(config, nil); err == nil {
+			t.Error("Expected an error for a missing prompt file")
+		}
+	})
+
+	t.Run("no file", func(t *testing.T) {
+		config := ModelConfig{Prompts: common.PromptsConfig{System: []string{"prompt"}}}
+		loaded, err := loadSystemPromptFile(config, nil)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if loaded.Prompts.GetSystemPrompts() != "prompt" {
+			t.Errorf("Expected the prompts to be unchanged, got %q", loaded.Prompts.GetSystemPrompts())
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package agent
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/sashabaranov/go-openai"
+
+	"github.com/inercia/MCPShell/pkg/common"
+)
+
+// systemCertPool returns a copy of the system certificate pool (or an empty pool if not available)
+func systemCertPool() *x509.CertPool {
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		return x509.NewCertPool()
+	}
+	return pool
+}
+
+// appendCACert adds the certificates of the given CA bundle (a PEM file) to the pool
+func appendCACert(pool *x509.CertPool, caCertPath string) error {
+	pem, err := os.ReadFile(caCertPath)
+	if err != nil {
+		return fmt.Errorf("failed to read CA certificate %s: %w", caCertPath, err)
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return fmt.Errorf("no valid certificates found in CA certificate %s", caCertPath)
+	}
+	return nil
+}
+
+// newHTTPClientWithCA returns an HTTP client that trusts the given CA bundle
+// (besides the system certificates)
+func newHTTPClientWithCA(caCertPath string) (*http.Client, error) {
+	pool := systemCertPool()
+	if err := appendCACert(pool, caCertPath); err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	return &http.Client{Transport: transport}, nil
+}
+
+// applyCACert configures the OpenAI client to trust the CA bundle of the model configuration (if any)
+func applyCACert(clientConfig *openai.ClientConfig, config ModelConfig, logger *common.Logger) error {
+	if config.CACert == "" {
+		return nil
+	}
+
+	httpClient, err := newHTTPClientWithCA(config.CACert)
+	if err != nil {
+		logger.Error("Failed to load CA certificate: %v", err)
+		return err
+	}
+	clientConfig.HTTPClient = httpClient
+	logger.Debug("Using custom CA certificate: %s", config.CACert)
+	return nil
+}
+
+// trustCACertGlobally makes the default HTTP transport trust the CA bundle of the model
+// configuration (if any). cagent creates its own HTTP clients for the LLM API, using the
+// default transport, so this is the only way of making them trust a custom CA.
+func trustCACertGlobally(config ModelConfig, logger *common.Logger) error {
+	if config.CACert == "" {
+		return nil
+	}
+
+	transport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("cannot use the CA certificate: unexpected default HTTP transport")
+	}
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+
+	// keep the CAs added for other models (like the orchestrator and the tool-runner ones)
+	pool := transport.TLSClientConfig.RootCAs
+	if pool == nil {
+		pool = systemCertPool()
+	}
+	if err := appendCACert(pool, config.CACert); err != nil {
+		logger.Error("Failed to load CA certificate: %v", err)
+		return err
+	}
+	transport.TLSClientConfig.RootCAs = pool
+
+	logger.Debug("Using custom CA certificate for the LLM API: %s", config.CACert)
+	return nil
+}
@@ -0,0 +1,95 @@
+package agent
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/inercia/MCPShell/pkg/common"
+)
+
+// newTestTLSServer starts a TLS server with an OpenAI-compatible models endpoint,
+// and writes its (self-signed) certificate to a PEM file
+func newTestTLSServer(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"object": "list", "data": [{"id": "test-model", "object": "model"}]}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	caCertPath := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(caCertPath, certPEM, 0644); err != nil {
+		t.Fatalf("Failed to write CA certificate: %v", err)
+	}
+	return srv, caCertPath
+}
+
+func TestModelClientWithCACert(t *testing.T) {
+	logger, err := common.NewLogger("", "", common.LogLevelNone, false)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	srv, caCertPath := newTestTLSServer(t)
+
+	config := ModelConfig{
+		Model:  "test-model",
+		Class:  "openai",
+		APIKey: "test-key",
+		APIURL: srv.URL + "/v1",
+	}
+
+	t.Run("without the CA certificate", func(t *testing.T) {
+		client, err := InitializeModelClient(config, logger)
+		if err != nil {
+			t.Fatalf("InitializeModelClient() error = %v", err)
+		}
+		_, err = client.ListModels(context.Background())
+		if err == nil || !strings.Contains(err.Error(), "certificate") {
+			t.Errorf("Expected a certificate verification error, got %v", err)
+		}
+	})
+
+	t.Run("with the CA certificate", func(t *testing.T) {
+		config := config
+		config.CACert = caCertPath
+
+		client, err := InitializeModelClient(config, logger)
+		if err != nil {
+			t.Fatalf("InitializeModelClient() error = %v", err)
+		}
+		models, err := client.ListModels(context.Background())
+		if err != nil {
+			t.Fatalf("ListModels() error = %v", err)
+		}
+		if len(models.Models) != 1 || models.Models[0].ID != "test-model" {
+			t.Errorf("Unexpected models: %+v", models.Models)
+		}
+	})
+
+	t.Run("with an invalid CA certificate", func(t *testing.T) {
+		invalidPath := filepath.Join(t.TempDir(), "invalid.pem")
+		if err := os.WriteFile(invalidPath, []byte("not a certificate"), 0644); err != nil {
+			t.Fatalf("Failed to write invalid CA certificate: %v", err)
+		}
+
+		config := config
+		config.CACert = invalidPath
+		if _, err := InitializeModelClient(config, logger); err == nil || !strings.Contains(err.Error(), "no valid certificates") {
+			t.Errorf("Expected an invalid CA certificate error, got %v", err)
+		}
+
+		config.CACert = filepath.Join(t.TempDir(), "missing.pem")
+		if _, err := InitializeModelClient(config, logger); err == nil || !strings.Contains(err.Error(), "failed to read CA certificate") {
+			t.Errorf("Expected a missing CA certificate error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktokenloader "github.com/pkoukk/tiktoken-go-loader"
+	"github.com/sashabaranov/go-openai"
+)
+
+// defaultTokenEncoding is the encoding used for models unknown to the tokenizer
+// (like the ones served by Ollama), as a reasonable approximation
+const defaultTokenEncoding = "o200k_base"
+
+func init() {
+	// use the embedded BPE files, so counting tokens does not need network access
+	tiktoken.SetBpeLoader(tiktokenloader.NewOfflineLoader())
+}
+
+// ToolTokens is the estimated number of tokens of the schema of a tool
+type ToolTokens struct {
+	Name   string `json:"name"`
+	Tokens int    `json:"tokens"`
+}
+
+// TokensEstimate is the estimated number of tokens used by the tools schemas
+type TokensEstimate struct {
+	Encoding string       `json:"encoding"`
+	Total    int          `json:"total"`
+	Tools    []ToolTokens `json:"tools"`
+}
+
+// EstimateToolsTokens estimates the number of prompt tokens used by the schemas of the
+// tools when they are sent to the given model. The tools are sorted by decreasing cost.
+func EstimateToolsTokens(tools []openai.Tool, model string) (*TokensEstimate, error) {
+	encodingName := tokenEncodingForModel(model)
+	encoding, err := tiktoken.GetEncoding(encodingName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the %s encoding: %w", encodingName, err)
+	}
+
+	estimate := &TokensEstimate{Encoding: encodingName}
+	for _, tool := range tools {
+		schema, err := json.Marshal(tool)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize the tool schema: %w", err)
+		}
+
+		name := ""
+		if tool.Function != nil {
+			name = tool.Function.Name
+		}
+		tokens := len(encoding.Encode(string(schema), nil, nil))
+		estimate.Tools = append(estimate.Tools, ToolTokens{Name: name, Tokens: tokens})
+		estimate.Total += tokens
+	}
+
+	sort.SliceStable(estimate.Tools, func(i, j int) bool {
+		return estimate.Tools[i].Tokens > estimate.Tools[j].Tokens
+	})
+	return estimate, nil
+}
+
+// tokenEncodingForModel returns the name of the encoding used by the model (using the
+// longest matching prefix for versioned model names), or the default one if unknown
+func tokenEncodingForModel(model string) string {
+	if name, ok := tiktoken.MODEL_TO_ENCODING[model]; ok {
+		return name
+	}
+
+	encodingName, longestPrefix := defaultTokenEncoding, ""
+	for prefix, name := range tiktoken.MODEL_PREFIX_TO_ENCODING {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(longestPrefix) {
+			encodingName, longestPrefix = name, prefix
+		}
+	}
+	return encodingName
+}
@@ -0,0 +1,22 @@
+package agent
+
+import "testing"
+
+func TestTokenEncodingForModel(t *testing.T) {
+	tests := []struct {
+		model string
+		want  string
+	}{
+		{model: "gpt-4o", want: "o200k_base"},
+		{model: "gpt-4o-2024-05-13", want: "o200k_base"},
+		{model: "gpt-4", want: "cl100k_base"},
+		{model: "gpt-3.5-turbo-0125", want: "cl100k_base"},
+		{model: "llama3.1:8b", want: defaultTokenEncoding},
+	}
+
+	for _, tt := range tests {
+		if got := tokenEncodingForModel(tt.model); got != tt.want {
+			t.Errorf("tokenEncodingForModel(%q) = %q, want %q", tt.model, got, tt.want)
+		}
+	}
+}
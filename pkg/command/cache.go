@@ -0,0 +1,100 @@
+package command
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// resultCache is an in-memory LRU cache of the outputs of the successful calls of a tool,
+// where entries expire after a TTL
+type resultCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]*list.Element
+	lru        *list.List // the most recently used entries first
+
+	now func() time.Time
+}
+
+// cacheEntry is an output kept in the cache
+type cacheEntry struct {
+	key      string
+	output   string
+	code     int
+	expireAt time.Time
+}
+
+// newResultCache creates a cache keeping up to maxEntries outputs for the given TTL
+func newResultCache(ttl time.Duration, maxEntries int) *resultCache {
+	return &resultCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    map[string]*list.Element{},
+		lru:        list.New(),
+		now:        time.Now,
+	}
+}
+
+// get returns the output (and exit code) cached for the key, if it has not expired
+func (c *resultCache) get(key string) (string, int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return "", 0, false
+	}
+
+	entry := elem.Value.(*cacheEntry)
+	if !c.now().Before(entry.expireAt) {
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+		return "", 0, false
+	}
+
+	c.lru.MoveToFront(elem)
+	return entry.output, entry.code, true
+}
+
+// put keeps the output (and exit code) for the key, evicting the least recently
+// used entry when the cache is full
+func (c *resultCache) put(key string, output string, code int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expireAt := c.now().Add(c.ttl)
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*cacheEntry)
+		entry.output, entry.code, entry.expireAt = output, code, expireAt
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, output: output, code: code, expireAt: expireAt})
+	for c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// cacheKey returns the key for a call of a tool: a hash of the tool name and the
+// arguments (encoded as JSON, where the keys of the maps are sorted)
+func cacheKey(toolName string, params map[string]interface{}) (string, error) {
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode the arguments: %w", err)
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(toolName))
+	hash.Write([]byte{0})
+	hash.Write(encoded)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,47 @@
+package command
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResultCache(t *testing.T) {
+	now := time.Now()
+	cache := newResultCache(time.Minute, 2)
+	cache.now = func() time.Time { return now }
+
+	cache.put("a", "output a", 0)
+	cache.put("b", "output b", 1)
+	if output, code, ok := cache.get("a"); !ok || output != "output a" || code != 0 {
+		t.Errorf("get(a) = %q, %d, %v", output, code, ok)
+	}
+
+	// "b" is now the least recently used entry, so it is evicted first
+	cache.put("c", "output c", 0)
+	if _, _, ok := cache.get("b"); ok {
+		t.Errorf("Expected the least recently used entry to be evicted")
+	}
+	if _, _, ok := cache.get("a"); !ok {
+		t.Errorf("Expected the recently used entry to be kept")
+	}
+
+	// entries expire after the TTL
+	now = now.Add(time.Minute)
+	if _, _, ok := cache.get("c"); ok {
+		t.Errorf("Expected the entry to expire after the TTL")
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	key1, _ := cacheKey("tool", map[string]interface{}{"a": "1", "b": 2.0})
+	key2, _ := cacheKey("tool", map[string]interface{}{"b": 2.0, "a": "1"})
+	key3, _ := cacheKey("other-tool", map[string]interface{}{"a": "1", "b": 2.0})
+	key4, _ := cacheKey("tool", map[string]interface{}{"a": "1", "b": 3.0})
+
+	if key1 != key2 {
+		t.Errorf("Expected the same key for the same arguments in a different order")
+	}
+	if key1 == key3 || key1 == key4 {
+		t.Errorf("Expected different keys for different tools or arguments")
+	}
+}
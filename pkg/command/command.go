@@ -8,7 +8,10 @@ package command
 import (
 	"context"
 	"fmt"
+	"maps"
 	"os"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -46,15 +49,33 @@ func isSingleExecutableCommand(command string) bool {
 type CommandHandler struct {
 	cmd                 string                        // the command to execute
 	output              common.OutputConfig           // the output configuration
+	jsonPath            []jsonPathSegment             // the parsed JSON path extracted from the output
+	outputRegex         *regexp.Regexp                // the regex extracted from (or replaced in) the output
 	constraints         []string                      // the constraints to evaluate
 	constraintsCompiled *common.CompiledConstraints   // ... and the compiled versions
+	constraintLog       string                        // the file where constraint decisions are recorded
+	warnings            *common.CompiledConstraints   // the compiled advisory constraints
 	params              map[string]common.ParamConfig // the parameter configurations
+	paramPatterns       map[string]*regexp.Regexp     // the compiled patterns of the parameters
 	envVars             []string                      // the environment variables passed to the command
-	timeout             string                        // the timeout for command execution (e.g., "30s", "5m")
+	requiredEnv         []string                      // the environment variables that must be set
+	timeout             time.Duration                 // the timeout for command execution (0 for no timeout)
+	timeoutSet          bool                          // whether a timeout (maybe 0) was configured for the tool
 	shell               string                        // the shell to use
+	shellFlags          string                        // the shell commands prepended to the command (e.g., "set -euo pipefail")
 	toolName            string                        // the name of the tool
 	runnerType          string                        // the type of runner to use
 	runnerOpts          RunnerOptions                 // the options for the runner
+	runnerHealthCheck   string                        // the command checking the runner is healthy (optional)
+	fallbackRunners     []config.MCPToolRunner        // the runners used when the runner is not healthy
+	destructive         bool                          // whether the tool is marked as destructive
+	deprecated          bool                          // whether the tool is deprecated
+	deprecationMessage  string                        // the reason why the tool is deprecated
+	progressInterval    time.Duration                 // the interval between progress notifications (0 when disabled)
+	successExitCodes    []int                         // the exit codes considered successful (only 0 when empty)
+	outputDir           *config.OutputDirConfig       // the per-call output directory configuration (nil when disabled)
+	cache               *resultCache                  // the cache of the outputs (nil when disabled)
+	unknownParams       string                        // the policy for the arguments that are not declared
 
 	logger *common.Logger
 }
@@ -86,7 +107,7 @@ func NewCommandHandler(tool config.Tool, params map[string]common.ParamConfig, s
 	if len(tool.Config.Constraints) > 0 {
 		logger.Debug("Compiling %d constraints for tool '%s'", len(tool.Config.Constraints), tool.MCPTool.Name)
 
-		compiled, err = common.NewCompiledConstraints(tool.Config.Constraints, params, logger)
+		compiled, err = common.NewCompiledConstraintsWithMacros(tool.Config.Constraints, tool.ConstraintMacros, params, logger)
 		if err != nil {
 			logger.Error("Failed to compile constraints for tool %s: %v", tool.MCPTool.Name, err)
 			return nil, fmt.Errorf("constraint compilation error: %w", err)
@@ -95,14 +116,146 @@ func NewCommandHandler(tool config.Tool, params map[string]common.ParamConfig, s
 		logger.Debug("Successfully compiled constraints for tool '%s'", tool.MCPTool.Name)
 	}
 
+	// ... and the same for the advisory constraints
+	var warnings *common.CompiledConstraints
+	if len(tool.Config.Warnings) > 0 {
+		warnings, err = common.NewCompiledConstraintsWithMacros(tool.Config.Warnings, tool.ConstraintMacros, params, logger)
+		if err != nil {
+			logger.Error("Failed to compile warnings for tool %s: %v", tool.MCPTool.Name, err)
+			return nil, fmt.Errorf("warning compilation error: %w", err)
+		}
+	}
+
+	// Compile the patterns the parameters must match
+	paramPatterns, err := compileParamPatterns(params)
+	if err != nil {
+		logger.Error("Failed to compile parameter patterns for tool %s: %v", tool.MCPTool.Name, err)
+		return nil, err
+	}
+
+	// Check the transforms of the parameters
+	if err := checkParamTransforms(params); err != nil {
+		logger.Error("Invalid parameter transform for tool %s: %v", tool.MCPTool.Name, err)
+		return nil, err
+	}
+
 	// Get the effective command, runner type, and options from the tool
 	effectiveCommand := tool.GetEffectiveCommand()
 	effectiveRunnerType := tool.GetEffectiveRunner()
 	effectiveOptions := tool.GetEffectiveOptions()
 
+	// Refuse to create a handler for a runner forbidden by the server policy
+	if tool.IsRunnerDisabled(effectiveRunnerType) {
+		logger.Error("Runner '%s' is disabled for tool '%s'", effectiveRunnerType, tool.MCPTool.Name)
+		return nil, fmt.Errorf("runner '%s' is disabled by server policy", effectiveRunnerType)
+	}
+
 	logger.Debug("Using command: %s", effectiveCommand)
 	logger.Debug("Using runner type: %s", effectiveRunnerType)
 
+	// Long-running tools send progress notifications periodically
+	var progressInterval time.Duration
+	if tool.Config.LongRunning {
+		progressInterval = defaultProgressInterval
+		if tool.Config.Run.ProgressInterval != "" {
+			progressInterval, err = time.ParseDuration(tool.Config.Run.ProgressInterval)
+			if err != nil || progressInterval <= 0 {
+				logger.Error("Invalid progress interval '%s' for tool '%s'", tool.Config.Run.ProgressInterval, tool.MCPTool.Name)
+				return nil, fmt.Errorf("invalid progress interval '%s'", tool.Config.Run.ProgressInterval)
+			}
+		}
+	}
+
+	// Parse the timeout, where "0" means no timeout
+	var timeout time.Duration
+	if tool.Config.Run.Timeout != "" {
+		timeout, err = time.ParseDuration(tool.Config.Run.Timeout)
+		if err != nil || timeout < 0 {
+			logger.Error("Invalid timeout '%s' for tool '%s'", tool.Config.Run.Timeout, tool.MCPTool.Name)
+			return nil, fmt.Errorf("invalid timeout format '%s'", tool.Config.Run.Timeout)
+		}
+	}
+
+	// Check the cleanup policy of the output directory
+	if outputDir := tool.Config.Run.OutputDir; outputDir != nil {
+		switch outputDir.Cleanup {
+		case "", config.OutputDirRemove, config.OutputDirKeep:
+		default:
+			logger.Error("Invalid output directory cleanup '%s' for tool '%s'", outputDir.Cleanup, tool.MCPTool.Name)
+			return nil, fmt.Errorf("invalid output directory cleanup '%s' (must be '%s' or '%s')",
+				outputDir.Cleanup, config.OutputDirRemove, config.OutputDirKeep)
+		}
+	}
+
+	// Check the output format, and parse the JSON path to extract
+	switch tool.Config.Output.Format {
+	case "", common.OutputFormatText, common.OutputFormatJSON:
+	default:
+		logger.Error("Invalid output format '%s' for tool '%s'", tool.Config.Output.Format, tool.MCPTool.Name)
+		return nil, fmt.Errorf("invalid output format '%s' (must be '%s' or '%s')",
+			tool.Config.Output.Format, common.OutputFormatText, common.OutputFormatJSON)
+	}
+	var jsonPath []jsonPathSegment
+	if tool.Config.Output.JSONPath != "" {
+		jsonPath, err = parseJSONPath(tool.Config.Output.JSONPath)
+		if err != nil {
+			logger.Error("Invalid jsonpath for tool '%s': %v", tool.MCPTool.Name, err)
+			return nil, err
+		}
+	}
+
+	// Compile the regex applied to the output, if any
+	outputRegex, err := tool.Config.Output.GetRegex()
+	if err != nil {
+		logger.Error("Invalid output regex for tool '%s': %v", tool.MCPTool.Name, err)
+		return nil, err
+	}
+
+	// Summarizing the output needs a command
+	if summarize := tool.Config.Output.Summarize; summarize != nil && strings.TrimSpace(summarize.Command) == "" {
+		logger.Error("Empty summarize command for tool '%s'", tool.MCPTool.Name)
+		return nil, fmt.Errorf("empty summarize command for tool '%s'", tool.MCPTool.Name)
+	}
+
+	// The health check of the selected runner, and the runners to use when it fails
+	runnerHealthCheck := ""
+	var fallbackRunners []config.MCPToolRunner
+	if tool.SelectedRunner != nil && tool.SelectedRunner.Requirements.HealthCheck != "" {
+		runnerHealthCheck = tool.SelectedRunner.Requirements.HealthCheck
+		fallbackRunners = tool.GetFallbackRunners()
+	}
+
+	// Check the policy for the unknown parameters
+	switch tool.Config.UnknownParams {
+	case "", config.UnknownParamsStrict, config.UnknownParamsIgnore, config.UnknownParamsPassthrough:
+	default:
+		logger.Error("Invalid unknown_params '%s' for tool '%s'", tool.Config.UnknownParams, tool.MCPTool.Name)
+		return nil, fmt.Errorf("invalid unknown_params '%s' (must be '%s', '%s' or '%s')",
+			tool.Config.UnknownParams, config.UnknownParamsStrict, config.UnknownParamsIgnore, config.UnknownParamsPassthrough)
+	}
+
+	// Create the cache of the outputs, if enabled
+	var cache *resultCache
+	if cacheConfig := tool.Config.Cache; cacheConfig != nil {
+		// the output directories are removed after every call, so cached outputs would
+		// point to (or list) files that do not exist anymore
+		if tool.Config.Run.OutputDir != nil {
+			logger.Error("Tool '%s' cannot use both a cache and an output directory", tool.MCPTool.Name)
+			return nil, fmt.Errorf("tool '%s' cannot use both 'cache' and 'output_dir'", tool.MCPTool.Name)
+		}
+
+		ttl, err := time.ParseDuration(cacheConfig.TTL)
+		if err != nil || ttl <= 0 {
+			logger.Error("Invalid cache TTL '%s' for tool '%s'", cacheConfig.TTL, tool.MCPTool.Name)
+			return nil, fmt.Errorf("invalid cache ttl '%s'", cacheConfig.TTL)
+		}
+		maxEntries := cacheConfig.MaxEntries
+		if maxEntries <= 0 {
+			maxEntries = config.DefaultCacheMaxEntries
+		}
+		cache = newResultCache(ttl, maxEntries)
+	}
+
 	// Convert the runner options to RunnerOptions
 	runnerOpts := RunnerOptions{}
 	if effectiveOptions != nil {
@@ -116,19 +269,53 @@ func NewCommandHandler(tool config.Tool, params map[string]common.ParamConfig, s
 	return &CommandHandler{
 		cmd:                 effectiveCommand,
 		output:              tool.Config.Output,
+		jsonPath:            jsonPath,
+		outputRegex:         outputRegex,
 		constraints:         tool.Config.Constraints,
 		params:              params,
+		paramPatterns:       paramPatterns,
 		constraintsCompiled: compiled,
+		constraintLog:       tool.ConstraintLog,
+		warnings:            warnings,
 		envVars:             tool.Config.Run.Env,
-		timeout:             tool.Config.Run.Timeout,
+		requiredEnv:         tool.Config.Run.RequireEnv,
+		timeout:             timeout,
+		timeoutSet:          tool.Config.Run.Timeout != "",
 		shell:               shell,
+		shellFlags:          tool.Config.Run.ShellFlags,
 		toolName:            tool.MCPTool.Name,
 		runnerType:          effectiveRunnerType,
 		runnerOpts:          runnerOpts,
+		runnerHealthCheck:   runnerHealthCheck,
+		fallbackRunners:     fallbackRunners,
+		destructive:         tool.Config.Destructive,
+		deprecated:          tool.Config.Deprecated,
+		deprecationMessage:  tool.Config.DeprecationMessage,
+		progressInterval:    progressInterval,
+		successExitCodes:    tool.Config.Run.SuccessExitCodes,
+		outputDir:           tool.Config.Run.OutputDir,
+		cache:               cache,
+		unknownParams:       tool.Config.UnknownParams,
 		logger:              logger,
 	}, nil
 }
 
+// SetTimeout overrides the timeout configured for the tool (0 for no timeout)
+func (h *CommandHandler) SetTimeout(timeout time.Duration) {
+	h.timeout = timeout
+	h.timeoutSet = true
+}
+
+// DisableCache disables the cache of the outputs of the tool, so the command always runs
+func (h *CommandHandler) DisableCache() {
+	h.cache = nil
+}
+
+// IsDestructive returns true if the tool is marked as destructive
+func (h *CommandHandler) IsDestructive() bool {
+	return h.destructive
+}
+
 // GetMCPHandler returns a function that handles MCP tool calls by executing shell commands.
 //
 // This is the function that should be registered with the MCP server.
@@ -147,6 +334,19 @@ func (h *CommandHandler) GetMCPHandler() func(ctx context.Context, request mcp.C
 			}
 		}
 
+		// Check the arguments against the declared parameters, so the client gets
+		// precise feedback before anything is executed. They are checked as they will be
+		// used, normalized, but on a copy: the transforms are applied (only once) when
+		// the command is executed, as value maps can be chained (like a->b, b->c)
+		checked := maps.Clone(args)
+		h.transformParams(checked)
+		if problems := h.validateArguments(checked); len(problems) > 0 {
+			h.logger.Info("Invalid arguments for tool '%s': %v", h.toolName, problems)
+			result := mcp.NewToolResultError(fmt.Sprintf("invalid arguments for tool '%s':\n- %s", h.toolName, strings.Join(problems, "\n- ")))
+			result.Meta = mcp.NewMetaFromMap(map[string]any{"validationErrors": problems})
+			return result, nil
+		}
+
 		// Extract runner options if present
 		var runnerOpts map[string]interface{}
 		if args != nil {
@@ -157,24 +357,59 @@ func (h *CommandHandler) GetMCPHandler() func(ctx context.Context, request mcp.C
 
 		// Apply timeout if configured
 		executionCtx := ctx
-		if h.timeout != "" {
-			timeout, err := time.ParseDuration(h.timeout)
-			if err != nil {
-				return mcp.NewToolResultError(fmt.Sprintf("invalid timeout format '%s': %v", h.timeout, err)), nil
-			}
+		if h.timeout > 0 {
 			var cancel context.CancelFunc
-			executionCtx, cancel = context.WithTimeout(ctx, timeout)
+			executionCtx, cancel = context.WithTimeout(ctx, h.timeout)
 			defer cancel()
 		}
 
+		// Keep the client informed while long-running tools execute
+		stopProgress := h.startProgress(executionCtx, request)
+
 		// Execute the command using the common implementation
-		output, _, err := h.executeToolCommand(executionCtx, args, runnerOpts)
+		output, code, stderr, _, err := h.executeToolCommand(executionCtx, args, runnerOpts)
+		stopProgress()
+
+		var result *mcp.CallToolResult
 		if err != nil {
-			return mcp.NewToolResultError(err.Error()), nil
+			result = mcp.NewToolResultError(err.Error())
+		} else {
+			result = mcp.NewToolResultText(output)
+		}
+
+		// Expose the exit code of the command, when it was run, and its stderr if requested
+		meta := map[string]any{}
+		if code >= 0 {
+			meta["exitCode"] = code
+		}
+		if h.output.ExposeStderr && stderr != "" {
+			meta["stderr"] = truncateStderr(stderr)
 		}
+		if len(meta) > 0 {
+			result.Meta = mcp.NewMetaFromMap(meta)
+		}
+
+		return result, nil
+	}
+}
 
-		return mcp.NewToolResultText(output), nil
+// maxExposedStderr is the maximum size (in bytes) of the stderr exposed in the result metadata
+const maxExposedStderr = 8 * 1024
+
+// truncateStderr bounds the size of the stderr exposed in the result metadata
+func truncateStderr(stderr string) string {
+	if len(stderr) <= maxExposedStderr {
+		return stderr
+	}
+	return stderr[:maxExposedStderr] + fmt.Sprintf("\n[... truncated to the first %d bytes ...]", maxExposedStderr)
+}
+
+// isSuccessExitCode returns true if the given exit code of the command is considered successful
+func (h *CommandHandler) isSuccessExitCode(code int) bool {
+	if len(h.successExitCodes) == 0 {
+		return code == 0
 	}
+	return slices.Contains(h.successExitCodes, code)
 }
 
 // getEnvironmentVariables gets the environment variables for the process.
@@ -210,3 +445,14 @@ func (h *CommandHandler) getEnvironmentVariables(params map[string]interface{})
 
 	return envVars
 }
+
+// MissingEnvVars returns the names of the environment variables that are not set (or are empty)
+func MissingEnvVars(names []string) []string {
+	var missing []string
+	for _, name := range names {
+		if os.Getenv(name) == "" {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
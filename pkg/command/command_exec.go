@@ -2,12 +2,22 @@
 package command
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/inercia/MCPShell/pkg/common"
+	"github.com/inercia/MCPShell/pkg/config"
 )
 
 // executeToolCommand handles the core logic of executing a command with the given parameters.
@@ -20,13 +30,61 @@ import (
 //
 // Returns:
 //   - The command output as a string
+//   - The exit code of the command, or -1 if it was not run
+//   - The standard error of the successful command (empty for cached outputs)
 //   - A slice of failed constraint messages
 //   - An error if command execution fails
-func (h *CommandHandler) executeToolCommand(ctx context.Context, params map[string]interface{}, extraRunnerOpts map[string]interface{}) (string, []string, error) {
+func (h *CommandHandler) executeToolCommand(ctx context.Context, params map[string]interface{}, extraRunnerOpts map[string]interface{}) (string, int, string, []string, error) {
 	// Log the tool execution
 	h.logger.Debug("Tool execution requested for '%s'", h.toolName)
 	h.logger.Debug("Arguments: %v", params)
 
+	// Work on a copy of the arguments, as they are modified below (with the defaults, the
+	// transforms...) and callers can run the same arguments concurrently (like bench)
+	params = maps.Clone(params)
+	if params == nil {
+		params = make(map[string]interface{})
+	}
+
+	// Warn about deprecated tools (they can still be used)
+	if h.deprecated {
+		if h.deprecationMessage != "" {
+			h.logger.Warn("Tool '%s' is deprecated: %s", h.toolName, h.deprecationMessage)
+		} else {
+			h.logger.Warn("Tool '%s' is deprecated", h.toolName)
+		}
+	}
+
+	// Fail fast if the environment the tool depends on is not available
+	if missing := MissingEnvVars(h.requiredEnv); len(missing) > 0 {
+		h.logger.Error("Tool '%s' requires environment variables that are not set: %v", h.toolName, missing)
+		if len(missing) == 1 {
+			return "", -1, "", nil, newKindError(ErrRequirementNotMet, "tool '%s' requires environment variable %s", h.toolName, missing[0])
+		}
+		return "", -1, "", nil, newKindError(ErrRequirementNotMet, "tool '%s' requires environment variables %s", h.toolName, strings.Join(missing, ", "))
+	}
+
+	// Choose the runner for this call, falling back to other runners when it is not healthy
+	selectedRunner, selectedRunnerOpts, err := h.selectRunner(ctx)
+	if err != nil {
+		h.logger.Error("Error selecting runner: %v", err)
+		return "", -1, "", nil, err
+	}
+
+	// Reject or drop the arguments that are not declared as parameters, depending on the policy
+	if unknown := h.unknownArguments(params); len(unknown) > 0 {
+		switch h.unknownParams {
+		case config.UnknownParamsStrict:
+			h.logger.Error("Unknown parameters for tool '%s': %v", h.toolName, unknown)
+			return "", -1, "", nil, fmt.Errorf("unknown parameters for tool '%s': %s", h.toolName, strings.Join(unknown, ", "))
+		case config.UnknownParamsIgnore:
+			h.logger.Debug("Ignoring unknown parameters for tool '%s': %v", h.toolName, unknown)
+			for _, name := range unknown {
+				delete(params, name)
+			}
+		}
+	}
+
 	// Apply default values for parameters that aren't provided but have defaults
 	for paramName, paramConfig := range h.params {
 		if _, exists := params[paramName]; !exists && paramConfig.Default != nil {
@@ -35,29 +93,41 @@ func (h *CommandHandler) executeToolCommand(ctx context.Context, params map[stri
 		}
 	}
 
+	// Normalize the values of the parameters
+	h.transformParams(params)
+
 	// Check for required parameters that weren't provided and don't have defaults
 	for paramName, paramConfig := range h.params {
 		if paramConfig.Required {
 			if _, exists := params[paramName]; !exists {
 				h.logger.Error("Required parameter missing: %s", paramName)
-				return "", nil, fmt.Errorf("required parameter missing: %s", paramName)
+				return "", -1, "", nil, fmt.Errorf("required parameter missing: %s", paramName)
 			}
 		}
 	}
 
+	// Check the arguments against the declared parameters (for the calls that were
+	// not checked before, like the direct executions)
+	if problems := h.validateArguments(params); len(problems) > 0 {
+		h.logger.Error("Invalid arguments for tool '%s': %v", h.toolName, problems)
+		return "", -1, "", nil, fmt.Errorf("invalid arguments for tool '%s': %s", h.toolName, strings.Join(problems, "; "))
+	}
+
 	// Validate constraints before executing command
 	var failedConstraints []string
 	if h.constraintsCompiled != nil {
 		h.logger.Debug("Checking %d constraints", len(h.constraints))
-		satisfied, failed, err := h.constraintsCompiled.Evaluate(params, h.params)
+		satisfied, failed, err := h.constraintsCompiled.EvaluateWithRunner(params, h.params, selectedRunner)
+		h.logConstraintDecision(params, selectedRunner, satisfied, failed, err)
 		if err != nil {
 			h.logger.Error("Error evaluating constraints: %v", err)
-			return "", nil, fmt.Errorf("error evaluating constraints: %v", err)
+			return "", -1, "", nil, fmt.Errorf("error evaluating constraints: %v", err)
 		}
 		if !satisfied {
 			h.logger.Info("Constraints not satisfied, blocking execution")
 			failedConstraints = failed
-			errorMsg := "command execution blocked by constraints"
+			h.recordConstraintRejections(failedConstraints)
+			errorMsg := ""
 
 			// Add details about which constraints failed
 			if len(failedConstraints) > 0 {
@@ -70,42 +140,83 @@ func (h *CommandHandler) executeToolCommand(ctx context.Context, params map[stri
 				}
 			}
 
-			return "", failedConstraints, fmt.Errorf("%s", errorMsg)
+			return "", -1, "", failedConstraints, fmt.Errorf("%w%s", ErrConstraintBlocked, errorMsg)
 		}
 		h.logger.Debug("All constraints satisfied")
 	}
 
+	// Evaluate the advisory constraints, which never block the execution
+	warnings := h.evaluateWarnings(params, selectedRunner)
+
+	// Return the cached output of a previous call with the same arguments, if still fresh
+	callKey := ""
+	if h.cache != nil {
+		key, err := cacheKey(h.toolName, params)
+		if err != nil {
+			h.logger.Error("Cannot cache the output of tool '%s': %v", h.toolName, err)
+		} else if output, code, ok := h.cache.get(key); ok {
+			h.logger.Debug("Returning the cached output of tool '%s'", h.toolName)
+			return output, code, "", nil, nil
+		} else {
+			callKey = key
+		}
+	}
+
+	// Create the output directory for tools producing files
+	outputDir := ""
+	if h.outputDir != nil {
+		var cleanupOutputDir func()
+		var err error
+		outputDir, cleanupOutputDir, err = h.createOutputDir()
+		if err != nil {
+			h.logger.Error("Error creating output directory: %v", err)
+			return "", -1, "", nil, err
+		}
+		defer cleanupOutputDir()
+		params[outputDirParam] = outputDir
+	}
+
 	// Process the command template with the tool arguments
 	// h.logger.Debug("Processing command template:\n%s", h.cmd)
 
 	cmd, err := common.ProcessTemplate(h.cmd, params)
 	if err != nil {
 		h.logger.Error("Error processing command template: %v", err)
-		return "", nil, fmt.Errorf("error processing command template: %v", err)
+		return "", -1, "", nil, fmt.Errorf("error processing command template: %v", err)
 	}
 
-	// Wrap command with timeout if configured and timeout command is available
-	if h.timeout != "" {
-		timeoutDuration, err := time.ParseDuration(h.timeout)
-		if err != nil {
-			h.logger.Error("Invalid timeout format '%s': %v", h.timeout, err)
-			return "", nil, fmt.Errorf("invalid timeout format '%s': %v", h.timeout, err)
-		}
+	// Keep the resolved command (before any timeout wrapping) for the output
+	resolvedCmd := cmd
+	wrappedWithTimeout := false
+
+	// Prepend the shell flags, so they apply to the whole script
+	if h.shellFlags != "" {
+		h.logger.Debug("Prepending shell flags: %s", h.shellFlags)
+		cmd = h.shellFlags + "\n" + cmd
+	}
 
+	// Wrap command with timeout if configured and timeout command is available
+	if h.timeout > 0 {
 		// Convert to seconds for the timeout command
-		timeoutSeconds := int(timeoutDuration.Seconds())
+		timeoutSeconds := int(h.timeout.Seconds())
 		if timeoutSeconds < 1 {
 			timeoutSeconds = 1 // Minimum 1 second
 		}
 
-		// Escape single quotes in the command for shell
-		escapedCmd := strings.ReplaceAll(cmd, "'", "'\"'\"'")
-
 		// On Unix systems, try to use the 'timeout' command if available, otherwise use context-based timeout
 		// On Windows, always use context-based timeout as 'timeout' command doesn't limit execution time
-		if shouldUseUnixTimeoutCommand() {
-			// On Unix/Linux/macOS systems, use timeout command with Unix syntax
-			cmd = fmt.Sprintf("timeout --kill-after=5s %ds sh -c '%s'", timeoutSeconds, escapedCmd)
+		// Commands run in containers or remote hosts (that may not have the 'timeout' command)
+		// are also limited by the context only
+		if runsInHost(RunnerType(selectedRunner)) && shouldUseUnixTimeoutCommand() {
+			// On Unix/Linux/macOS systems, use timeout command with Unix syntax, running the
+			// script with the same shell as without a timeout (so the shell flags still work)
+			shell, shellArgs := getShellCommandArgs(getShell(h.shell), cmd)
+			quoted := []string{shellQuote(shell)}
+			for _, arg := range shellArgs {
+				quoted = append(quoted, shellQuote(arg))
+			}
+			cmd = fmt.Sprintf("timeout --kill-after=5s %ds %s", timeoutSeconds, strings.Join(quoted, " "))
+			wrappedWithTimeout = true
 			h.logger.Debug("Wrapped command with Unix timeout: %ds", timeoutSeconds)
 		} else {
 			// timeout command not available on this platform or this is Windows
@@ -124,23 +235,18 @@ func (h *CommandHandler) executeToolCommand(ctx context.Context, params map[stri
 
 	// Determine which runner to use based on the configuration
 	runnerType := RunnerTypeExec // default runner
-	if h.runnerType != "" {
-		h.logger.Debug("Using configured runner type: %s", h.runnerType)
-		switch h.runnerType {
-		case string(RunnerTypeExec):
-			runnerType = RunnerTypeExec
-		case string(RunnerTypeSandboxExec):
-			runnerType = RunnerTypeSandboxExec
-		case string(RunnerTypeFirejail):
-			runnerType = RunnerTypeFirejail
-		default:
-			h.logger.Error("Unknown runner type '%s', falling back to default runner", h.runnerType)
+	if selectedRunner != "" {
+		h.logger.Debug("Using configured runner type: %s", selectedRunner)
+		if _, ok := registeredRunners[RunnerType(selectedRunner)]; ok {
+			runnerType = RunnerType(selectedRunner)
+		} else {
+			h.logger.Error("Unknown runner type '%s', falling back to default runner", selectedRunner)
 		}
 	}
 
 	// Start with the configured runner options from the tool definition
 	runnerOptions := RunnerOptions{}
-	for k, v := range h.runnerOpts {
+	for k, v := range selectedRunnerOpts {
 		runnerOptions[k] = v
 	}
 
@@ -152,44 +258,187 @@ func (h *CommandHandler) executeToolCommand(ctx context.Context, params map[stri
 		}
 	}
 
+	// The output directory is created in the host: mount it in the container, at the same
+	// path, so the command can write to it. Persistent containers are started once, so
+	// they cannot mount the directory of every call.
+	if outputDir != "" && runnerType == RunnerTypeDocker {
+		if persistent, _ := runnerOptions["persistent"].(bool); persistent {
+			h.logger.Warn("The output directory of tool '%s' is not mounted in its persistent container", h.toolName)
+		} else {
+			mounts, _ := runnerOptions["mounts"].([]interface{})
+			runnerOptions["mounts"] = append(slices.Clone(mounts), outputDir+":"+outputDir)
+		}
+	}
+
 	// Create the appropriate runner with options
 	h.logger.Debug("Creating runner of type %s and checking implicit requirements", runnerType)
 	runner, err := NewRunner(runnerType, runnerOptions, h.logger)
 	if err != nil {
 		h.logger.Error("Error creating runner: %v", err)
-		return "", nil, fmt.Errorf("error creating runner: %v", err)
+		return "", -1, "", nil, fmt.Errorf("error creating runner: %w", err)
 	}
 
 	// Execute the command (timeout is handled by the context passed in from caller)
-	commandOutput, err := runner.Run(ctx, h.shell, cmd, env, params, true)
+	result, err := runner.Run(ctx, h.shell, cmd, env, params, true)
+	code := -1
+	if result != nil {
+		code = result.ExitCode
+	}
+
+	// Commands exiting with a non-zero exit code declared as successful did not fail
+	if err != nil && code > 0 && h.isSuccessExitCode(code) && ctx.Err() == nil {
+		h.logger.Debug("Command exited with %d, which is considered a success", code)
+		err = nil
+	}
 	if err != nil {
 		h.logger.Error("Error executing command: %v", err)
-		return "", nil, err
+		return "", code, "", nil, h.classifyRunError(ctx, err, wrappedWithTimeout)
+	}
+	if !h.isSuccessExitCode(code) {
+		h.logger.Error("Command exited with %d, which is not considered a success", code)
+		return "", code, "", nil, &ErrExit{Code: code, Err: fmt.Errorf("command exited with code %d", code)}
+	}
+
+	// Use the output as it was written when the whitespace is significant
+	if h.output.PreserveWhitespace {
+		result.Stdout, result.Stderr = result.RawStdout, result.RawStderr
+	}
+
+	// Validate and pretty-print JSON outputs, extracting a subtree if requested
+	if h.output.Format == common.OutputFormatJSON || h.output.JSONPath != "" {
+		formatted, err := formatJSONOutput(result.Stdout, h.jsonPath, h.output.JSONPath)
+		if err != nil {
+			h.logger.Error("Error formatting the output of tool '%s': %v", h.toolName, err)
+			return "", code, "", nil, err
+		}
+		result.Stdout = formatted
+	}
+
+	// Extract (or replace) the parts of the output matching the regex
+	if h.outputRegex != nil {
+		result.Stdout = applyOutputRegex(result.Stdout, h.outputRegex, h.output.RegexReplace)
 	}
 
 	// Process the output
-	finalOutput := commandOutput
+	finalOutput := h.commandOutput(result)
+
+	// Clean up whitespace if requested
+	if h.output.Normalize {
+		finalOutput = normalizeOutput(finalOutput)
+	}
+
+	// Hide the URLs before the output (or its summary) leaves the server
+	if h.output.RedactURLs != nil {
+		finalOutput = redactURLs(finalOutput, h.output.RedactURLs)
+	}
+
+	// Summarize oversized outputs if requested
+	if summarize := h.output.Summarize; summarize != nil && len(finalOutput) > summarize.GetThreshold() {
+		h.logger.Debug("Output size %d exceeds the threshold %d: summarizing with '%s'",
+			len(finalOutput), summarize.GetThreshold(), summarize.Command)
+		summary, err := h.summarizeOutput(ctx, summarize.Command, finalOutput, env)
+		if err != nil {
+			h.logger.Error("Error summarizing output: %v", err)
+			return "", code, "", nil, fmt.Errorf("error summarizing output: %v", err)
+		}
+		finalOutput = summary
+	}
+
+	// Keep only the first lines of long outputs
+	if maxLines := h.output.MaxLines; maxLines > 0 {
+		finalOutput = truncateOutputLines(finalOutput, maxLines)
+	}
+
+	// Truncate outputs that are still too big
+	if maxBytes := h.output.GetMaxBytes(); maxBytes > 0 && len(finalOutput) > maxBytes {
+		h.logger.Info("Truncating the output of tool '%s' from %d to %d bytes", h.toolName, len(finalOutput), maxBytes)
+		finalOutput = truncateOutput(finalOutput, maxBytes)
+	}
+
+	// List the files produced by the tool if requested
+	if h.outputDir != nil && h.outputDir.ListFiles {
+		files, err := listOutputFiles(outputDir)
+		if err != nil {
+			h.logger.Error("Error listing output files: %v", err)
+			return "", code, "", nil, fmt.Errorf("error listing output files: %v", err)
+		}
+		if strings.TrimSpace(finalOutput) == "" {
+			finalOutput = files
+		} else {
+			finalOutput = strings.TrimRight(finalOutput, "\n") + "\n\n" + files
+		}
+	}
 
-	// Apply prefix if provided
-	if h.output.Prefix != "" {
-		h.logger.Debug("Applying output prefix template: %s", h.output.Prefix)
+	// Prepend the resolved command if requested
+	if h.output.IncludeCommand {
+		finalOutput = "$ " + strings.TrimSpace(resolvedCmd) + "\n\n" + finalOutput
+	}
+
+	// Render the output template, or apply the prefix and postfix if provided
+	if h.output.Template != "" {
+		h.logger.Debug("Applying output template: %s", h.output.Template)
 
-		// Process the prefix template with the tool arguments
-		prefix, err := common.ProcessTemplate(h.output.Prefix, params)
+		// The template gets the tool arguments, as well as the command output
+		vars := make(map[string]interface{}, len(params)+1)
+		for k, v := range params {
+			vars[k] = v
+		}
+		vars["output"] = finalOutput
+
+		rendered, err := common.ProcessTemplate(h.output.Template, vars)
 		if err != nil {
-			h.logger.Error("Error processing output prefix template: %v", err)
-			return "", nil, fmt.Errorf("error processing output prefix template: %v", err)
+			h.logger.Error("Error processing output template: %v", err)
+			return "", code, "", nil, fmt.Errorf("error processing output template: %v", err)
+		}
+		finalOutput = rendered
+	} else {
+		if h.output.Prefix != "" {
+			h.logger.Debug("Applying output prefix template: %s", h.output.Prefix)
+
+			// Process the prefix template with the tool arguments
+			prefix, err := common.ProcessTemplate(h.output.Prefix, params)
+			if err != nil {
+				h.logger.Error("Error processing output prefix template: %v", err)
+				return "", code, "", nil, fmt.Errorf("error processing output prefix template: %v", err)
+			}
+
+			// Combine prefix and command output
+			finalOutput = strings.TrimSpace(prefix) + "\n\n" + finalOutput
+		}
+
+		if h.output.Postfix != "" {
+			h.logger.Debug("Applying output postfix template: %s", h.output.Postfix)
+
+			// Process the postfix template with the tool arguments
+			postfix, err := common.ProcessTemplate(h.output.Postfix, params)
+			if err != nil {
+				h.logger.Error("Error processing output postfix template: %v", err)
+				return "", code, "", nil, fmt.Errorf("error processing output postfix template: %v", err)
+			}
+
+			// Combine command output and postfix
+			finalOutput = finalOutput + "\n\n" + strings.TrimSpace(postfix)
 		}
+	}
+	h.logger.Debug("Final output:\n--------------------------------\n%s\n--------------------------------", finalOutput)
 
-		// Combine prefix and command output
-		finalOutput = strings.TrimSpace(prefix) + "\n\n" + finalOutput
-		h.logger.Debug("Final output with prefix:\n--------------------------------\n%s\n--------------------------------", finalOutput)
+	// Put the warnings first, so they are not missed
+	if len(warnings) > 0 {
+		finalOutput = strings.Join(warnings, "\n") + "\n\n" + finalOutput
+	}
+
+	// Keep the output for the next calls with the same arguments
+	if callKey != "" {
+		h.cache.put(callKey, finalOutput, code)
 	}
 
 	h.logger.Debug("Tool execution completed successfully")
-	return finalOutput, nil, nil
+	return finalOutput, code, result.Stderr, nil, nil
 }
 
+// defaultExecuteTimeout is the timeout for the direct execution of tools without a timeout
+const defaultExecuteTimeout = 60 * time.Second
+
 // ExecuteCommand handles the direct execution of a command without going through the MCP server.
 // This is used by the "exe" command to execute a tool directly from the command line.
 //
@@ -215,20 +464,19 @@ func (h *CommandHandler) ExecuteCommand(params map[string]interface{}) (string,
 	}
 
 	// Create context with timeout for command execution
-	// Use configured timeout if available, otherwise use a default of 60 seconds
-	timeout := 60 * time.Second
-	if h.timeout != "" {
-		parsedTimeout, err := time.ParseDuration(h.timeout)
-		if err != nil {
-			return "", fmt.Errorf("invalid timeout format '%s': %v", h.timeout, err)
-		}
-		timeout = parsedTimeout
+	// Use configured timeout if available (0 meaning no timeout), otherwise use a default of 60 seconds
+	timeout := defaultExecuteTimeout
+	if h.timeoutSet {
+		timeout = h.timeout
+	}
+	ctx, cancel := context.Background(), context.CancelFunc(func() {})
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	// Use the common implementation
-	output, failedConstraints, err := h.executeToolCommand(ctx, params, runnerOpts)
+	output, _, _, failedConstraints, err := h.executeToolCommand(ctx, params, runnerOpts)
 
 	// If constraints failed, format the error message
 	if err != nil && len(failedConstraints) > 0 {
@@ -237,3 +485,150 @@ func (h *CommandHandler) ExecuteCommand(params map[string]interface{}) (string,
 
 	return output, err
 }
+
+// classifyRunError converts the error returned by a runner into one of the typed errors
+// of this package: ErrTimeout when the command did not finish in time (either because the
+// context expired or because the timeout command killed it), or ErrExit when the command
+// exited with a non-zero exit code. Other errors are returned unchanged.
+func (h *CommandHandler) classifyRunError(ctx context.Context, err error, wrappedWithTimeout bool) error {
+	code := exitCode(err)
+
+	// the timeout command exits with 124 when the command times out
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) || (wrappedWithTimeout && code == 124) {
+		if h.timeout > 0 {
+			return &kindError{kind: ErrTimeout, msg: fmt.Sprintf("tool '%s' timed out after %s", h.toolName, h.timeout), cause: err}
+		}
+		return wrapKindError(ErrTimeout, err)
+	}
+
+	if code > 0 {
+		return &ErrExit{Code: code, Err: err}
+	}
+
+	return err
+}
+
+// evaluateWarnings evaluates the advisory constraints of the tool, returning a warning
+// message for each one that is not satisfied. Errors are logged and ignored, as these
+// constraints never block the execution.
+func (h *CommandHandler) evaluateWarnings(params map[string]interface{}, runner string) []string {
+	if h.warnings == nil {
+		return nil
+	}
+
+	satisfied, failed, err := h.warnings.EvaluateWithRunner(params, h.params, runner)
+	if err != nil {
+		h.logger.Error("Error evaluating warnings for tool '%s': %v", h.toolName, err)
+		return nil
+	}
+	if satisfied {
+		return nil
+	}
+
+	messages := make([]string, 0, len(failed))
+	for _, f := range failed {
+		h.logger.Info("Warning for tool '%s': %s", h.toolName, f)
+		messages = append(messages, "WARNING: "+f)
+	}
+	return messages
+}
+
+// stderrDelimiter separates the standard output from the standard error in the output
+const stderrDelimiter = "--- stderr ---"
+
+// commandOutput returns the output of a command, including its standard error
+// (or only it) when the tool asks for it
+func (h *CommandHandler) commandOutput(result *RunResult) string {
+	switch {
+	case h.output.StderrOnly:
+		return result.Stderr
+	case h.output.IncludeStderr && result.Stderr != "":
+		if result.Stdout == "" {
+			return stderrDelimiter + "\n" + result.Stderr
+		}
+		return result.Stdout + "\n\n" + stderrDelimiter + "\n" + result.Stderr
+	default:
+		return result.Stdout
+	}
+}
+
+// truncateOutput cuts the output to (at most) maxBytes, without splitting a UTF-8
+// character, appending a notice with the number of bytes omitted
+func truncateOutput(output string, maxBytes int) string {
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(output[cut]) {
+		cut--
+	}
+	return output[:cut] + fmt.Sprintf("\n... (truncated, %d bytes omitted)", len(output)-cut)
+}
+
+// truncateOutputLines keeps the first maxLines lines of the output, appending a notice
+// with the number of lines omitted when there are more
+func truncateOutputLines(output string, maxLines int) string {
+	lines := strings.SplitAfter(output, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) <= maxLines {
+		return output
+	}
+	return strings.Join(lines[:maxLines], "") + fmt.Sprintf("... (truncated, %d lines omitted)", len(lines)-maxLines)
+}
+
+// normalizeOutput trims the trailing whitespace of every line, removes the leading
+// blank lines and collapses consecutive blank lines into a single one
+func normalizeOutput(output string) string {
+	lines := strings.Split(output, "\n")
+	result := make([]string, 0, len(lines))
+	previousBlank := false
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		blank := line == ""
+		if blank && (previousBlank || len(result) == 0) {
+			continue
+		}
+		previousBlank = blank
+		result = append(result, line)
+	}
+	return strings.Join(result, "\n")
+}
+
+// urlRegexp matches URLs with a scheme (like https://host/path?query)
+var urlRegexp = regexp.MustCompile(`\b[a-zA-Z][a-zA-Z0-9+.-]*://[^\s<>"'\x60]+`)
+
+// redactURLs replaces the URLs in the output with a placeholder or, when the host
+// must be kept, with just their scheme and host
+func redactURLs(output string, cfg *common.RedactURLsConfig) string {
+	return urlRegexp.ReplaceAllStringFunc(output, func(match string) string {
+		// Punctuation at the end is more likely part of the text than of the URL
+		rawURL := strings.TrimRight(match, ".,;:!?)]}")
+		trailing := match[len(rawURL):]
+
+		if cfg.KeepHost {
+			if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+				return u.Scheme + "://" + u.Host + trailing
+			}
+		}
+		return cfg.GetPlaceholder() + trailing
+	})
+}
+
+// summarizeOutput runs the summarization command with the output in its standard input,
+// returning what the command writes to its standard output
+func (h *CommandHandler) summarizeOutput(ctx context.Context, command string, output string, env []string) (string, error) {
+	shellPath, args := getShellCommandArgs(getShell(h.shell), command)
+	summarizeCmd := exec.CommandContext(ctx, shellPath, args...)
+	summarizeCmd.Env = append(os.Environ(), env...)
+	summarizeCmd.Stdin = strings.NewReader(output)
+
+	var stdout, stderr bytes.Buffer
+	summarizeCmd.Stdout = &stdout
+	summarizeCmd.Stderr = &stderr
+	if err := summarizeCmd.Run(); err != nil {
+		if stderr.Len() > 0 {
+			return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		return "", err
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -0,0 +1,83 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Decisions recorded in the constraint log
+const (
+	constraintDecisionPass  = "pass"
+	constraintDecisionBlock = "block"
+	constraintDecisionError = "error"
+)
+
+// constraintLogMu serializes the writes to the constraint logs
+var constraintLogMu sync.Mutex
+
+// constraintRecord is the record written to the constraint log for every evaluation
+// of the constraints of a tool
+type constraintRecord struct {
+	Time        string                 `json:"time"`
+	Tool        string                 `json:"tool"`
+	Runner      string                 `json:"runner,omitempty"`
+	Decision    string                 `json:"decision"`
+	Constraints []string               `json:"constraints"`
+	Failed      []string               `json:"failed,omitempty"`
+	Error       string                 `json:"error,omitempty"`
+	Args        map[string]interface{} `json:"args"`
+}
+
+// logConstraintDecision appends a JSON record with the result of the evaluation of the
+// constraints to the constraint log (when configured). Failures for writing the record
+// are logged, but they do not affect the execution of the tool.
+func (h *CommandHandler) logConstraintDecision(params map[string]interface{}, runner string, satisfied bool, failed []string, evalErr error) {
+	if h.constraintLog == "" {
+		return
+	}
+
+	record := constraintRecord{
+		Time:        time.Now().UTC().Format(time.RFC3339Nano),
+		Tool:        h.toolName,
+		Runner:      runner,
+		Decision:    constraintDecisionPass,
+		Constraints: h.constraints,
+		Failed:      failed,
+		Args:        params,
+	}
+	switch {
+	case evalErr != nil:
+		record.Decision = constraintDecisionError
+		record.Error = evalErr.Error()
+	case !satisfied:
+		record.Decision = constraintDecisionBlock
+	}
+
+	if err := appendJSONRecord(h.constraintLog, record); err != nil {
+		h.logger.Error("Failed to write to the constraint log: %v", err)
+	}
+}
+
+// appendJSONRecord appends a record (as a single line of JSON) to the given file
+func appendJSONRecord(path string, record interface{}) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode record: %w", err)
+	}
+
+	constraintLogMu.Lock()
+	defer constraintLogMu.Unlock()
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,75 @@
+package command
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ConstraintRejection is the number of executions of a tool blocked by one of its constraints
+type ConstraintRejection struct {
+	Tool       string `json:"tool"`
+	Constraint string `json:"constraint"`
+	Count      int64  `json:"count"`
+}
+
+// constraintRejectionKey identifies a constraint of a tool
+type constraintRejectionKey struct {
+	tool       string
+	constraint string
+}
+
+// constraintRejections counts the executions blocked by every constraint of every tool.
+// Constraints are counted by their expression (not by the values they were evaluated
+// with), so the number of counters is bounded by the configuration.
+var constraintRejections = struct {
+	sync.Mutex
+	counts map[constraintRejectionKey]int64
+}{counts: map[constraintRejectionKey]int64{}}
+
+// recordConstraintRejections counts an execution blocked by the failed constraints,
+// as they are returned by the evaluation of the constraints
+func (h *CommandHandler) recordConstraintRejections(failed []string) {
+	constraintRejections.Lock()
+	defer constraintRejections.Unlock()
+
+	for _, failure := range failed {
+		key := constraintRejectionKey{tool: h.toolName, constraint: h.failedConstraintExpression(failure)}
+		constraintRejections.counts[key]++
+	}
+}
+
+// failedConstraintExpression returns the expression of a failed constraint, without
+// the values of the arguments included in the failure
+func (h *CommandHandler) failedConstraintExpression(failure string) string {
+	for _, constraint := range h.constraints {
+		if failure == constraint || strings.HasPrefix(failure, constraint+" (with values:") {
+			return constraint
+		}
+	}
+	return failure
+}
+
+// ConstraintRejections returns the number of executions blocked by each constraint
+// since the program started, with the constraints that blocked the most first.
+// It can be used for finding the constraints that should be tuned.
+func ConstraintRejections() []ConstraintRejection {
+	constraintRejections.Lock()
+	defer constraintRejections.Unlock()
+
+	rejections := make([]ConstraintRejection, 0, len(constraintRejections.counts))
+	for key, count := range constraintRejections.counts {
+		rejections = append(rejections, ConstraintRejection{Tool: key.tool, Constraint: key.constraint, Count: count})
+	}
+
+	sort.Slice(rejections, func(i, j int) bool {
+		if rejections[i].Count != rejections[j].Count {
+			return rejections[i].Count > rejections[j].Count
+		}
+		if rejections[i].Tool != rejections[j].Tool {
+			return rejections[i].Tool < rejections[j].Tool
+		}
+		return rejections[i].Constraint < rejections[j].Constraint
+	})
+	return rejections
+}
@@ -0,0 +1,100 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+)
+
+var (
+	// ErrConstraintBlocked is returned when the execution of a tool is blocked
+	// because some of its constraints are not satisfied
+	ErrConstraintBlocked = errors.New("command execution blocked by constraints")
+
+	// ErrTimeout is returned when a tool does not finish within its timeout
+	ErrTimeout = errors.New("command execution timed out")
+
+	// ErrRequirementNotMet is returned when a tool cannot be run because something it
+	// depends on (like an environment variable or the runner executable) is not available
+	ErrRequirementNotMet = errors.New("requirement not met")
+)
+
+// ErrExit is returned when the command of a tool exits with a non-zero exit code.
+// Use errors.As for getting the exit code:
+//
+//	var exitErr *command.ErrExit
+//	if errors.As(err, &exitErr) {
+//		fmt.Println(exitErr.Code)
+//	}
+type ErrExit struct {
+	// Code is the exit code of the command
+	Code int
+
+	// Err is the original error (its message usually contains the stderr of the command)
+	Err error
+}
+
+// Error returns the message of the original error
+func (e *ErrExit) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the original error
+func (e *ErrExit) Unwrap() error {
+	return e.Err
+}
+
+// kindError is an error that keeps its own message but matches a sentinel error
+// (like ErrTimeout) with errors.Is, as well as the error that caused it
+type kindError struct {
+	kind  error
+	msg   string
+	cause error
+}
+
+func (e *kindError) Error() string {
+	return e.msg
+}
+
+func (e *kindError) Unwrap() []error {
+	if e.cause == nil {
+		return []error{e.kind}
+	}
+	return []error{e.kind, e.cause}
+}
+
+// newKindError creates an error of the given kind with a formatted message
+func newKindError(kind error, format string, args ...interface{}) error {
+	return &kindError{kind: kind, msg: fmt.Sprintf(format, args...)}
+}
+
+// wrapKindError marks an error as being of the given kind, keeping its message
+func wrapKindError(kind error, err error) error {
+	return &kindError{kind: kind, msg: err.Error(), cause: err}
+}
+
+// stderrError is the error returned by runners when a command fails writing to stderr:
+// the message is the stderr output, but the original error (usually an *exec.ExitError)
+// can still be reached with errors.As
+type stderrError struct {
+	stderr string
+	err    error
+}
+
+func (e *stderrError) Error() string {
+	return e.stderr
+}
+
+func (e *stderrError) Unwrap() error {
+	return e.err
+}
+
+// exitCode returns the exit code of the process that failed with the given error,
+// or -1 when the error does not come from an exited process
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
@@ -0,0 +1,102 @@
+package command
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/inercia/MCPShell/pkg/common"
+	"github.com/inercia/MCPShell/pkg/config"
+)
+
+// TestExecuteToolCommandTypedErrors tests that the failures of tools can be detected
+// with errors.Is and errors.As, while keeping their original messages
+func TestExecuteToolCommandTypedErrors(t *testing.T) {
+	newHandler := func(t *testing.T, toolConfig config.MCPToolConfig) *CommandHandler {
+		t.Helper()
+		handler, err := NewCommandHandler(config.Tool{MCPTool: mcp.Tool{Name: "test-tool"}, Config: toolConfig}, toolConfig.Params, "", testLogger)
+		if err != nil {
+			t.Fatalf("Failed to create handler: %v", err)
+		}
+		return handler
+	}
+
+	t.Run("Constraint blocked", func(t *testing.T) {
+		handler := newHandler(t, config.MCPToolConfig{
+			Params:      map[string]common.ParamConfig{"name": {Type: "string"}},
+			Constraints: []string{"name.size() < 5"},
+			Run:         config.MCPToolRunConfig{Command: "echo {{ .name }}"},
+		})
+
+		_, err := handler.ExecuteCommand(map[string]interface{}{"name": "too long"})
+		if !errors.Is(err, ErrConstraintBlocked) {
+			t.Fatalf("ExecuteCommand() error = %v, want ErrConstraintBlocked", err)
+		}
+		if !strings.HasPrefix(err.Error(), "command execution blocked by constraints:\n- Constraint 1:") {
+			t.Errorf("Unexpected error message: %q", err.Error())
+		}
+	})
+
+	t.Run("Exit code", func(t *testing.T) {
+		handler := newHandler(t, config.MCPToolConfig{
+			Run: config.MCPToolRunConfig{Command: "echo 'something failed' >&2; exit 3"},
+		})
+
+		_, err := handler.ExecuteCommand(map[string]interface{}{})
+		var exitErr *ErrExit
+		if !errors.As(err, &exitErr) {
+			t.Fatalf("ExecuteCommand() error = %v, want ErrExit", err)
+		}
+		if exitErr.Code != 3 {
+			t.Errorf("Expected exit code 3, got %d", exitErr.Code)
+		}
+		if err.Error() != "something failed" {
+			t.Errorf("Expected the stderr as the error message, got %q", err.Error())
+		}
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		handler := newHandler(t, config.MCPToolConfig{
+			Run: config.MCPToolRunConfig{Command: "sleep 10", Timeout: "1s"},
+		})
+
+		_, err := handler.ExecuteCommand(map[string]interface{}{})
+		if !errors.Is(err, ErrTimeout) {
+			t.Fatalf("ExecuteCommand() error = %v, want ErrTimeout", err)
+		}
+	})
+
+	t.Run("Missing environment variable", func(t *testing.T) {
+		const requiredVar = "MCPSHELL_TEST_REQUIRED_TOKEN"
+		t.Setenv(requiredVar, "")
+
+		handler := newHandler(t, config.MCPToolConfig{
+			Run: config.MCPToolRunConfig{Command: "echo ok", RequireEnv: []string{requiredVar}},
+		})
+
+		_, err := handler.ExecuteCommand(map[string]interface{}{})
+		if !errors.Is(err, ErrRequirementNotMet) {
+			t.Fatalf("ExecuteCommand() error = %v, want ErrRequirementNotMet", err)
+		}
+		if err.Error() != "tool 'test-tool' requires environment variable "+requiredVar {
+			t.Errorf("Unexpected error message: %q", err.Error())
+		}
+	})
+}
+
+// TestNewRunnerRequirementNotMet tests that runners that cannot be used in this
+// system return ErrRequirementNotMet
+func TestNewRunnerRequirementNotMet(t *testing.T) {
+	runnerType := RunnerTypeSandboxExec
+	if runtime.GOOS == "darwin" {
+		runnerType = RunnerTypeFirejail
+	}
+
+	_, err := NewRunner(runnerType, RunnerOptions{}, testLogger)
+	if !errors.Is(err, ErrRequirementNotMet) {
+		t.Errorf("NewRunner(%s) error = %v, want ErrRequirementNotMet", runnerType, err)
+	}
+}
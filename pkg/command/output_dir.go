@@ -0,0 +1,69 @@
+package command
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/inercia/MCPShell/pkg/config"
+)
+
+// outputDirParam is the template variable with the output directory of the tool
+const outputDirParam = "output_dir"
+
+// createOutputDir creates the output directory for a call of the tool. The returned
+// function applies the cleanup policy of the tool, and must always be called.
+func (h *CommandHandler) createOutputDir() (string, func(), error) {
+	dir, err := os.MkdirTemp(h.outputDir.Parent, "mcpshell-output-*")
+	if err != nil {
+		return "", func() {}, fmt.Errorf("failed to create output directory: %w", err)
+	}
+	h.logger.Debug("Created output directory: %s", dir)
+
+	cleanup := func() {
+		if h.outputDir.Cleanup == config.OutputDirKeep {
+			h.logger.Debug("Keeping output directory: %s", dir)
+			return
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			h.logger.Debug("Failed to remove output directory %s: %v", dir, err)
+		}
+	}
+
+	return dir, cleanup, nil
+}
+
+// listOutputFiles returns the list of files created in the output directory,
+// with their paths relative to it and their sizes
+func listOutputFiles(dir string) (string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, fmt.Sprintf("- %s (%d bytes)", filepath.ToSlash(relPath), info.Size()))
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if len(files) == 0 {
+		return fmt.Sprintf("No files created in %s", dir), nil
+	}
+	return fmt.Sprintf("Files created in %s:\n%s", dir, strings.Join(files, "\n")), nil
+}
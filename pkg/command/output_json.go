@@ -0,0 +1,113 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// jsonPathSegment is a step of a JSON path: a key of an object or an index of an array
+type jsonPathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// parseJSONPath parses a (simple) JSON path like "$.items[0].metadata.name" or
+// "items[0]['some key']", where the leading "$" is optional
+func parseJSONPath(path string) ([]jsonPathSegment, error) {
+	rest := strings.TrimPrefix(strings.TrimSpace(path), "$")
+
+	var segments []jsonPathSegment
+	for rest != "" {
+		switch {
+		case rest[0] == '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			key := rest[1 : end+1]
+			if key == "" {
+				return nil, fmt.Errorf("invalid jsonpath '%s': empty key", path)
+			}
+			segments = append(segments, jsonPathSegment{key: key})
+			rest = rest[end+1:]
+
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid jsonpath '%s': missing ']'", path)
+			}
+			inner := rest[1:end]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				segments = append(segments, jsonPathSegment{key: inner[1 : len(inner)-1]})
+			} else {
+				index, err := strconv.Atoi(inner)
+				if err != nil || index < 0 {
+					return nil, fmt.Errorf("invalid jsonpath '%s': invalid index '%s'", path, inner)
+				}
+				segments = append(segments, jsonPathSegment{index: index, isIndex: true})
+			}
+			rest = rest[end+1:]
+
+		case len(segments) == 0:
+			// a path starting with a key, like "items[0]"
+			rest = "." + rest
+
+		default:
+			return nil, fmt.Errorf("invalid jsonpath '%s': unexpected '%s'", path, rest)
+		}
+	}
+
+	return segments, nil
+}
+
+// formatJSONOutput parses the output as JSON, returning it pretty-printed. When a path
+// is given, only the value at the path is returned (strings are returned unquoted).
+func formatJSONOutput(output string, path []jsonPathSegment, rawPath string) (string, error) {
+	// keep the numbers as they are (big integers would lose precision as float64)
+	decoder := json.NewDecoder(strings.NewReader(output))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return "", fmt.Errorf("the output of the command is not valid JSON: %w", err)
+	}
+	if decoder.More() {
+		return "", fmt.Errorf("the output of the command is not valid JSON: unexpected data after the JSON value")
+	}
+
+	for _, segment := range path {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			child, ok := v[segment.key]
+			if segment.isIndex || !ok {
+				return "", fmt.Errorf("jsonpath '%s' not found in the output", rawPath)
+			}
+			value = child
+		case []interface{}:
+			if !segment.isIndex || segment.index >= len(v) {
+				return "", fmt.Errorf("jsonpath '%s' not found in the output", rawPath)
+			}
+			value = v[segment.index]
+		default:
+			return "", fmt.Errorf("jsonpath '%s' not found in the output", rawPath)
+		}
+	}
+
+	if s, ok := value.(string); ok && len(path) > 0 {
+		return s, nil
+	}
+
+	// Characters like '<' or '&' are kept as they are (instead of the HTML-safe escapes),
+	// as the output is read by the LLM
+	var formatted strings.Builder
+	encoder := json.NewEncoder(&formatted)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		return "", fmt.Errorf("failed to format the JSON output: %w", err)
+	}
+	return strings.TrimSuffix(formatted.String(), "\n"), nil
+}
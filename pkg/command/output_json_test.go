@@ -0,0 +1,62 @@
+package command
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatJSONOutput(t *testing.T) {
+	output := `{"items": [{"name": "web", "replicas": 3, "labels": {"app.kubernetes.io/name": "web"}}], "total": 12345678901234567890}`
+
+	tests := []struct {
+		name    string
+		output  string
+		path    string
+		want    string
+		wantErr string
+	}{
+		{name: "pretty-print", output: `{"b":[1,2],"a":"x"}`, want: "{\n  \"a\": \"x\",\n  \"b\": [\n    1,\n    2\n  ]\n}"},
+		{name: "big numbers are kept", output: output, path: "$.total", want: "12345678901234567890"},
+		{name: "nested string", output: output, path: "$.items[0].name", want: "web"},
+		{name: "path without $", output: output, path: "items[0].replicas", want: "3"},
+		{name: "quoted key", output: output, path: "$.items[0].labels['app.kubernetes.io/name']", want: "web"},
+		{name: "subtree", output: output, path: "$.items[0].labels", want: "{\n  \"app.kubernetes.io/name\": \"web\"\n}"},
+		{name: "HTML characters are not escaped", output: `{"cmds": ["a && b <x>"]}`, path: "$.cmds", want: "[\n  \"a && b <x>\"\n]"},
+		{name: "HTML characters in objects", output: `{"cmd": "a && b <x>"}`, want: "{\n  \"cmd\": \"a && b <x>\"\n}"},
+		{name: "invalid JSON", output: "not json", wantErr: "not valid JSON"},
+		{name: "trailing data", output: `{"a": 1} {"b": 2}`, wantErr: "not valid JSON"},
+		{name: "missing key", output: output, path: "$.items[0].image", wantErr: "jsonpath '$.items[0].image' not found"},
+		{name: "index out of range", output: output, path: "$.items[3]", wantErr: "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := parseJSONPath(tt.path)
+			if err != nil {
+				t.Fatalf("parseJSONPath(%q) error = %v", tt.path, err)
+			}
+
+			got, err := formatJSONOutput(tt.output, path, tt.path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("formatJSONOutput() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("formatJSONOutput() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseJSONPath_Invalid(t *testing.T) {
+	for _, path := range []string{"$.items[", "$.items[-1]", "$.items[x]", "$..items", "$.items[0]name"} {
+		if _, err := parseJSONPath(path); err == nil {
+			t.Errorf("Expected an error for the jsonpath %q", path)
+		}
+	}
+}
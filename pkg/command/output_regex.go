@@ -0,0 +1,15 @@
+package command
+
+import (
+	"regexp"
+	"strings"
+)
+
+// applyOutputRegex returns the matches of the regex in the output, one per line, or
+// the output with the matches replaced with the replacement template when there is one
+func applyOutputRegex(output string, re *regexp.Regexp, replace *string) string {
+	if replace != nil {
+		return re.ReplaceAllString(output, *replace)
+	}
+	return strings.Join(re.FindAllString(output, -1), "\n")
+}
@@ -0,0 +1,63 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/inercia/MCPShell/pkg/common"
+)
+
+// paramTransforms are the operations that can be applied to the values of string parameters
+var paramTransforms = map[string]func(value string, param common.ParamConfig) interface{}{
+	"trim":  func(value string, _ common.ParamConfig) interface{} { return strings.TrimSpace(value) },
+	"lower": func(value string, _ common.ParamConfig) interface{} { return strings.ToLower(value) },
+	"upper": func(value string, _ common.ParamConfig) interface{} { return strings.ToUpper(value) },
+	"default": func(value string, param common.ParamConfig) interface{} {
+		if value == "" && param.Default != nil {
+			return param.Default
+		}
+		return value
+	},
+}
+
+// checkParamTransforms returns an error if some parameter uses an unknown transform
+func checkParamTransforms(params map[string]common.ParamConfig) error {
+	for name, param := range params {
+		for _, op := range param.Transform {
+			if _, ok := paramTransforms[op]; !ok {
+				known := make([]string, 0, len(paramTransforms))
+				for k := range paramTransforms {
+					known = append(known, k)
+				}
+				sort.Strings(known)
+				return fmt.Errorf("unknown transform '%s' for parameter '%s' (must be one of %s)", op, name, strings.Join(known, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// transformParams applies the transforms and the value maps of the parameters to the
+// values of the arguments
+func (h *CommandHandler) transformParams(args map[string]interface{}) {
+	for name, param := range h.params {
+		value, exists := args[name]
+		if !exists {
+			continue
+		}
+		for _, op := range param.Transform {
+			s, ok := value.(string)
+			if !ok {
+				break
+			}
+			value = paramTransforms[op](s, param)
+		}
+		if s, ok := value.(string); ok {
+			if mapped, found := param.ValueMap[s]; found {
+				value = mapped
+			}
+		}
+		args[name] = value
+	}
+}
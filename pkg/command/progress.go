@@ -0,0 +1,71 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// defaultProgressInterval is the interval between progress notifications
+// for long-running tools that do not specify one
+const defaultProgressInterval = 10 * time.Second
+
+// progressNotificationMethod is the MCP method used for progress notifications
+const progressNotificationMethod = "notifications/progress"
+
+// startProgress starts sending periodic "still running" progress notifications for
+// the given tool call, until the returned function is called.
+// Notifications are only sent for long-running tools, and only when the client asked
+// for progress (by including a progress token in the request).
+func (h *CommandHandler) startProgress(ctx context.Context, request mcp.CallToolRequest) func() {
+	if h.progressInterval <= 0 || request.Params.Meta == nil || request.Params.Meta.ProgressToken == nil {
+		return func() {}
+	}
+
+	mcpServer := server.ServerFromContext(ctx)
+	if mcpServer == nil {
+		return func() {}
+	}
+
+	token := request.Params.Meta.ProgressToken
+	h.logger.Debug("Sending progress notifications for tool '%s' every %s", h.toolName, h.progressInterval)
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(h.progressInterval)
+		defer ticker.Stop()
+
+		start := time.Now()
+		for progress := 1; ; progress++ {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				message := fmt.Sprintf("'%s' still running (%s elapsed)", h.toolName, time.Since(start).Round(time.Second))
+				err := mcpServer.SendNotificationToClient(ctx, progressNotificationMethod, map[string]any{
+					"progressToken": token,
+					"progress":      progress,
+					"message":       message,
+				})
+				if err != nil {
+					h.logger.Debug("Could not send progress notification for tool '%s': %v", h.toolName, err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
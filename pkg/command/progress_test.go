@@ -0,0 +1,111 @@
+package command
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/inercia/MCPShell/pkg/config"
+)
+
+// testSession is a client session that collects the notifications sent to the client
+type testSession struct {
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (s *testSession) Initialize()       {}
+func (s *testSession) Initialized() bool { return true }
+func (s *testSession) SessionID() string { return "test-session" }
+func (s *testSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+
+// TestCommandHandlerProgress tests that long-running tools send progress notifications
+// to the clients that ask for them
+func TestCommandHandlerProgress(t *testing.T) {
+	callTool := func(t *testing.T, toolConfig config.MCPToolConfig, withToken bool) []mcp.JSONRPCNotification {
+		t.Helper()
+
+		tool := config.Tool{MCPTool: mcp.NewTool("slow-tool"), Config: toolConfig}
+		handler, err := NewCommandHandler(tool, nil, "", testLogger)
+		if err != nil {
+			t.Fatalf("Failed to create handler: %v", err)
+		}
+
+		mcpServer := server.NewMCPServer("test", "1.0.0")
+		mcpServer.AddTool(tool.MCPTool, handler.GetMCPHandler())
+
+		session := &testSession{notifications: make(chan mcp.JSONRPCNotification, 100)}
+		ctx := mcpServer.WithContext(context.Background(), session)
+
+		meta := ""
+		if withToken {
+			meta = `, "_meta": {"progressToken": "token-1"}`
+		}
+		request := `{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": {"name": "slow-tool", "arguments": {}` + meta + `}}`
+		response := mcpServer.HandleMessage(ctx, []byte(request))
+		if _, ok := response.(mcp.JSONRPCResponse); !ok {
+			t.Fatalf("Unexpected response: %#v", response)
+		}
+
+		close(session.notifications)
+		var notifications []mcp.JSONRPCNotification
+		for notification := range session.notifications {
+			notifications = append(notifications, notification)
+		}
+		return notifications
+	}
+
+	slowTool := config.MCPToolConfig{
+		LongRunning: true,
+		Run:         config.MCPToolRunConfig{Command: "sleep 1", ProgressInterval: "200ms"},
+	}
+
+	t.Run("Long-running tool", func(t *testing.T) {
+		notifications := callTool(t, slowTool, true)
+		if len(notifications) < 2 {
+			t.Fatalf("Expected several progress notifications, got %d", len(notifications))
+		}
+		for i, notification := range notifications {
+			if notification.Method != progressNotificationMethod {
+				t.Errorf("Expected a progress notification, got %q", notification.Method)
+			}
+			fields := notification.Params.AdditionalFields
+			if fields["progressToken"] != "token-1" {
+				t.Errorf("Expected progress token 'token-1', got %v", fields["progressToken"])
+			}
+			if fields["progress"] != i+1 {
+				t.Errorf("Expected progress %d, got %v", i+1, fields["progress"])
+			}
+			if message, _ := fields["message"].(string); !strings.Contains(message, "still running") {
+				t.Errorf("Unexpected progress message %q", message)
+			}
+		}
+	})
+
+	t.Run("No progress token", func(t *testing.T) {
+		if notifications := callTool(t, slowTool, false); len(notifications) != 0 {
+			t.Errorf("Expected no notifications without a progress token, got %d", len(notifications))
+		}
+	})
+
+	t.Run("Not long-running", func(t *testing.T) {
+		toolConfig := slowTool
+		toolConfig.LongRunning = false
+		if notifications := callTool(t, toolConfig, true); len(notifications) != 0 {
+			t.Errorf("Expected no notifications for a tool that is not long-running, got %d", len(notifications))
+		}
+	})
+
+	t.Run("Invalid interval", func(t *testing.T) {
+		toolConfig := slowTool
+		toolConfig.Run.ProgressInterval = "often"
+		tool := config.Tool{MCPTool: mcp.NewTool("slow-tool"), Config: toolConfig}
+		if _, err := NewCommandHandler(tool, nil, "", testLogger); err == nil {
+			t.Error("NewCommandHandler() did not return an error for an invalid progress interval")
+		}
+	})
+}
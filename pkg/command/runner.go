@@ -4,6 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
 
 	"github.com/inercia/MCPShell/pkg/common"
 )
@@ -26,11 +30,105 @@ const (
 	// Implicit requirements: OS=linux, executables=[firejail]
 	RunnerTypeFirejail RunnerType = "firejail"
 
+	// RunnerTypeNsjail is the Linux-specific nsjail runner
+	// Implicit requirements: OS=linux, executables=[nsjail]
+	RunnerTypeNsjail RunnerType = "nsjail"
+
+	// RunnerTypeBubblewrap is the Linux-specific bubblewrap runner
+	// Implicit requirements: OS=linux, executables=[bwrap]
+	RunnerTypeBubblewrap RunnerType = "bubblewrap"
+
 	// RunnerTypeDocker is the Docker-based runner
 	// Implicit requirements: executables=[docker]
 	RunnerTypeDocker RunnerType = "docker"
+
+	// RunnerTypeSSH is the runner for running commands in a remote host with ssh
+	// Implicit requirements: executables=[ssh]
+	RunnerTypeSSH RunnerType = "ssh"
 )
 
+// runsInHost returns whether the commands of a runner run in this host (maybe in a sandbox),
+// so they can use the executables checked here (like the timeout command). The empty
+// type is the default runner.
+func runsInHost(runnerType RunnerType) bool {
+	return !registeredRunners[runnerType].remote
+}
+
+// scriptCommandArgs returns the command line for running a temporary script. When withShell
+// is false, the script is executed directly (so it must be executable). Otherwise, it is passed
+// as an argument to the shell, so it works on filesystems mounted with noexec (like some /tmp).
+// The shell is the one given, or the default shell if empty.
+func scriptCommandArgs(scriptPath string, shell string, withShell bool) []string {
+	if !withShell {
+		return []string{scriptPath}
+	}
+	return []string{getShell(shell), scriptPath}
+}
+
+// sandboxCommandArgs returns the command line (to be run by a sandboxing tool like firejail)
+// for a command, handling it like RunnerExec does: single executables are run directly,
+// other commands are written to a temporary script in tempDir when tmpfile is true (see
+// scriptCommandArgs), and passed to the shell with -c otherwise.
+// The returned function removes the temporary script, if any, and must always be called.
+func sandboxCommandArgs(command string, shell string, tmpfile bool, tempDir string, scriptPattern string, withShell bool, logger *common.Logger) ([]string, func(), error) {
+	noCleanup := func() {}
+
+	// Check if we can optimize by running a single executable directly
+	if isSingleExecutableCommand(command) {
+		logger.Debug("Optimization: running single executable command directly: %s", command)
+		return []string{command}, noCleanup, nil
+	}
+
+	if !tmpfile {
+		shellPath, args := getShellCommandArgs(getShell(shell), command)
+		return append([]string{shellPath}, args...), noCleanup, nil
+	}
+
+	// Create a temporary file for the command
+	tmpScript, err := os.CreateTemp(tempDir, scriptPattern)
+	if err != nil {
+		logger.Debug("Failed to create temporary command file: %v", err)
+		return nil, noCleanup, fmt.Errorf("failed to create temporary command file: %w", err)
+	}
+	logger.Debug("Created temporary script file at: %s", tmpScript.Name())
+
+	// Ensure temporary file is deleted when the command is done
+	cleanup := func() {
+		tmpScriptPath := tmpScript.Name()
+		if err := tmpScript.Close(); err != nil {
+			logger.Debug("Warning: failed to close script file: %v", err)
+		}
+		if err := os.Remove(tmpScriptPath); err != nil {
+			logger.Debug("Warning: failed to remove temporary script file: %v", err)
+		}
+	}
+
+	// Write the command to the temporary file
+	if _, err := tmpScript.WriteString(command); err != nil {
+		cleanup()
+		logger.Debug("Failed to write command to temporary file: %v", err)
+		return nil, noCleanup, fmt.Errorf("failed to write command to temporary file: %w", err)
+	}
+
+	// Flush data to ensure it's written to disk
+	if err := tmpScript.Sync(); err != nil {
+		cleanup()
+		logger.Debug("Failed to sync script file: %v", err)
+		return nil, noCleanup, fmt.Errorf("failed to sync script file: %w", err)
+	}
+
+	// Make the temporary file executable (not needed when it is run with the shell)
+	if !withShell {
+		if err := os.Chmod(tmpScript.Name(), 0o700); err != nil {
+			cleanup()
+			logger.Debug("Failed to make temporary file executable: %v", err)
+			return nil, noCleanup, fmt.Errorf("failed to make temporary file executable: %w", err)
+		}
+	}
+
+	return scriptCommandArgs(tmpScript.Name(), shell, withShell), cleanup, nil
+}
+
 // RunnerOptions is a map of options for the runner
 type RunnerOptions map[string]interface{}
 
@@ -39,30 +137,151 @@ func (ro RunnerOptions) ToJSON() (string, error) {
 	return string(json), err
 }
 
+// knownOptionKeys returns the option keys accepted by a runner, taken from
+// the json tags of the runner options struct.
+func knownOptionKeys(optionsStruct interface{}) []string {
+	var keys []string
+	t := reflect.TypeOf(optionsStruct)
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}
+
+// CheckUnknownKeys checks that all the keys in the options are known by the
+// runner whose options struct is given.
+//
+// Returns:
+//   - nil if all the keys are known
+//   - an error listing the unknown keys (with suggestions for likely misspellings)
+func (ro RunnerOptions) CheckUnknownKeys(runnerType RunnerType, optionsStruct interface{}) error {
+	known := knownOptionKeys(optionsStruct)
+
+	var problems []string
+	for key := range ro {
+		if contains(known, key) {
+			continue
+		}
+		problem := fmt.Sprintf("'%s'", key)
+		if suggestion := closestKey(key, known); suggestion != "" {
+			problem += fmt.Sprintf(" (did you mean '%s'?)", suggestion)
+		}
+		problems = append(problems, problem)
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+
+	sort.Strings(problems)
+	return fmt.Errorf("unknown %s runner option(s): %s", runnerType, strings.Join(problems, ", "))
+}
+
+// warnUnknownOptionKeys logs a warning when the options contain keys unknown to the runner,
+// as they would be silently ignored otherwise.
+func warnUnknownOptionKeys(runnerType RunnerType, options RunnerOptions, optionsStruct interface{}, logger *common.Logger) {
+	if err := options.CheckUnknownKeys(runnerType, optionsStruct); err != nil {
+		logger.Warn("%v", err)
+	}
+}
+
+// closestKey returns the known key closest to the given key, or an empty string
+// if none of them is close enough to be a likely misspelling.
+func closestKey(key string, known []string) string {
+	best, bestDistance := "", 4 // only suggest keys within 3 edits
+	for _, k := range known {
+		if d := editDistance(key, k); d < bestDistance {
+			best, bestDistance = k, d
+		}
+	}
+	return best
+}
+
+// editDistance computes the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// RunResult is the result of running a command with a Runner
+type RunResult struct {
+	// Stdout is the standard output of the command, without surrounding whitespace
+	Stdout string
+
+	// Stderr is the standard error of the command, without surrounding whitespace
+	Stderr string
+
+	// ExitCode is the exit code of the command, or -1 if it did not exit normally
+	ExitCode int
+
+	// RawStdout is the standard output of the command, as it was written
+	RawStdout string
+
+	// RawStderr is the standard error of the command, as it was written
+	RawStderr string
+}
+
+// newRunResult creates the result of a command that was run with the given stdout and stderr,
+// and failed with runErr (if not nil). The error returned is the one runners should return:
+// for failed commands, its message is the stderr output (when there is some).
+func newRunResult(stdout, stderr string, runErr error, logger *common.Logger) (*RunResult, error) {
+	result := &RunResult{
+		Stdout:   strings.TrimSpace(stdout),
+		Stderr:   strings.TrimSpace(stderr),
+		ExitCode: 0,
+
+		RawStdout: stdout,
+		RawStderr: stderr,
+	}
+	if runErr == nil {
+		return result, nil
+	}
+
+	result.ExitCode = exitCode(runErr)
+
+	// If there's error output, include it in the error
+	if result.Stderr != "" {
+		logger.Debug("Command failed with stderr: %s", result.Stderr)
+		return result, &stderrError{stderr: result.Stderr, err: runErr}
+	}
+	logger.Debug("Command failed with error: %v", runErr)
+	return result, runErr
+}
+
 // Runner is an interface for running commands
 type Runner interface {
-	Run(ctx context.Context, shell string, command string, env []string, params map[string]interface{}, tmpfile bool) (string, error)
+	// Run runs a command, returning its result. When the command fails (for example,
+	// exiting with a non-zero exit code) an error is returned too, but the result
+	// is still returned when the command could be run.
+	Run(ctx context.Context, shell string, command string, env []string, params map[string]interface{}, tmpfile bool) (*RunResult, error)
 	CheckImplicitRequirements() error
 }
 
 // NewRunner creates a new Runner based on the given type
 func NewRunner(runnerType RunnerType, options RunnerOptions, logger *common.Logger) (Runner, error) {
-	var runner Runner
-	var err error
-
 	// Create the runner instance based on type
-	switch runnerType {
-	case RunnerTypeExec:
-		runner, err = NewRunnerExec(options, logger)
-	case RunnerTypeSandboxExec:
-		runner, err = NewRunnerSandboxExec(options, logger)
-	case RunnerTypeFirejail:
-		runner, err = NewRunnerFirejail(options, logger)
-	case RunnerTypeDocker:
-		runner, err = NewDockerRunner(options, logger)
-	default:
+	registration, ok := registeredRunners[runnerType]
+	if !ok {
 		return nil, fmt.Errorf("unknown runner type: %s", runnerType)
 	}
+	runner, err := registration.newRunner(options, logger)
 
 	// Check if runner creation failed
 	if err != nil {
@@ -74,8 +293,37 @@ func NewRunner(runnerType RunnerType, options RunnerOptions, logger *common.Logg
 		if logger != nil {
 			logger.Debug("Runner %s failed implicit requirements check: %v", runnerType, err)
 		}
-		return nil, err
+		return nil, wrapKindError(ErrRequirementNotMet, err)
 	}
 
 	return runner, nil
 }
+
+// ValidateRunnerOptions checks the options of a runner against the runner's schema,
+// without creating the runner or checking its requirements. It is meant for
+// catching configuration mistakes (like a Docker runner without an 'image')
+// before the tool is executed.
+//
+// Parameters:
+//   - runnerType: The type of the runner
+//   - options: The options given to the runner
+//   - rejectUnknown: Whether unknown option keys are reported as errors
+//
+// Returns:
+//   - nil if the options are valid
+//   - an error describing the problem otherwise
+func ValidateRunnerOptions(runnerType RunnerType, options RunnerOptions, rejectUnknown bool) error {
+	registration, ok := registeredRunners[runnerType]
+	if !ok {
+		return fmt.Errorf("unknown runner type: %s", runnerType)
+	}
+	optionsStruct, err := registration.parseOptions(options)
+	if err != nil {
+		return fmt.Errorf("invalid %s runner options: %w", runnerType, err)
+	}
+
+	if rejectUnknown {
+		return options.CheckUnknownKeys(runnerType, optionsStruct)
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/inercia/MCPShell/pkg/common"
+)
+
+// RunnerBubblewrap implements the Runner interface using bubblewrap (bwrap) on Linux
+type RunnerBubblewrap struct {
+	logger  *common.Logger
+	options RunnerBubblewrapOptions
+}
+
+// RunnerBubblewrapOptions is the options for the RunnerBubblewrap
+type RunnerBubblewrapOptions struct {
+	Shell             string   `json:"shell"`
+	AllowNetworking   bool     `json:"allow_networking"`
+	AllowReadFolders  []string `json:"allow_read_folders"`
+	AllowWriteFolders []string `json:"allow_write_folders"`
+	AllowReadFiles    []string `json:"allow_read_files"`
+	AllowWriteFiles   []string `json:"allow_write_files"`
+	Tmpfs             []string `json:"tmpfs"` // folders mounted as empty, writable, temporary filesystems
+	ScriptWithShell   bool     `json:"script_with_shell"`
+	TempDir           string   `json:"temp_dir"`
+}
+
+// bwrapSystemFolders are the system folders mounted (read-only) in the sandbox, when they exist
+var bwrapSystemFolders = []string{"/usr", "/bin", "/sbin", "/lib", "/lib64", "/etc"}
+
+// bwrapCheckTimeout is the maximum time for checking bwrap can create a sandbox
+const bwrapCheckTimeout = 5 * time.Second
+
+// bwrapUsable is the result of checking bwrap can create a sandbox in this system, checked
+// only once as it depends on the system configuration (like unprivileged user namespaces)
+var bwrapUsable struct {
+	once sync.Once
+	err  error
+}
+
+// NewRunnerBubblewrapOptions creates a new RunnerBubblewrapOptions from a RunnerOptions
+func NewRunnerBubblewrapOptions(options RunnerOptions) (RunnerBubblewrapOptions, error) {
+	var reopts RunnerBubblewrapOptions
+	opts, err := options.ToJSON()
+	if err != nil {
+		return RunnerBubblewrapOptions{}, err
+	}
+	err = json.Unmarshal([]byte(opts), &reopts)
+	return reopts, err
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// NewRunnerBubblewrap creates a new RunnerBubblewrap with the provided logger
+// If logger is nil, a default logger is created
+func NewRunnerBubblewrap(options RunnerOptions, logger *common.Logger) (*RunnerBubblewrap, error) {
+	if logger == nil {
+		logger = common.GetLogger()
+	}
+
+	// Parse bubblewrap-specific options
+	bwrapOpts, err := NewRunnerBubblewrapOptions(options)
+	if err != nil {
+		logger.Debug("Failed to parse bubblewrap options: %v", err)
+		return nil, fmt.Errorf("failed to parse bubblewrap options: %w", err)
+	}
+	warnUnknownOptionKeys(RunnerTypeBubblewrap, options, RunnerBubblewrapOptions{}, logger)
+
+	return &RunnerBubblewrap{
+		logger:  logger,
+		options: bwrapOpts,
+	}, nil
+}
+
+// Run executes a command inside the bubblewrap sandbox and returns the output
+// It implements the Runner interface
+//
+// When tmpfile is true, the command is written to a temporary script (in the temp_dir
+// option, or the default temporary directory) that is mounted in the sandbox; otherwise
+// it is passed to the shell with -c.
+func (r *RunnerBubblewrap) Run(ctx context.Context,
+	shell string, command string,
+	env []string, params map[string]interface{}, tmpfile bool,
+) (*RunResult, error) {
+	// Check if context is done
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		// Continue execution
+	}
+
+	scriptShell := r.options.Shell
+	if scriptShell == "" {
+		scriptShell = shell
+	}
+	cmdArgs, cleanup, err := sandboxCommandArgs(command, scriptShell, tmpfile, r.options.TempDir, "bwrap-command-*.sh", r.options.ScriptWithShell, r.logger)
+	defer cleanup()
+	if err != nil {
+		return nil, err
+	}
+
+	// The script (if any) is the last argument of the command line
+	script := ""
+	if tmpfile && len(cmdArgs) > 0 && cmdArgs[len(cmdArgs)-1] != command {
+		script = cmdArgs[len(cmdArgs)-1]
+	}
+
+	args := append(r.bwrapArgs(params, script), "--")
+	execCmd := exec.CommandContext(ctx, "bwrap", append(args, cmdArgs...)...)
+	r.logger.Debug("Created command: %s", execCmd.String())
+
+	// Set environment variables if provided (the sandbox keeps the environment)
+	if len(env) > 0 {
+		r.logger.Debug("Adding %d environment variables to command", len(env))
+		for _, e := range env {
+			r.logger.Debug("... adding environment variable: %s", e)
+		}
+		execCmd.Env = append(os.Environ(), env...)
+	}
+
+	// Capture output
+	var stdout, stderr bytes.Buffer
+	execCmd.Stdout = &stdout
+	execCmd.Stderr = &stderr
+
+	// Run the command
+	r.logger.Debug("Executing command")
+
+	runErr := execCmd.Run()
+	result, err := newRunResult(stdout.String(), stderr.String(), runErr, r.logger)
+	if err != nil {
+		return result, err
+	}
+
+	r.logger.Debug("Command executed successfully, output length: %d bytes", len(result.Stdout))
+	if result.Stderr != "" {
+		r.logger.Debug("Command generated stderr (but no error): %s", result.Stderr)
+	}
+
+	return result, nil
+}
+
+// bwrapArgs returns the arguments of bwrap for creating the sandbox, with the templates
+// in the allowed folders and files replaced with the parameters.
+//
+// Only the system folders are visible (read-only), with a new /dev, /proc and an
+// empty /tmp, and then the script with the command and the allowed folders and files.
+// As bwrap applies the mounts in order, the ones in the options are mounted over
+// (and so they can be inside) the default ones.
+func (r *RunnerBubblewrap) bwrapArgs(params map[string]interface{}, script string) []string {
+	args := []string{"--die-with-parent", "--unshare-pid", "--unshare-ipc", "--unshare-uts"}
+	if !r.options.AllowNetworking {
+		args = append(args, "--unshare-net")
+	}
+
+	for _, folder := range bwrapSystemFolders {
+		args = append(args, "--ro-bind-try", folder, folder)
+	}
+	args = append(args, "--dev", "/dev", "--proc", "/proc", "--tmpfs", "/tmp")
+
+	if script != "" {
+		args = append(args, "--ro-bind", script, script)
+	}
+
+	for _, folder := range common.ProcessTemplateListFlexible(r.options.Tmpfs, params) {
+		args = append(args, "--tmpfs", folder)
+	}
+	for _, path := range common.ProcessTemplateListFlexible(slices.Concat(r.options.AllowReadFolders, r.options.AllowReadFiles), params) {
+		args = append(args, "--ro-bind", path, path)
+	}
+	for _, path := range common.ProcessTemplateListFlexible(slices.Concat(r.options.AllowWriteFolders, r.options.AllowWriteFiles), params) {
+		args = append(args, "--bind", path, path)
+	}
+
+	return append(args, "--chdir", "/tmp")
+}
+
+// checkBwrapUsable checks bwrap can create a sandbox in this system: it can be installed but
+// not usable, for example when unprivileged user namespaces are disabled
+func checkBwrapUsable() error {
+	bwrapUsable.once.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), bwrapCheckTimeout)
+		defer cancel()
+
+		output, err := exec.CommandContext(ctx, "bwrap", "--ro-bind", "/", "/", "--dev", "/dev", "true").CombinedOutput()
+		if err != nil {
+			if msg := strings.TrimSpace(string(output)); msg != "" {
+				err = fmt.Errorf("%s", msg)
+			}
+			bwrapUsable.err = fmt.Errorf("bwrap cannot create a sandbox: %w", err)
+		}
+	})
+	return bwrapUsable.err
+}
+
+// CheckImplicitRequirements checks if the runner meets its implicit requirements
+// Bubblewrap runner requires Linux and a bwrap executable that can create sandboxes
+func (r *RunnerBubblewrap) CheckImplicitRequirements() error {
+	// Bubblewrap is Linux only
+	if runtime.GOOS != "linux" {
+		return fmt.Errorf("bubblewrap runner requires Linux")
+	}
+
+	// Check if bwrap is available
+	if !common.CheckExecutableExists("bwrap") {
+		return fmt.Errorf("bwrap executable not found in PATH")
+	}
+
+	// ... and usable
+	return checkBwrapUsable()
+}
@@ -0,0 +1,80 @@
+package command
+
+import (
+	"context"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/inercia/MCPShell/pkg/common"
+)
+
+func TestRunnerBubblewrapArgs(t *testing.T) {
+	runner, err := NewRunnerBubblewrap(RunnerOptions{
+		"allow_read_folders":  []interface{}{"/data/{{ .project }}"},
+		"allow_write_folders": []interface{}{"/output"},
+		"allow_read_files":    []interface{}{"/etc/app.conf"},
+		"tmpfs":               []interface{}{"/var/cache"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create bubblewrap runner: %v", err)
+	}
+
+	args := strings.Join(runner.bwrapArgs(map[string]interface{}{"project": "demo"}, "/tmp/bwrap-command-1.sh"), " ")
+	for _, want := range []string{
+		"--unshare-net",
+		"--ro-bind-try /usr /usr",
+		"--tmpfs /tmp",
+		"--ro-bind /tmp/bwrap-command-1.sh /tmp/bwrap-command-1.sh",
+		"--tmpfs /var/cache",
+		"--ro-bind /data/demo /data/demo",
+		"--ro-bind /etc/app.conf /etc/app.conf",
+		"--bind /output /output",
+	} {
+		if !strings.Contains(args, want) {
+			t.Errorf("Expected the arguments to contain %q, got: %s", want, args)
+		}
+	}
+
+	// the script is mounted after the empty /tmp, so it is visible in the sandbox
+	if strings.Index(args, "--ro-bind /tmp/bwrap-command-1.sh") < strings.Index(args, "--tmpfs /tmp") {
+		t.Errorf("Expected the script to be mounted after /tmp, got: %s", args)
+	}
+
+	// the options are not modified by the templates of the parameters
+	if runner.options.AllowReadFolders[0] != "/data/{{ .project }}" {
+		t.Errorf("Expected the options to keep the templates, got %q", runner.options.AllowReadFolders)
+	}
+
+	// networking can be allowed
+	runner, err = NewRunnerBubblewrap(RunnerOptions{"allow_networking": true}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create bubblewrap runner: %v", err)
+	}
+	if slices.Contains(runner.bwrapArgs(nil, ""), "--unshare-net") {
+		t.Error("Expected the network to be shared when networking is allowed")
+	}
+}
+
+func TestRunnerBubblewrapRun(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Skipping bubblewrap tests on non-Linux platform")
+	}
+	if !common.CheckExecutableExists("bwrap") {
+		t.Skip("Skipping test because bwrap is not installed")
+	}
+
+	runner, err := NewRunner(RunnerTypeBubblewrap, RunnerOptions{}, nil)
+	if err != nil {
+		t.Skipf("Skipping test because bwrap is not usable: %v", err)
+	}
+
+	output, err := stdoutOf(runner.Run(context.Background(), "/bin/sh", "echo $GREETING | tr a-z A-Z", []string{"GREETING=hi"}, nil, true))
+	if err != nil {
+		t.Fatalf("Failed to run command: %v", err)
+	}
+	if output != "HI" {
+		t.Errorf("Expected 'HI', got '%s'", output)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/inercia/MCPShell/pkg/common"
@@ -71,6 +72,114 @@ type DockerRunnerOptions struct {
 
 	// Set platform if server is multi-platform capable (e.g., "linux/amd64", "linux/arm64")
 	Platform string `json:"platform"`
+
+	// Mount the root filesystem of the container as read-only
+	ReadOnly bool `json:"read_only"`
+
+	// Tmpfs mounts in the container, in the format "containerpath[:options]"
+	// (e.g. "/tmp" or "/run:rw,size=64m"), usually needed with a read-only root filesystem
+	Tmpfs []string `json:"tmpfs"`
+
+	// ContainerShell is the interpreter used for running the script inside the container
+	// (defaults to "sh"). Use it for images without /bin/sh (e.g. "/busybox/sh" in distroless images)
+	ContainerShell string `json:"container_shell"`
+
+	// DaemonCheckCache is how long the result of the Docker daemon check is reused
+	// (e.g. "30s"), so it is not repeated for every docker runner. Use "0" for
+	// checking the daemon every time
+	DaemonCheckCache time.Duration `json:"daemon_check_cache"`
+
+	// PullPolicy is when the image is pulled before running the command: "always",
+	// "missing" (only when it is not available locally) or "never". When empty, the
+	// image is not pulled explicitly (so docker run pulls it if needed)
+	PullPolicy string `json:"pull_policy"`
+
+	// Persistent reuses a long-lived container for running the commands (with docker exec),
+	// instead of creating a new container for every call. The prepare command is only run
+	// when the container is started. The containers are removed when the server shuts down
+	Persistent bool `json:"persistent"`
+}
+
+const (
+	// PullPolicyAlways pulls the image before every run
+	PullPolicyAlways = "always"
+
+	// PullPolicyMissing pulls the image only when it is not available locally
+	PullPolicyMissing = "missing"
+
+	// PullPolicyNever never pulls the image, that must be available locally
+	PullPolicyNever = "never"
+)
+
+// defaultContainerShell is the default interpreter for running scripts inside the container
+const defaultContainerShell = "sh"
+
+// defaultDaemonCheckCache is the default time the result of the Docker daemon check is reused
+const defaultDaemonCheckCache = 30 * time.Second
+
+// daemonCheck keeps the result of the last Docker daemon check, shared by all the docker runners.
+// It works as a light circuit breaker: when the daemon is not available, the failure is reused
+// until the cache window expires, so runners do not wait for the check timeout again and again,
+// and the check is only retried afterwards.
+type daemonCheck struct {
+	mu        sync.Mutex
+	checkedAt time.Time
+	err       error
+}
+
+// dockerDaemonCheck is the shared result of the Docker daemon check
+var dockerDaemonCheck daemonCheck
+
+// checkDockerDaemon checks if the Docker daemon is running
+var checkDockerDaemon = func() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "docker", "stats", "--no-stream")
+	return cmd.Run()
+}
+
+// check returns the result of the Docker daemon check, reusing the last result
+// when it is not older than the given cache window
+func (d *daemonCheck) check(cache time.Duration, logger *common.Logger) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.checkedAt.IsZero() && time.Since(d.checkedAt) < cache {
+		if d.err != nil {
+			logger.Debug("Docker daemon was not available %s ago: not checking it again yet",
+				time.Since(d.checkedAt).Round(time.Millisecond))
+		}
+		return d.err
+	}
+
+	d.err = checkDockerDaemon()
+	d.checkedAt = time.Now()
+	return d.err
+}
+
+// dockerImageExists returns true if the image is available locally
+// (a variable, so tests can stub it)
+var dockerImageExists = func(ctx context.Context, image string) bool {
+	return exec.CommandContext(ctx, "docker", "image", "inspect", image).Run() == nil
+}
+
+// dockerPull pulls an image for the given platform (if not empty), returning an error
+// with the output of docker when it fails (a variable, so tests can stub it)
+var dockerPull = func(ctx context.Context, image string, platform string) error {
+	args := []string{"pull"}
+	if platform != "" {
+		args = append(args, "--platform", platform)
+	}
+	args = append(args, image)
+
+	output, err := exec.CommandContext(ctx, "docker", args...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
 }
 
 // GetBaseDockerCommand creates the common parts of a docker run command with all configured options.
@@ -137,6 +246,21 @@ func (o *DockerRunnerOptions) GetBaseDockerCommand(env []string) []string {
 		parts = append(parts, fmt.Sprintf("--platform %s", o.Platform))
 	}
 
+	// Never let docker run pull the image when the pull policy forbids it
+	if o.PullPolicy == PullPolicyNever {
+		parts = append(parts, "--pull=never")
+	}
+
+	// Make the root filesystem read-only (the script file is still mounted separately)
+	if o.ReadOnly {
+		parts = append(parts, "--read-only")
+	}
+
+	// Add tmpfs mounts
+	for _, tmpfs := range o.Tmpfs {
+		parts = append(parts, fmt.Sprintf("--tmpfs %s", tmpfs))
+	}
+
 	// Add custom docker run options
 	if o.DockerRunOpts != "" {
 		parts = append(parts, o.DockerRunOpts)
@@ -167,7 +291,7 @@ func (o *DockerRunnerOptions) GetDockerCommand(scriptFile string, env []string)
 
 	// Add image and the command to execute the script
 	parts = append(parts, o.Image)
-	parts = append(parts, fmt.Sprintf("sh %s", containerScriptPath))
+	parts = append(parts, fmt.Sprintf("%s %s", o.ContainerShell, containerScriptPath))
 
 	// Join all parts
 	return strings.Join(parts, " ")
@@ -194,6 +318,8 @@ func NewDockerRunnerOptions(genericOpts RunnerOptions) (DockerRunnerOptions, err
 		User:             "",   // Default to Docker's default user
 		WorkDir:          "",   // Default to Docker's default working directory
 		MemorySwappiness: -1,   // Default to Docker's default swappiness
+		ContainerShell:   defaultContainerShell,
+		DaemonCheckCache: defaultDaemonCheckCache,
 	}
 
 	// Parse image (required)
@@ -303,6 +429,50 @@ func NewDockerRunnerOptions(genericOpts RunnerOptions) (DockerRunnerOptions, err
 		opts.Platform = platform
 	}
 
+	// Parse read-only root filesystem option
+	if readOnly, ok := genericOpts["read_only"].(bool); ok {
+		opts.ReadOnly = readOnly
+	}
+
+	// Parse tmpfs mounts
+	if tmpfs, ok := genericOpts["tmpfs"].([]interface{}); ok {
+		for _, t := range tmpfs {
+			if tmpfsStr, ok := t.(string); ok {
+				opts.Tmpfs = append(opts.Tmpfs, tmpfsStr)
+			}
+		}
+	}
+
+	// Parse container shell option
+	if containerShell, ok := genericOpts["container_shell"].(string); ok && containerShell != "" {
+		opts.ContainerShell = containerShell
+	}
+
+	// Parse the pull policy option
+	if pullPolicy, ok := genericOpts["pull_policy"].(string); ok && pullPolicy != "" {
+		switch pullPolicy {
+		case PullPolicyAlways, PullPolicyMissing, PullPolicyNever:
+			opts.PullPolicy = pullPolicy
+		default:
+			return opts, fmt.Errorf("invalid pull_policy '%s': must be '%s', '%s' or '%s'",
+				pullPolicy, PullPolicyAlways, PullPolicyMissing, PullPolicyNever)
+		}
+	}
+
+	// Parse the persistent option
+	if persistent, ok := genericOpts["persistent"].(bool); ok {
+		opts.Persistent = persistent
+	}
+
+	// Parse the daemon check cache option
+	if daemonCheckCache, ok := genericOpts["daemon_check_cache"].(string); ok && daemonCheckCache != "" {
+		duration, err := time.ParseDuration(daemonCheckCache)
+		if err != nil {
+			return opts, fmt.Errorf("invalid daemon_check_cache '%s': %w", daemonCheckCache, err)
+		}
+		opts.DaemonCheckCache = duration
+	}
+
 	return opts, nil
 }
 
@@ -318,6 +488,7 @@ func NewDockerRunner(options RunnerOptions, logger *common.Logger) (*DockerRunne
 	if err != nil {
 		return nil, err
 	}
+	warnUnknownOptionKeys(RunnerTypeDocker, options, DockerRunnerOptions{}, logger)
 
 	// Docker executable and daemon checks are now handled by CheckImplicitRequirements()
 	return &DockerRunner{
@@ -334,23 +505,54 @@ func (r *DockerRunner) CheckImplicitRequirements() error {
 		return fmt.Errorf("docker executable not found in PATH")
 	}
 
-	// Check if Docker daemon is running
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-	cmd := exec.CommandContext(ctx, "docker", "stats", "--no-stream")
-	if err := cmd.Run(); err != nil {
+	// Check if Docker daemon is running (reusing recent checks)
+	if err := dockerDaemonCheck.check(r.opts.DaemonCheckCache, r.logger); err != nil {
 		return fmt.Errorf("docker daemon is not running: %w", err)
 	}
 
+	// Images that are never pulled must be available locally
+	if r.opts.PullPolicy == PullPolicyNever && !dockerImageExists(context.Background(), r.opts.Image) {
+		return fmt.Errorf("docker image '%s' is not available locally (and the pull_policy is '%s')", r.opts.Image, PullPolicyNever)
+	}
+
+	return nil
+}
+
+// pullImage pulls the image of the runner when the pull policy requires it
+func (r *DockerRunner) pullImage(ctx context.Context) error {
+	switch r.opts.PullPolicy {
+	case PullPolicyAlways:
+	case PullPolicyMissing:
+		if dockerImageExists(ctx, r.opts.Image) {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	r.logger.Debug("Pulling docker image '%s' (pull_policy '%s')", r.opts.Image, r.opts.PullPolicy)
+	if err := dockerPull(ctx, r.opts.Image, r.opts.Platform); err != nil {
+		return wrapKindError(ErrRequirementNotMet, fmt.Errorf("failed to pull docker image '%s': %w", r.opts.Image, err))
+	}
 	return nil
 }
 
 // Run executes the command using Docker.
-func (r *DockerRunner) Run(ctx context.Context, shell string, cmd string, env []string, params map[string]interface{}, tmpfile bool) (string, error) {
+func (r *DockerRunner) Run(ctx context.Context, shell string, cmd string, env []string, params map[string]interface{}, tmpfile bool) (*RunResult, error) {
 	// Create an exec runner that we'll use to execute the docker command
 	execRunner, err := NewRunnerExec(RunnerOptions{}, r.logger)
 	if err != nil {
-		return "", fmt.Errorf("failed to create exec runner: %w", err)
+		return nil, fmt.Errorf("failed to create exec runner: %w", err)
+	}
+
+	// Pull the image before running the command, if needed
+	if err := r.pullImage(ctx); err != nil {
+		return nil, err
+	}
+
+	// Run the command in the persistent container, if enabled
+	if r.opts.Persistent {
+		return r.runPersistent(ctx, execRunner, cmd, env, params)
 	}
 
 	var dockerCmd string
@@ -365,7 +567,7 @@ func (r *DockerRunner) Run(ctx context.Context, shell string, cmd string, env []
 		// Create a temporary script file
 		scriptFile, err := r.createScriptFile(shell, cmd, env)
 		if err != nil {
-			return "", fmt.Errorf("failed to create script file: %w", err)
+			return nil, fmt.Errorf("failed to create script file: %w", err)
 		}
 
 		// Clean up the temporary script file when done
@@ -384,12 +586,46 @@ func (r *DockerRunner) Run(ctx context.Context, shell string, cmd string, env []
 	r.logger.Debug("Running command in Docker: %s", dockerCmd)
 
 	// Run the docker command - we set tmpfile to false because dockerCmd is already a full command
-	output, err := execRunner.Run(ctx, "sh", dockerCmd, nil, params, false)
+	// (the exit code of docker run is the exit code of the command)
+	result, err := execRunner.Run(ctx, "sh", dockerCmd, nil, params, false)
 	if err != nil {
-		return "", fmt.Errorf("docker command execution failed: %w", err)
+		return result, fmt.Errorf("docker command execution failed: %w", err)
+	}
+
+	return result, nil
+}
+
+// runPersistent runs the command in the persistent container of the runner,
+// starting the container if needed (or when it is gone)
+func (r *DockerRunner) runPersistent(ctx context.Context, execRunner *RunnerExec, cmd string, env []string, params map[string]interface{}) (*RunResult, error) {
+	for attempt := 1; ; attempt++ {
+		containerID, err := dockerContainers.get(ctx, &r.opts, r.logger)
+		if err != nil {
+			return nil, err
+		}
+
+		dockerCmd := r.opts.GetExecCommand(containerID, cmd, env)
+		r.logger.Debug("Running command in persistent container: %s", dockerCmd)
+
+		result, err := execRunner.Run(ctx, "sh", dockerCmd, nil, params, false)
+		if err == nil {
+			return result, nil
+		}
+
+		// The container could have been stopped or removed by someone else: start a new one
+		if attempt == 1 && isContainerGone(err) {
+			r.logger.Info("Persistent container %s is gone: starting a new one", shortID(containerID))
+			dockerContainers.forget(containerID)
+			continue
+		}
+		return result, fmt.Errorf("docker command execution failed: %w", err)
 	}
+}
 
-	return output, nil
+// isContainerGone returns true if docker exec failed because the container does not run anymore
+func isContainerGone(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "No such container") || strings.Contains(msg, "is not running")
 }
 
 // createScriptFile writes the command to a temporary script file.
@@ -405,7 +641,11 @@ func (r *DockerRunner) createScriptFile(shell string, cmd string, env []string)
 
 	// Prepare script content
 	var content strings.Builder
-	content.WriteString("#!/bin/sh\n\n")
+	// The script is run with the container shell, so the shebang is only
+	// informative: skip it for custom interpreters that may not live in /bin
+	if r.opts.ContainerShell == defaultContainerShell {
+		content.WriteString("#!/bin/sh\n\n")
+	}
 
 	// Add environment variables
 	for _, e := range env {
@@ -428,7 +668,7 @@ func (r *DockerRunner) createScriptFile(shell string, cmd string, env []string)
 	if shell != "" {
 		fmt.Fprintf(&content, "exec %s -c %q\n", shell, cmd)
 	} else {
-		fmt.Fprintf(&content, "exec sh -c %q\n", cmd)
+		fmt.Fprintf(&content, "exec %s -c %q\n", r.opts.ContainerShell, cmd)
 	}
 
 	// Write the content to the file
@@ -0,0 +1,191 @@
+package command
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/inercia/MCPShell/pkg/common"
+)
+
+// persistentContainers keeps the long-lived containers started by the docker runners
+// in persistent mode, shared by all the calls of the tools with the same runner options
+type persistentContainers struct {
+	mu         sync.Mutex
+	containers map[string]*persistentContainer // by runner options (see containerKey)
+}
+
+// persistentContainer is a persistent container, being started until ready is closed
+type persistentContainer struct {
+	ready chan struct{}
+	id    string // set (with the lock held) before ready is closed
+	err   error  // the error starting the container, if any
+}
+
+// newPersistentContainers creates an empty set of persistent containers
+func newPersistentContainers() *persistentContainers {
+	return &persistentContainers{containers: map[string]*persistentContainer{}}
+}
+
+// dockerContainers are the persistent containers started by this process
+var dockerContainers = newPersistentContainers()
+
+// runDocker runs a docker command line with the shell, returning its standard output
+// (a variable, so tests can stub it)
+var runDocker = func(ctx context.Context, command string) (string, error) {
+	var stderr strings.Builder
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// containerKey identifies the persistent container for some runner options: runners
+// with the same image, docker options and prepare command share the container
+func (o *DockerRunnerOptions) containerKey() string {
+	hash := sha256.New()
+	hash.Write([]byte(strings.Join(o.GetBaseDockerCommand(nil), " ")))
+	hash.Write([]byte{0})
+	hash.Write([]byte(o.Image))
+	hash.Write([]byte{0})
+	hash.Write([]byte(o.PrepareCommand))
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// get returns the ID of the persistent container for the runner options, starting it
+// (and running the prepare command in it) the first time. The lock is only held for
+// looking up the container, so the calls for other runner options are not blocked
+// while it starts, and concurrent calls for the same options wait for the same start.
+func (c *persistentContainers) get(ctx context.Context, opts *DockerRunnerOptions, logger *common.Logger) (string, error) {
+	key := opts.containerKey()
+
+	c.mu.Lock()
+	container, ok := c.containers[key]
+	if !ok {
+		container = &persistentContainer{ready: make(chan struct{})}
+		c.containers[key] = container
+	}
+	c.mu.Unlock()
+
+	if ok {
+		select {
+		case <-container.ready:
+			return container.id, container.err
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+
+	id, err := startPersistentContainer(ctx, opts, logger)
+
+	c.mu.Lock()
+	container.id, container.err = id, err
+	if err != nil && c.containers[key] == container {
+		// do not keep failures, so the next call tries again
+		delete(c.containers, key)
+	}
+	c.mu.Unlock()
+	close(container.ready)
+
+	return id, err
+}
+
+// startPersistentContainer starts a persistent container for the runner options, and runs
+// the prepare command in it, returning its ID
+func startPersistentContainer(ctx context.Context, opts *DockerRunnerOptions, logger *common.Logger) (string, error) {
+	// Start the container detached, with the same options as "docker run --rm",
+	// just sleeping so commands can be executed in it later
+	parts := append(opts.GetBaseDockerCommand(nil), "--detach --label mcpshell.persistent=true", opts.Image, "sleep infinity")
+	startCmd := strings.Join(parts, " ")
+	logger.Debug("Starting persistent container: %s", startCmd)
+	id, err := runDocker(ctx, startCmd)
+	if err != nil {
+		return "", fmt.Errorf("failed to start persistent container for image '%s': %w", opts.Image, err)
+	}
+
+	if opts.PrepareCommand != "" {
+		logger.Debug("Running the prepare command in the persistent container %s", shortID(id))
+		if _, err := runDocker(ctx, opts.GetExecCommand(id, opts.PrepareCommand, nil)); err != nil {
+			_, _ = runDocker(context.Background(), "docker rm --force "+id)
+			return "", fmt.Errorf("failed to run the prepare command in the persistent container: %w", err)
+		}
+	}
+
+	logger.Info("Started persistent container %s for image '%s'", shortID(id), opts.Image)
+	return id, nil
+}
+
+// forget drops a container that cannot be used anymore, so a new one is started
+// the next time it is needed
+func (c *persistentContainers) forget(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, container := range c.containers {
+		if container.id == id {
+			delete(c.containers, key)
+		}
+	}
+}
+
+// stop removes all the persistent containers, waiting for the ones being started
+func (c *persistentContainers) stop(logger *common.Logger) {
+	c.mu.Lock()
+	containers := c.containers
+	c.containers = map[string]*persistentContainer{}
+	c.mu.Unlock()
+
+	for _, container := range containers {
+		<-container.ready
+		if container.err != nil {
+			continue
+		}
+		logger.Info("Removing persistent container %s", shortID(container.id))
+		if _, err := runDocker(context.Background(), "docker rm --force "+container.id); err != nil {
+			logger.Error("Failed to remove persistent container %s: %v", shortID(container.id), err)
+		}
+	}
+}
+
+// StopPersistentContainers removes the containers started by the docker runners
+// in persistent mode. It must be called when the server shuts down.
+func StopPersistentContainers(logger *common.Logger) {
+	if logger == nil {
+		logger = common.GetLogger()
+	}
+	dockerContainers.stop(logger)
+}
+
+// GetExecCommand constructs the docker exec command for running a command in
+// a persistent container, with the given environment variables
+func (o *DockerRunnerOptions) GetExecCommand(containerID string, cmd string, env []string) string {
+	parts := []string{"docker exec"}
+	for _, e := range env {
+		parts = append(parts, "-e", shellQuote(e))
+	}
+	parts = append(parts, containerID, o.ContainerShell, "-c", shellQuote(cmd))
+	return strings.Join(parts, " ")
+}
+
+// shellQuote quotes a string as a single argument for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// shortID returns the short form of a container ID, for logging
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
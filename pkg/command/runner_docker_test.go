@@ -2,9 +2,13 @@ package command
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -96,7 +100,7 @@ func TestDockerRunnerBasic(t *testing.T) {
 	}
 
 	// Test a simple echo command (this should work even in GitHub Actions)
-	output, err := runner.Run(context.Background(), "", "echo 'Hello from Docker'", nil, nil, false)
+	output, err := stdoutOf(runner.Run(context.Background(), "", "echo 'Hello from Docker'", nil, nil, false))
 	if err != nil {
 		t.Errorf("Failed to run command: %v", err)
 	}
@@ -192,7 +196,7 @@ func TestDockerRunnerEnvironmentVariables(t *testing.T) {
 	}
 
 	// Run a command that echoes the environment variables
-	output, err := runner.Run(context.Background(), "", "echo $TEST_VAR1,$TEST_VAR2,$TEST_VAR3", env, nil, false)
+	output, err := stdoutOf(runner.Run(context.Background(), "", "echo $TEST_VAR1,$TEST_VAR2,$TEST_VAR3", env, nil, false))
 	if err != nil {
 		t.Errorf("Failed to run command with environment variables: %v", err)
 	}
@@ -204,7 +208,7 @@ func TestDockerRunnerEnvironmentVariables(t *testing.T) {
 	}
 
 	// Test with a mix of shell variables and environment variables
-	output, err = runner.Run(context.Background(), "sh", "echo $TEST_VAR1 and $TEST_VAR2", env, nil, false)
+	output, err = stdoutOf(runner.Run(context.Background(), "sh", "echo $TEST_VAR1 and $TEST_VAR2", env, nil, false))
 	if err != nil {
 		t.Errorf("Failed to run command with mixed variables: %v", err)
 	}
@@ -234,7 +238,7 @@ func TestDockerRunnerPrepareCommand(t *testing.T) {
 	}
 
 	// Run grep command that should only work if the prepare_command executed properly
-	output, err := runner.Run(context.Background(), "", "grep --version | head -n 1", nil, nil, false)
+	output, err := stdoutOf(runner.Run(context.Background(), "", "grep --version | head -n 1", nil, nil, false))
 	if err != nil {
 		t.Errorf("Failed to run command that requires prepare_command: %v", err)
 	}
@@ -257,7 +261,7 @@ func TestDockerRunner_Optimization_SingleExecutable(t *testing.T) {
 		t.Fatalf("Failed to create Docker runner: %v", err)
 	}
 	// Should succeed: /bin/ls is a single executable in alpine
-	output, err := runner.Run(context.Background(), "", "/bin/ls", nil, nil, false)
+	output, err := stdoutOf(runner.Run(context.Background(), "", "/bin/ls", nil, nil, false))
 	if err != nil {
 		t.Errorf("Expected /bin/ls to run without error in Docker, got: %v", err)
 	}
@@ -271,6 +275,95 @@ func TestDockerRunner_Optimization_SingleExecutable(t *testing.T) {
 	}
 }
 
+func TestDockerRunner_ContainerShell(t *testing.T) {
+	logger, _ := common.NewLogger("test-docker-shell: ", "", common.LogLevelNone, false)
+
+	t.Run("default shell", func(t *testing.T) {
+		runner, err := NewDockerRunner(RunnerOptions{"image": "alpine:latest"}, logger)
+		if err != nil {
+			t.Fatalf("Failed to create Docker runner: %v", err)
+		}
+
+		scriptFile, err := runner.createScriptFile("", "echo hello | wc -c", nil)
+		if err != nil {
+			t.Fatalf("Failed to create script file: %v", err)
+		}
+		defer func() { _ = os.Remove(scriptFile) }()
+
+		content, err := os.ReadFile(scriptFile)
+		if err != nil {
+			t.Fatalf("Failed to read script file: %v", err)
+		}
+		if !strings.HasPrefix(string(content), "#!/bin/sh\n") {
+			t.Errorf("Expected a /bin/sh shebang, got:\n%s", content)
+		}
+		if !strings.Contains(string(content), "exec sh -c") {
+			t.Errorf("Expected the command to be run with sh, got:\n%s", content)
+		}
+		if cmd := runner.opts.GetDockerCommand(scriptFile, nil); !strings.HasSuffix(cmd, "alpine:latest sh /tmp/"+filepath.Base(scriptFile)) {
+			t.Errorf("Expected the script to be run with sh, got: %s", cmd)
+		}
+	})
+
+	t.Run("custom shell", func(t *testing.T) {
+		runner, err := NewDockerRunner(RunnerOptions{
+			"image":           "gcr.io/distroless/base:debug",
+			"container_shell": "/busybox/sh",
+		}, logger)
+		if err != nil {
+			t.Fatalf("Failed to create Docker runner: %v", err)
+		}
+
+		scriptFile, err := runner.createScriptFile("", "echo hello | wc -c", nil)
+		if err != nil {
+			t.Fatalf("Failed to create script file: %v", err)
+		}
+		defer func() { _ = os.Remove(scriptFile) }()
+
+		content, err := os.ReadFile(scriptFile)
+		if err != nil {
+			t.Fatalf("Failed to read script file: %v", err)
+		}
+		if strings.HasPrefix(string(content), "#!") {
+			t.Errorf("Expected no shebang with a custom container shell, got:\n%s", content)
+		}
+		if !strings.Contains(string(content), "exec /busybox/sh -c") {
+			t.Errorf("Expected the command to be run with /busybox/sh, got:\n%s", content)
+		}
+		if cmd := runner.opts.GetDockerCommand(scriptFile, nil); !strings.HasSuffix(cmd, "debug /busybox/sh /tmp/"+filepath.Base(scriptFile)) {
+			t.Errorf("Expected the script to be run with /busybox/sh, got: %s", cmd)
+		}
+	})
+}
+
+func TestDockerRunner_ContainerShellNonStandardImage(t *testing.T) {
+	if !checkDockerRunning() {
+		t.Skip("Docker not installed or not running, skipping test")
+	}
+	if os.Getenv("GITHUB_ACTIONS") == "true" {
+		t.Skip("Skipping test pulling a distroless image in GitHub Actions environment")
+	}
+
+	logger, _ := common.NewLogger("test-docker-shell: ", "", common.LogLevelInfo, false)
+
+	// the distroless debug images have no /bin/sh, only /busybox/sh
+	runner, err := NewDockerRunner(RunnerOptions{
+		"image":           "gcr.io/distroless/base:debug",
+		"container_shell": "/busybox/sh",
+	}, logger)
+	if err != nil {
+		t.Fatalf("Failed to create Docker runner: %v", err)
+	}
+
+	output, err := stdoutOf(runner.Run(context.Background(), "", "echo hello from busybox | cat", nil, nil, false))
+	if err != nil {
+		t.Fatalf("Failed to run command with a custom container shell: %v", err)
+	}
+	if !strings.Contains(output, "hello from busybox") {
+		t.Errorf("Expected output to contain 'hello from busybox', got: %q", output)
+	}
+}
+
 func TestNewDockerRunnerOptions(t *testing.T) {
 	testCases := []struct {
 		name        string
@@ -287,6 +380,7 @@ func TestNewDockerRunnerOptions(t *testing.T) {
 				Image:            "alpine:latest",
 				AllowNetworking:  true,
 				MemorySwappiness: -1,
+				DaemonCheckCache: defaultDaemonCheckCache,
 			},
 			expectError: false,
 		},
@@ -316,6 +410,9 @@ func TestNewDockerRunnerOptions(t *testing.T) {
 				"dns":                []interface{}{"8.8.8.8"},
 				"dns_search":         []interface{}{"example.com"},
 				"platform":           "linux/amd64",
+				"read_only":          true,
+				"tmpfs":              []interface{}{"/tmp", "/run:rw,size=64m"},
+				"daemon_check_cache": "1m",
 			},
 			expected: DockerRunnerOptions{
 				Image:             "ubuntu:20.04",
@@ -335,9 +432,21 @@ func TestNewDockerRunnerOptions(t *testing.T) {
 				DNS:               []string{"8.8.8.8"},
 				DNSSearch:         []string{"example.com"},
 				Platform:          "linux/amd64",
+				ReadOnly:          true,
+				Tmpfs:             []string{"/tmp", "/run:rw,size=64m"},
+				DaemonCheckCache:  time.Minute,
 			},
 			expectError: false,
 		},
+		{
+			name: "invalid daemon check cache",
+			input: RunnerOptions{
+				"image":              "alpine:latest",
+				"daemon_check_cache": "often",
+			},
+			expected:    DockerRunnerOptions{},
+			expectError: true,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -382,6 +491,13 @@ func TestNewDockerRunnerOptions(t *testing.T) {
 				t.Errorf("PrepareCommand: expected %q, got %q", tc.expected.PrepareCommand, result.PrepareCommand)
 			}
 
+			if result.ReadOnly != tc.expected.ReadOnly {
+				t.Errorf("ReadOnly: expected %v, got %v", tc.expected.ReadOnly, result.ReadOnly)
+			}
+			if result.DaemonCheckCache != tc.expected.DaemonCheckCache {
+				t.Errorf("DaemonCheckCache: expected %v, got %v", tc.expected.DaemonCheckCache, result.DaemonCheckCache)
+			}
+
 			// Check slice fields
 			if !compareStringSlices(result.Mounts, tc.expected.Mounts) {
 				t.Errorf("Mounts: expected %v, got %v", tc.expected.Mounts, result.Mounts)
@@ -398,10 +514,301 @@ func TestNewDockerRunnerOptions(t *testing.T) {
 			if !compareStringSlices(result.DNSSearch, tc.expected.DNSSearch) {
 				t.Errorf("DNSSearch: expected %v, got %v", tc.expected.DNSSearch, result.DNSSearch)
 			}
+			if !compareStringSlices(result.Tmpfs, tc.expected.Tmpfs) {
+				t.Errorf("Tmpfs: expected %v, got %v", tc.expected.Tmpfs, result.Tmpfs)
+			}
+
+			// The flags are emitted in the docker command, and the script is still mounted
+			if tc.expected.ReadOnly {
+				cmd := result.GetDockerCommand("/tmp/script.sh", nil)
+				for _, flag := range []string{"--read-only", "--tmpfs /tmp", "--tmpfs /run:rw,size=64m", "-v /tmp/script.sh:/tmp/script.sh"} {
+					if !strings.Contains(cmd, flag) {
+						t.Errorf("Expected %q in the docker command, got %q", flag, cmd)
+					}
+				}
+			}
 		})
 	}
 }
 
+func TestDockerRunner_PullImage(t *testing.T) {
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+
+	originalExists, originalPull := dockerImageExists, dockerPull
+	defer func() { dockerImageExists, dockerPull = originalExists, originalPull }()
+
+	imageExists := false
+	var pullErr error
+	var pulls []string
+	dockerImageExists = func(ctx context.Context, image string) bool { return imageExists }
+	dockerPull = func(ctx context.Context, image string, platform string) error {
+		pulls = append(pulls, image+"@"+platform)
+		return pullErr
+	}
+
+	newRunner := func(t *testing.T, options RunnerOptions) *DockerRunner {
+		t.Helper()
+		runner, err := NewDockerRunner(options, logger)
+		if err != nil {
+			t.Fatalf("Failed to create Docker runner: %v", err)
+		}
+		return runner
+	}
+
+	testCases := []struct {
+		name        string
+		options     RunnerOptions
+		imageExists bool
+		expected    []string
+	}{
+		{"no policy", RunnerOptions{"image": "alpine:latest"}, false, nil},
+		{"always", RunnerOptions{"image": "alpine:latest", "pull_policy": "always", "platform": "linux/arm64"}, true, []string{"alpine:latest@linux/arm64"}},
+		{"missing and present", RunnerOptions{"image": "alpine:latest", "pull_policy": "missing"}, true, nil},
+		{"missing and absent", RunnerOptions{"image": "alpine:latest", "pull_policy": "missing"}, false, []string{"alpine:latest@"}},
+		{"never", RunnerOptions{"image": "alpine:latest", "pull_policy": "never"}, false, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pulls, imageExists = nil, tc.imageExists
+			if err := newRunner(t, tc.options).pullImage(context.Background()); err != nil {
+				t.Fatalf("pullImage() error = %v", err)
+			}
+			if !compareStringSlices(pulls, tc.expected) {
+				t.Errorf("Expected pulls %v, got %v", tc.expected, pulls)
+			}
+		})
+	}
+
+	t.Run("failed pull", func(t *testing.T) {
+		pullErr = errors.New("manifest unknown")
+		defer func() { pullErr = nil }()
+
+		err := newRunner(t, RunnerOptions{"image": "nonexistent:latest", "pull_policy": "always"}).pullImage(context.Background())
+		if err == nil || !errors.Is(err, ErrRequirementNotMet) {
+			t.Fatalf("Expected a requirement error, got %v", err)
+		}
+		if !strings.Contains(err.Error(), "failed to pull docker image 'nonexistent:latest': manifest unknown") {
+			t.Errorf("Expected a friendly error, got %q", err.Error())
+		}
+	})
+
+	t.Run("never pulled by docker run", func(t *testing.T) {
+		for policy, want := range map[string]bool{"": false, "always": false, "missing": false, "never": true} {
+			opts, err := NewDockerRunnerOptions(RunnerOptions{"image": "alpine:latest", "pull_policy": policy})
+			if err != nil {
+				t.Fatalf("NewDockerRunnerOptions() error = %v", err)
+			}
+			if got := strings.Contains(opts.GetDockerCommand("/tmp/script.sh", nil), "--pull=never"); got != want {
+				t.Errorf("pull_policy %q: --pull=never in the docker command = %v, want %v", policy, got, want)
+			}
+		}
+	})
+
+	t.Run("invalid policy", func(t *testing.T) {
+		if _, err := NewDockerRunnerOptions(RunnerOptions{"image": "alpine:latest", "pull_policy": "sometimes"}); err == nil {
+			t.Error("Expected an error for an invalid pull policy")
+		}
+	})
+}
+
+func TestDockerRunner_PersistentContainers(t *testing.T) {
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+
+	originalRunDocker := runDocker
+	defer func() { runDocker = originalRunDocker }()
+
+	var commands []string
+	runDocker = func(ctx context.Context, command string) (string, error) {
+		commands = append(commands, command)
+		if strings.HasPrefix(command, "docker run") {
+			return fmt.Sprintf("container%d", len(commands)), nil
+		}
+		return "", nil
+	}
+
+	containers := newPersistentContainers()
+
+	opts, err := NewDockerRunnerOptions(RunnerOptions{
+		"image":           "alpine:latest",
+		"persistent":      true,
+		"prepare_command": "apk add curl",
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse options: %v", err)
+	}
+	if !opts.Persistent {
+		t.Fatal("Expected the persistent option to be parsed")
+	}
+
+	// the container is started (and prepared) only once
+	first, err := containers.get(context.Background(), &opts, logger)
+	if err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+	second, err := containers.get(context.Background(), &opts, logger)
+	if err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+	if first != second {
+		t.Errorf("Expected the container to be reused, got %q and %q", first, second)
+	}
+	if len(commands) != 2 {
+		t.Fatalf("Expected a start and a prepare command, got %q", commands)
+	}
+	if !strings.HasPrefix(commands[0], "docker run --rm") || !strings.HasSuffix(commands[0], "--detach --label mcpshell.persistent=true alpine:latest sleep infinity") {
+		t.Errorf("Unexpected start command: %q", commands[0])
+	}
+	if commands[1] != "docker exec "+first+" sh -c 'apk add curl'" {
+		t.Errorf("Unexpected prepare command: %q", commands[1])
+	}
+
+	// runners with other options get their own container
+	otherOpts, _ := NewDockerRunnerOptions(RunnerOptions{"image": "ubuntu:22.04", "persistent": true})
+	other, err := containers.get(context.Background(), &otherOpts, logger)
+	if err != nil || other == first {
+		t.Errorf("Expected a different container for other options, got %q (%v)", other, err)
+	}
+
+	// forgotten containers are started again
+	containers.forget(first)
+	if restarted, _ := containers.get(context.Background(), &opts, logger); restarted == first {
+		t.Errorf("Expected a new container after forgetting %q", first)
+	}
+
+	// all the containers are removed when stopping
+	commands = nil
+	containers.stop(logger)
+	if len(commands) != 2 || !strings.HasPrefix(commands[0], "docker rm --force ") {
+		t.Errorf("Expected the containers to be removed, got %q", commands)
+	}
+	if len(containers.containers) != 0 {
+		t.Errorf("Expected no containers after stopping, got %v", containers.containers)
+	}
+}
+
+func TestDockerRunner_PersistentContainersConcurrency(t *testing.T) {
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+
+	originalRunDocker := runDocker
+	defer func() { runDocker = originalRunDocker }()
+
+	// the start of the alpine container blocks until it is released
+	starting, release := make(chan struct{}, 3), make(chan struct{})
+	var mu sync.Mutex
+	starts := map[string]int{}
+	runDocker = func(ctx context.Context, command string) (string, error) {
+		if !strings.HasPrefix(command, "docker run") {
+			return "", nil
+		}
+		image := "ubuntu"
+		if strings.Contains(command, "alpine") {
+			image = "alpine"
+			starting <- struct{}{}
+			<-release
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		starts[image]++
+		return image + "-container", nil
+	}
+
+	containers := newPersistentContainers()
+	alpineOpts, _ := NewDockerRunnerOptions(RunnerOptions{"image": "alpine:latest", "persistent": true})
+	ubuntuOpts, _ := NewDockerRunnerOptions(RunnerOptions{"image": "ubuntu:22.04", "persistent": true})
+
+	var wg sync.WaitGroup
+	ids := make([]string, 3)
+	for i := range ids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ids[i], _ = containers.get(context.Background(), &alpineOpts, logger)
+		}(i)
+	}
+
+	<-starting
+
+	// other runner options are not blocked by the container being started...
+	if id, err := containers.get(context.Background(), &ubuntuOpts, logger); err != nil || id != "ubuntu-container" {
+		t.Errorf("get() = %q, %v while another container is starting", id, err)
+	}
+
+	// ... and the calls waiting for it can give up
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := containers.get(ctx, &alpineOpts, logger); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("get() with an expired context error = %v, want a deadline error", err)
+	}
+
+	// concurrent calls for the same options share the same start
+	close(release)
+	wg.Wait()
+	for _, id := range ids {
+		if id != "alpine-container" {
+			t.Errorf("Expected all the calls to get the same container, got %q", ids)
+			break
+		}
+	}
+	if starts["alpine"] != 1 {
+		t.Errorf("Expected the container to be started once, it was started %d times", starts["alpine"])
+	}
+}
+
+func TestDockerRunnerOptions_GetExecCommand(t *testing.T) {
+	opts := DockerRunnerOptions{ContainerShell: "sh"}
+	got := opts.GetExecCommand("abc123", "echo 'hi' | wc -c", []string{"FOO=bar", "MSG=a b; rm -rf /"})
+	want := `docker exec -e 'FOO=bar' -e 'MSG=a b; rm -rf /' abc123 sh -c 'echo '\''hi'\'' | wc -c'`
+	if got != want {
+		t.Errorf("GetExecCommand() = %s, want %s", got, want)
+	}
+}
+
+func TestDockerDaemonCheck_Cache(t *testing.T) {
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+
+	calls := 0
+	daemonErr := errors.New("daemon is busy")
+	originalCheck := checkDockerDaemon
+	checkDockerDaemon = func() error {
+		calls++
+		return daemonErr
+	}
+	defer func() { checkDockerDaemon = originalCheck }()
+
+	var d daemonCheck
+
+	// failures are reused within the cache window (the circuit is open)...
+	for i := 0; i < 3; i++ {
+		if err := d.check(time.Minute, logger); !errors.Is(err, daemonErr) {
+			t.Fatalf("check() error = %v, want %v", err, daemonErr)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected the daemon to be checked once within the cache window, got %d checks", calls)
+	}
+
+	// ... and retried when it expires
+	daemonErr = nil
+	d.checkedAt = time.Now().Add(-2 * time.Minute)
+	if err := d.check(time.Minute, logger); err != nil {
+		t.Errorf("check() after the cache window error = %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected the daemon to be checked again after the cache window, got %d checks", calls)
+	}
+
+	// successful checks are reused too
+	if err := d.check(time.Minute, logger); err != nil || calls != 2 {
+		t.Errorf("check() = %v with %d checks, want the cached success", err, calls)
+	}
+
+	// without a cache window, the daemon is always checked
+	if err := d.check(0, logger); err != nil || calls != 3 {
+		t.Errorf("check() without cache = %v with %d checks, want a new check", err, calls)
+	}
+}
+
 // Helper function to compare string slices
 func compareStringSlices(a, b []string) bool {
 	if len(a) != len(b) {
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -22,7 +21,8 @@ type RunnerExec struct {
 
 // RunnerExecOptions is the options for the RunnerExec
 type RunnerExecOptions struct {
-	Shell string `json:"shell"`
+	Shell   string `json:"shell"`
+	TempDir string `json:"temp_dir"`
 }
 
 // NewRunnerExecOptions creates a new RunnerExecOptions from a RunnerOptions
@@ -49,6 +49,7 @@ func NewRunnerExec(options RunnerOptions, logger *common.Logger) (*RunnerExec, e
 	if err != nil {
 		return nil, err
 	}
+	warnUnknownOptionKeys(RunnerTypeExec, options, RunnerExecOptions{}, logger)
 
 	return &RunnerExec{
 		logger:  logger,
@@ -65,11 +66,11 @@ func (r *RunnerExec) Run(ctx context.Context, shell string,
 	command string,
 	env []string, params map[string]interface{},
 	tmpfile bool,
-) (string, error) {
+) (*RunResult, error) {
 	// Check if context is done
 	select {
 	case <-ctx.Done():
-		return "", ctx.Err()
+		return nil, ctx.Err()
 	default:
 		// Continue execution
 	}
@@ -102,10 +103,10 @@ func (r *RunnerExec) Run(ctx context.Context, shell string,
 	} else if tmpfile {
 		// Create a temporary file for the command
 		var err error
-		tmpDir, err = os.MkdirTemp("", "mcpshell")
+		tmpDir, err = os.MkdirTemp(r.options.TempDir, "mcpshell")
 		if err != nil {
 			r.logger.Debug("Failed to create temp directory: %v", err)
-			return "", err
+			return nil, err
 		}
 		defer func() {
 			if err := os.RemoveAll(tmpDir); err != nil {
@@ -125,7 +126,7 @@ func (r *RunnerExec) Run(ctx context.Context, shell string,
 		err = os.WriteFile(tmpFile, []byte(scriptContent.String()), 0o700)
 		if err != nil {
 			r.logger.Debug("Failed to write temporary file: %v", err)
-			return "", err
+			return nil, err
 		}
 
 		r.logger.Debug("Created temporary script file at: %s", tmpFile)
@@ -163,16 +164,10 @@ func (r *RunnerExec) Run(ctx context.Context, shell string,
 	// Run the command
 	r.logger.Debug("Executing command")
 
-	err := execCmd.Run()
+	runErr := execCmd.Run()
+	result, err := newRunResult(stdout.String(), stderr.String(), runErr, r.logger)
 	if err != nil {
-		// If there's error output, include it in the error
-		if stderr.Len() > 0 {
-			errMsg := strings.TrimSpace(stderr.String())
-			r.logger.Debug("Command failed with stderr: %s", errMsg)
-			return "", errors.New(errMsg)
-		}
-		r.logger.Debug("Command failed with error: %v", err)
-		return "", err
+		return result, err
 	}
 
 	// Get the combined output in case stdout doesn't capture everything
@@ -193,16 +188,16 @@ func (r *RunnerExec) Run(ctx context.Context, shell string,
 	}
 
 	// Trim the output but preserve meaningful content
-	output = strings.TrimSpace(output)
+	result.Stdout = strings.TrimSpace(output)
+	result.RawStdout = output
 
-	r.logger.Debug("Command executed successfully, output length: %d bytes", len(output))
+	r.logger.Debug("Command executed successfully, output length: %d bytes", len(result.Stdout))
 	if stderr.Len() > 0 {
 		r.logger.Debug("Command generated stderr (but no error): '%s'", strings.TrimSpace(stderrStr))
 	}
-	r.logger.Debug("Full output captured: '%s'", output)
+	r.logger.Debug("Full output captured: '%s'", result.Stdout)
 
-	// Return the output
-	return output, nil
+	return result, nil
 }
 
 // isCmdShell checks if the given shell is a Windows cmd shell
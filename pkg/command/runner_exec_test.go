@@ -112,7 +112,7 @@ func TestRunnerExec_Run(t *testing.T) {
 				t.Fatalf("Failed to create RunnerExec: %v", err)
 			}
 
-			got, err := r.Run(context.Background(), tt.shell, tt.command, tt.env, tt.params, true)
+			got, err := stdoutOf(r.Run(context.Background(), tt.shell, tt.command, tt.env, tt.params, true))
 			if (err != nil) != tt.wantErr {
 				t.Errorf("RunnerExec.Run() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -143,14 +143,14 @@ func TestRunnerExec_RunWithEnvExpansion(t *testing.T) {
 	}
 
 	// Use the shell's -c flag directly to execute a command that expands an environment variable
-	output, err := r.Run(
+	output, err := stdoutOf(r.Run(
 		context.Background(),
 		"",
 		command,
 		[]string{"TEST_VAR=test_value_expanded"},
 		nil,
 		false, // No tmpfile needed for this test
-	)
+	))
 
 	if err != nil {
 		t.Fatalf("RunnerExec.Run() error = %v", err)
@@ -173,7 +173,7 @@ func TestRunnerExec_Optimization_SingleExecutable(t *testing.T) {
 
 	// This command should be a single executable and run directly
 	command := "whoami"
-	output, err := r.Run(context.Background(), "", command, nil, nil, false)
+	output, err := stdoutOf(r.Run(context.Background(), "", command, nil, nil, false))
 	if err != nil {
 		t.Errorf("Expected '%s' to run without error, got: %v", command, err)
 	}
@@ -185,7 +185,7 @@ func TestRunnerExec_Optimization_SingleExecutable(t *testing.T) {
 	// isSingleExecutableCommand should return false.
 	// The command itself should succeed when run through the shell.
 	commandWithArgs := "echo hello"
-	output, err = r.Run(context.Background(), "", commandWithArgs, nil, nil, false)
+	output, err = stdoutOf(r.Run(context.Background(), "", commandWithArgs, nil, nil, false))
 	if err != nil {
 		t.Errorf("Expected '%s' to run without error, got: %v", commandWithArgs, err)
 	}
@@ -193,3 +193,34 @@ func TestRunnerExec_Optimization_SingleExecutable(t *testing.T) {
 		t.Errorf("Expected output from '%s' to be 'hello', got %q", commandWithArgs, output)
 	}
 }
+
+func TestRunnerExec_RunResult(t *testing.T) {
+	r, err := NewRunnerExec(RunnerOptions{}, testLogger)
+	if err != nil {
+		t.Fatalf("Failed to create RunnerExec: %v", err)
+	}
+
+	result, err := r.Run(context.Background(), "", "echo 'out'; echo 'err' >&2; exit 3", nil, nil, false)
+	if err == nil {
+		t.Fatalf("Expected an error for a non-zero exit code")
+	}
+	if result == nil {
+		t.Fatalf("Expected a result for a command that was run")
+	}
+	if result.ExitCode != 3 || result.Stdout != "out" || result.Stderr != "err" {
+		t.Errorf("Run() = %+v, want exit code 3, stdout 'out' and stderr 'err'", result)
+	}
+
+	result, err = r.Run(context.Background(), "", "echo 'out'", nil, nil, false)
+	if err != nil || result.ExitCode != 0 || result.Stdout != "out" {
+		t.Errorf("Run() = %+v, %v, want exit code 0 and stdout 'out'", result, err)
+	}
+}
+
+// stdoutOf returns the stdout of the result of a runner, for tests only checking the output
+func stdoutOf(result *RunResult, err error) (string, error) {
+	if result == nil {
+		return "", err
+	}
+	return result.Stdout, err
+}
@@ -5,13 +5,12 @@ import (
 	"context"
 	_ "embed"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"runtime"
-	"strings"
 	"text/template"
+	"time"
 
 	"github.com/inercia/MCPShell/pkg/common"
 )
@@ -36,6 +35,29 @@ type RunnerFirejailOptions struct {
 	AllowReadFiles    []string `json:"allow_read_files"`
 	AllowWriteFiles   []string `json:"allow_write_files"`
 	CustomProfile     string   `json:"custom_profile"`
+	ScriptWithShell   bool     `json:"script_with_shell"`
+	TempDir           string   `json:"temp_dir"`
+	RlimitCPU         int      `json:"rlimit_cpu"`   // CPU time, in seconds (0 for no limit)
+	RlimitAS          int64    `json:"rlimit_as"`    // size of the address space, in bytes (0 for no limit)
+	RlimitNproc       int      `json:"rlimit_nproc"` // number of processes of the user (0 for no limit)
+	Timeout           string   `json:"timeout"`      // wall-clock time, as a duration like "30s" (empty for no limit)
+}
+
+// TimeoutClock returns the timeout in the hh:mm:ss format of firejail, or an empty
+// string when there is no timeout (or it is invalid). Used in the profile template.
+func (o RunnerFirejailOptions) TimeoutClock() string {
+	if o.Timeout == "" {
+		return ""
+	}
+	timeout, err := time.ParseDuration(o.Timeout)
+	if err != nil || timeout <= 0 {
+		return ""
+	}
+	seconds := int(timeout.Round(time.Second).Seconds())
+	if seconds == 0 {
+		seconds = 1
+	}
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
 }
 
 // NewRunnerFirejailOptions creates a new RunnerFirejailOptions from a RunnerOptions
@@ -45,8 +67,18 @@ func NewRunnerFirejailOptions(options RunnerOptions) (RunnerFirejailOptions, err
 	if err != nil {
 		return RunnerFirejailOptions{}, err
 	}
-	err = json.Unmarshal([]byte(opts), &reopts)
-	return reopts, err
+	if err := json.Unmarshal([]byte(opts), &reopts); err != nil {
+		return reopts, err
+	}
+	if reopts.RlimitCPU < 0 || reopts.RlimitAS < 0 || reopts.RlimitNproc < 0 {
+		return reopts, fmt.Errorf("firejail rlimits cannot be negative")
+	}
+	if reopts.Timeout != "" {
+		if timeout, err := time.ParseDuration(reopts.Timeout); err != nil || timeout <= 0 {
+			return reopts, fmt.Errorf("invalid firejail timeout '%s'", reopts.Timeout)
+		}
+	}
+	return reopts, nil
 }
 
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -71,6 +103,7 @@ func NewRunnerFirejail(options RunnerOptions, logger *common.Logger) (*RunnerFir
 		logger.Debug("Failed to parse firejail options: %v", err)
 		return nil, fmt.Errorf("failed to parse firejail options: %w", err)
 	}
+	warnUnknownOptionKeys(RunnerTypeFirejail, options, RunnerFirejailOptions{}, logger)
 
 	return &RunnerFirejail{
 		logger:     logger,
@@ -82,43 +115,26 @@ func NewRunnerFirejail(options RunnerOptions, logger *common.Logger) (*RunnerFir
 // Run executes a command inside the firejail sandbox and returns the output
 // It implements the Runner interface
 //
-// note: tmpfile is ignored for firejail because it's not supported
+// When tmpfile is true, the command is written to a temporary script (in the temp_dir
+// option, or the default temporary directory); otherwise it is passed to the shell with -c.
 func (r *RunnerFirejail) Run(ctx context.Context,
 	shell string, command string,
 	env []string, params map[string]interface{}, tmpfile bool,
-) (string, error) {
+) (*RunResult, error) {
 	fullCmd := command
 
 	// Check if context is done
 	select {
 	case <-ctx.Done():
-		return "", ctx.Err()
+		return nil, ctx.Err()
 	default:
 		// Continue execution
 	}
 
-	// replace template variables in allow read and write folders and files
-	if len(r.options.AllowReadFolders) > 0 {
-		r.options.AllowReadFolders = common.ProcessTemplateListFlexible(r.options.AllowReadFolders, params)
-	}
-	if len(r.options.AllowWriteFolders) > 0 {
-		r.options.AllowWriteFolders = common.ProcessTemplateListFlexible(r.options.AllowWriteFolders, params)
-	}
-	if len(r.options.AllowReadFiles) > 0 {
-		r.options.AllowReadFiles = common.ProcessTemplateListFlexible(r.options.AllowReadFiles, params)
-	}
-	if len(r.options.AllowWriteFiles) > 0 {
-		r.options.AllowWriteFiles = common.ProcessTemplateListFlexible(r.options.AllowWriteFiles, params)
-	}
-
-	// Generate the profile by rendering the template
-	var profileBuf bytes.Buffer
-	if err := r.profileTpl.Execute(&profileBuf, r.options); err != nil {
-		r.logger.Debug("Failed to render firejail profile template: %v", err)
-		return "", fmt.Errorf("failed to render firejail profile: %w", err)
+	profile, err := r.renderProfile(params)
+	if err != nil {
+		return nil, err
 	}
-
-	profile := profileBuf.String()
 	r.logger.Debug("Firejail options: %+v", r.options)
 	r.logger.Debug("Generated firejail profile: %s", profile)
 
@@ -126,7 +142,7 @@ func (r *RunnerFirejail) Run(ctx context.Context,
 	profileFile, err := os.CreateTemp("", "firejail-profile-*.profile")
 	if err != nil {
 		r.logger.Debug("Failed to create temporary profile file: %v", err)
-		return "", fmt.Errorf("failed to create temporary profile file: %w", err)
+		return nil, fmt.Errorf("failed to create temporary profile file: %w", err)
 	}
 	defer func() {
 		profileFilePath := profileFile.Name()
@@ -141,64 +157,27 @@ func (r *RunnerFirejail) Run(ctx context.Context,
 	// Write the profile to the temporary file
 	if _, err := profileFile.WriteString(profile); err != nil {
 		r.logger.Debug("Failed to write profile to temporary file: %v", err)
-		return "", fmt.Errorf("failed to write profile to temporary file: %w", err)
+		return nil, fmt.Errorf("failed to write profile to temporary file: %w", err)
 	}
 
 	// Flush data to ensure it's written to disk
 	if err := profileFile.Sync(); err != nil {
 		r.logger.Debug("Failed to sync profile file: %v", err)
-		return "", fmt.Errorf("failed to sync profile file: %w", err)
+		return nil, fmt.Errorf("failed to sync profile file: %w", err)
 	}
 
-	var execCmd *exec.Cmd
-
-	// Check if we can optimize by running a single executable directly
-	if isSingleExecutableCommand(fullCmd) {
-		r.logger.Debug("Optimization: running single executable command directly: %s", fullCmd)
-		execCmd = exec.CommandContext(ctx, "firejail", "--profile="+profileFile.Name(), fullCmd)
-	} else {
-		// Create a temporary file for the command
-		tmpScript, err := os.CreateTemp("", "firejail-command-*.sh")
-		if err != nil {
-			r.logger.Debug("Failed to create temporary command file: %v", err)
-			return "", fmt.Errorf("failed to create temporary command file: %w", err)
-		}
-		// Ensure temporary file is deleted when this function exits
-		defer func() {
-			tmpScriptPath := tmpScript.Name()
-			if err := tmpScript.Close(); err != nil {
-				r.logger.Debug("Warning: failed to close script file: %v", err)
-			}
-			if err := os.Remove(tmpScriptPath); err != nil {
-				r.logger.Debug("Warning: failed to remove temporary script file: %v", err)
-			}
-		}()
-
-		// Write the command to the temporary file
-		if _, err := tmpScript.WriteString(fullCmd); err != nil {
-			r.logger.Debug("Failed to write command to temporary file: %v", err)
-			return "", fmt.Errorf("failed to write command to temporary file: %w", err)
-		}
-
-		// Flush data to ensure it's written to disk
-		if err := tmpScript.Sync(); err != nil {
-			r.logger.Debug("Failed to sync script file: %v", err)
-			return "", fmt.Errorf("failed to sync script file: %w", err)
-		}
-
-		// Make the temporary file executable
-		if err := os.Chmod(tmpScript.Name(), 0o700); err != nil {
-			r.logger.Debug("Failed to make temporary file executable: %v", err)
-			return "", fmt.Errorf("failed to make temporary file executable: %w", err)
-		}
-
-		execCmd = exec.CommandContext(ctx, "firejail", "--profile="+profileFile.Name(), tmpScript.Name())
+	cmdArgs, cleanup, err := r.commandArgs(shell, fullCmd, tmpfile)
+	defer cleanup()
+	if err != nil {
+		return nil, err
 	}
 
+	execCmd := exec.CommandContext(ctx, "firejail", append([]string{"--profile=" + profileFile.Name()}, cmdArgs...)...)
+
 	// Check if context is done
 	select {
 	case <-ctx.Done():
-		return "", ctx.Err()
+		return nil, ctx.Err()
 	default:
 		// Continue execution
 	}
@@ -222,27 +201,53 @@ func (r *RunnerFirejail) Run(ctx context.Context,
 	// Run the command
 	r.logger.Debug("Executing command")
 
-	if err := execCmd.Run(); err != nil {
-		// If there's error output, include it in the error
-		if stderr.Len() > 0 {
-			errMsg := strings.TrimSpace(stderr.String())
-			r.logger.Debug("Command failed with stderr: %s", errMsg)
-			return "", errors.New(errMsg)
-		}
-		r.logger.Debug("Command failed with error: %v", err)
-		return "", err
+	runErr := execCmd.Run()
+	result, err := newRunResult(stdout.String(), stderr.String(), runErr, r.logger)
+	if err != nil {
+		return result, err
+	}
+
+	r.logger.Debug("Command executed successfully, output length: %d bytes", len(result.Stdout))
+	if result.Stderr != "" {
+		r.logger.Debug("Command generated stderr (but no error): %s", result.Stderr)
 	}
 
-	// Get the output
-	outputStr := strings.TrimSpace(stdout.String())
+	return result, nil
+}
 
-	r.logger.Debug("Command executed successfully, output length: %d bytes", len(outputStr))
-	if stderr.Len() > 0 {
-		r.logger.Debug("Command generated stderr (but no error): %s", strings.TrimSpace(stderr.String()))
+// renderProfile renders the firejail profile for running the command, with the
+// templates in the allowed folders and files replaced with the parameters
+func (r *RunnerFirejail) renderProfile(params map[string]interface{}) (string, error) {
+	options := r.options
+	if len(options.AllowReadFolders) > 0 {
+		options.AllowReadFolders = common.ProcessTemplateListFlexible(options.AllowReadFolders, params)
+	}
+	if len(options.AllowWriteFolders) > 0 {
+		options.AllowWriteFolders = common.ProcessTemplateListFlexible(options.AllowWriteFolders, params)
+	}
+	if len(options.AllowReadFiles) > 0 {
+		options.AllowReadFiles = common.ProcessTemplateListFlexible(options.AllowReadFiles, params)
+	}
+	if len(options.AllowWriteFiles) > 0 {
+		options.AllowWriteFiles = common.ProcessTemplateListFlexible(options.AllowWriteFiles, params)
 	}
 
-	// Return the stdout output
-	return outputStr, nil
+	var profileBuf bytes.Buffer
+	if err := r.profileTpl.Execute(&profileBuf, options); err != nil {
+		r.logger.Debug("Failed to render firejail profile template: %v", err)
+		return "", fmt.Errorf("failed to render firejail profile: %w", err)
+	}
+	return profileBuf.String(), nil
+}
+
+// commandArgs returns the command line to run inside the sandbox (see sandboxCommandArgs),
+// using the shell from the runner options or the one for the tool
+func (r *RunnerFirejail) commandArgs(shell string, command string, tmpfile bool) ([]string, func(), error) {
+	scriptShell := r.options.Shell
+	if scriptShell == "" {
+		scriptShell = shell
+	}
+	return sandboxCommandArgs(command, scriptShell, tmpfile, r.options.TempDir, "firejail-command-*.sh", r.options.ScriptWithShell, r.logger)
 }
 
 // CheckImplicitRequirements checks if the runner meets its implicit requirements
@@ -47,6 +47,20 @@ whitelist {{ . }}
 whitelist {{ . }}
 {{ end }}
 
+# Resource limits
+{{ if .RlimitCPU }}
+rlimit-cpu {{ .RlimitCPU }}
+{{ end }}
+{{ if .RlimitAS }}
+rlimit-as {{ .RlimitAS }}
+{{ end }}
+{{ if .RlimitNproc }}
+rlimit-nproc {{ .RlimitNproc }}
+{{ end }}
+{{ with .TimeoutClock }}
+timeout {{ . }}
+{{ end }}
+
 # Always apply basic security features
 seccomp
 caps.drop all
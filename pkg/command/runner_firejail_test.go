@@ -3,6 +3,7 @@ package command
 import (
 	"context"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
@@ -28,6 +29,48 @@ func TestNewRunnerFirejail(t *testing.T) {
 	}
 }
 
+func TestRunnerFirejailProfileLimits(t *testing.T) {
+	runner, err := NewRunnerFirejail(RunnerOptions{
+		"rlimit_cpu":   10,
+		"rlimit_as":    536870912,
+		"rlimit_nproc": 50,
+		"timeout":      "1m30s",
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create firejail runner: %v", err)
+	}
+
+	profile, err := runner.renderProfile(nil)
+	if err != nil {
+		t.Fatalf("renderProfile() error = %v", err)
+	}
+	for _, want := range []string{"rlimit-cpu 10", "rlimit-as 536870912", "rlimit-nproc 50", "timeout 00:01:30"} {
+		if !strings.Contains(profile, want) {
+			t.Errorf("Expected the profile to contain %q, got:\n%s", want, profile)
+		}
+	}
+
+	// without limits, none is included in the profile
+	runner, err = NewRunnerFirejail(RunnerOptions{}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create firejail runner: %v", err)
+	}
+	profile, err = runner.renderProfile(nil)
+	if err != nil {
+		t.Fatalf("renderProfile() error = %v", err)
+	}
+	if strings.Contains(profile, "rlimit-") || strings.Contains(profile, "timeout") {
+		t.Errorf("Expected no limits in the profile, got:\n%s", profile)
+	}
+
+	// invalid limits are rejected
+	for _, options := range []RunnerOptions{{"timeout": "soon"}, {"rlimit_cpu": -1}} {
+		if _, err := NewRunnerFirejail(options, nil); err == nil {
+			t.Errorf("Expected an error for the options %v", options)
+		}
+	}
+}
+
 func TestRunnerFirejailRun(t *testing.T) {
 	// Skip on non-Linux platforms
 	if runtime.GOOS != "linux" {
@@ -51,7 +94,7 @@ func TestRunnerFirejailRun(t *testing.T) {
 	ctx := context.Background()
 
 	// Test simple echo command
-	output, err := runner.Run(ctx, "/bin/sh", "echo hello world", nil, nil, false) // No need for tmpfile here
+	output, err := stdoutOf(runner.Run(ctx, "/bin/sh", "echo hello world", nil, nil, false)) // No need for tmpfile here
 	if err != nil {
 		t.Fatalf("Failed to run command: %v", err)
 	}
@@ -115,7 +158,7 @@ func TestRunnerFirejail_Optimization_SingleExecutable(t *testing.T) {
 		t.Fatalf("Failed to create firejail runner: %v", err)
 	}
 	// Should succeed: /bin/ls is a single executable
-	output, err := runner.Run(context.Background(), "", "/bin/ls", nil, nil, false)
+	output, err := stdoutOf(runner.Run(context.Background(), "", "/bin/ls", nil, nil, false))
 	if err != nil {
 		t.Errorf("Expected /bin/ls to run without error, got: %v", err)
 	}
@@ -128,3 +171,64 @@ func TestRunnerFirejail_Optimization_SingleExecutable(t *testing.T) {
 		t.Logf("Expected failure for /bin/ls -l as a single executable: %v", err2)
 	}
 }
+
+func TestRunnerFirejail_ScriptWithShell(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Skipping firejail tests on non-Linux platform")
+	}
+	if _, err := os.Stat("/usr/bin/firejail"); os.IsNotExist(err) {
+		t.Skip("Skipping test because firejail is not installed")
+	}
+	runner, err := NewRunnerFirejail(RunnerOptions{"allow_networking": true, "script_with_shell": true}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create firejail runner: %v", err)
+	}
+	output, err := stdoutOf(runner.Run(context.Background(), "/bin/sh", "echo hello | tr a-z A-Z", nil, nil, true))
+	if err != nil {
+		t.Fatalf("Failed to run command with the shell: %v", err)
+	}
+	if strings.TrimSpace(output) != "HELLO" {
+		t.Errorf("Expected 'HELLO', got '%s'", output)
+	}
+}
+
+func TestRunnerFirejail_TempDir(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Skipping firejail tests on non-Linux platform")
+	}
+	tempDir := t.TempDir()
+	runner, err := NewRunnerFirejail(RunnerOptions{"temp_dir": tempDir}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create firejail runner: %v", err)
+	}
+
+	// With tmpfile, the script is created in the configured temporary directory
+	args, cleanup, err := runner.commandArgs("/bin/sh", "echo hello | tr a-z A-Z", true)
+	if err != nil {
+		t.Fatalf("Failed to prepare the command: %v", err)
+	}
+	scriptPath := args[len(args)-1]
+	if filepath.Dir(scriptPath) != tempDir {
+		t.Errorf("Expected the script to be created in %s, got %s", tempDir, scriptPath)
+	}
+	if _, err := os.Stat(scriptPath); err != nil {
+		t.Errorf("Expected the script to exist: %v", err)
+	}
+	cleanup()
+	if _, err := os.Stat(scriptPath); !os.IsNotExist(err) {
+		t.Errorf("Expected the script to be removed after the cleanup")
+	}
+
+	// Without tmpfile, the command is passed to the shell
+	args, cleanup, err = runner.commandArgs("/bin/sh", "echo hello | tr a-z A-Z", false)
+	if err != nil {
+		t.Fatalf("Failed to prepare the command: %v", err)
+	}
+	defer cleanup()
+	if strings.Join(args, " ") != "/bin/sh -c echo hello | tr a-z A-Z" {
+		t.Errorf("Expected the command to be passed to the shell, got %v", args)
+	}
+	if entries, _ := os.ReadDir(tempDir); len(entries) != 0 {
+		t.Errorf("Expected no temporary files without tmpfile, got %d", len(entries))
+	}
+}
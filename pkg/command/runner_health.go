@@ -0,0 +1,74 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/inercia/MCPShell/pkg/config"
+)
+
+// healthCheckTimeout is the maximum duration of the health check of a runner
+const healthCheckTimeout = 10 * time.Second
+
+// runHealthCheck runs the health check command of a runner with the given shell,
+// returning an error when the runner is not healthy (a variable, so tests can stub it)
+var runHealthCheck = func(ctx context.Context, shell string, command string) error {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	shellPath, args := getShellCommandArgs(getShell(shell), command)
+	checkCmd := exec.CommandContext(ctx, shellPath, args...)
+
+	var output bytes.Buffer
+	checkCmd.Stdout = &output
+	checkCmd.Stderr = &output
+	if err := checkCmd.Run(); err != nil {
+		if msg := strings.TrimSpace(output.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// selectRunner returns the runner to use for a call of the tool: the runner selected
+// for the tool when it is healthy, or the first healthy runner among the fallbacks
+// otherwise. Runners without a health check are always healthy.
+func (h *CommandHandler) selectRunner(ctx context.Context) (string, RunnerOptions, error) {
+	if h.runnerHealthCheck == "" {
+		return h.runnerType, h.runnerOpts, nil
+	}
+
+	err := runHealthCheck(ctx, h.shell, h.runnerHealthCheck)
+	if err == nil {
+		return h.runnerType, h.runnerOpts, nil
+	}
+	h.logger.Info("Runner '%s' of tool '%s' is not healthy: %v", h.runnerType, h.toolName, err)
+
+	for _, fallback := range h.fallbackRunners {
+		if check := fallback.Requirements.HealthCheck; check != "" {
+			if err := runHealthCheck(ctx, h.shell, check); err != nil {
+				h.logger.Info("Runner '%s' of tool '%s' is not healthy: %v", fallback.Name, h.toolName, err)
+				continue
+			}
+		}
+
+		h.logger.Info("Falling back to runner '%s' for tool '%s'", fallback.Name, h.toolName)
+		return fallback.Name, fallbackOptions(fallback), nil
+	}
+
+	return "", nil, newKindError(ErrRequirementNotMet, "no healthy runner for tool '%s' (runner '%s' is not healthy: %v)", h.toolName, h.runnerType, err)
+}
+
+// fallbackOptions returns the options of a fallback runner as RunnerOptions
+func fallbackOptions(runner config.MCPToolRunner) RunnerOptions {
+	options := RunnerOptions{}
+	for k, v := range runner.Options {
+		options[k] = v
+	}
+	return options
+}
@@ -0,0 +1,98 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/inercia/MCPShell/pkg/common"
+	"github.com/inercia/MCPShell/pkg/config"
+)
+
+func TestSelectRunner_HealthCheckFallback(t *testing.T) {
+	// stub the health checks: only the commands listed as healthy succeed
+	healthy := map[string]bool{}
+	var checked []string
+	oldRunHealthCheck := runHealthCheck
+	runHealthCheck = func(ctx context.Context, shell string, command string) error {
+		checked = append(checked, command)
+		if !healthy[command] {
+			return errors.New("overloaded")
+		}
+		return nil
+	}
+	defer func() { runHealthCheck = oldRunHealthCheck }()
+
+	newHandler := func(t *testing.T) *CommandHandler {
+		tool := config.Tool{
+			MCPTool: mcp.Tool{Name: "healthy-tool"},
+			Config: config.MCPToolConfig{
+				// the runner used is checked by the constraints
+				Constraints: []string{"runner == 'exec'"},
+				Run: config.MCPToolRunConfig{
+					Command: "echo 'ran'",
+					Runners: []config.MCPToolRunner{
+						{Name: "docker", Requirements: config.MCPToolRequirements{HealthCheck: "check-docker"}, Options: map[string]interface{}{"image": "alpine"}},
+						{Name: "exec", Requirements: config.MCPToolRequirements{HealthCheck: "check-exec"}},
+					},
+				},
+			},
+		}
+		tool.SelectedRunner = &tool.Config.Run.Runners[0]
+
+		handler, err := NewCommandHandler(tool, map[string]common.ParamConfig{}, "", testLogger)
+		if err != nil {
+			t.Fatalf("Failed to create handler: %v", err)
+		}
+		return handler
+	}
+
+	t.Run("Healthy runner is used", func(t *testing.T) {
+		healthy["check-docker"], healthy["check-exec"], checked = true, true, nil
+		runner, options, err := newHandler(t).selectRunner(context.Background())
+		if err != nil {
+			t.Fatalf("selectRunner() error = %v", err)
+		}
+		if runner != "docker" || options["image"] != "alpine" {
+			t.Errorf("selectRunner() = %q %v, want the docker runner", runner, options)
+		}
+		if len(checked) != 1 {
+			t.Errorf("Expected only the selected runner to be checked, checked %v", checked)
+		}
+	})
+
+	t.Run("Unhealthy runner falls back to the next one", func(t *testing.T) {
+		healthy["check-docker"], healthy["check-exec"], checked = false, true, nil
+		output, err := newHandler(t).ExecuteCommand(map[string]interface{}{})
+		if err != nil {
+			t.Fatalf("ExecuteCommand() error = %v", err)
+		}
+		if output != "ran" {
+			t.Errorf("ExecuteCommand() = %q, want %q", output, "ran")
+		}
+		if strings.Join(checked, ",") != "check-docker,check-exec" {
+			t.Errorf("Expected both runners to be checked, checked %v", checked)
+		}
+	})
+
+	t.Run("No healthy runner", func(t *testing.T) {
+		healthy["check-docker"], healthy["check-exec"], checked = false, false, nil
+		_, err := newHandler(t).ExecuteCommand(map[string]interface{}{})
+		if !errors.Is(err, ErrRequirementNotMet) || !strings.Contains(err.Error(), "no healthy runner") {
+			t.Errorf("ExecuteCommand() error = %v, want a 'no healthy runner' error", err)
+		}
+	})
+}
+
+func TestRunHealthCheck(t *testing.T) {
+	if err := runHealthCheck(context.Background(), "", "exit 0"); err != nil {
+		t.Errorf("runHealthCheck() with a successful command error = %v", err)
+	}
+	err := runHealthCheck(context.Background(), "", "echo 'memory pressure' >&2; exit 1")
+	if err == nil || !strings.Contains(err.Error(), "memory pressure") {
+		t.Errorf("runHealthCheck() with a failing command error = %v, want its output in the error", err)
+	}
+}
@@ -0,0 +1,138 @@
+package command
+
+import (
+	"sort"
+
+	"github.com/inercia/MCPShell/pkg/common"
+)
+
+// runnerRegistration describes a registered type of runner
+type runnerRegistration struct {
+	// optionsStruct is the (empty) options struct of the runner, whose json tags
+	// are the options accepted by the runner
+	optionsStruct interface{}
+
+	// parseOptions parses and checks the options of the runner, returning its options struct
+	parseOptions func(options RunnerOptions) (interface{}, error)
+
+	// newRunner creates a runner with the given options
+	newRunner func(options RunnerOptions, logger *common.Logger) (Runner, error)
+
+	// newBare creates a runner without options, only used for checking its
+	// implicit requirements
+	newBare func(logger *common.Logger) Runner
+
+	// remote is true when the commands are not run in this host (but in a container
+	// or in a remote host), where the commands of this host may not be available
+	remote bool
+}
+
+// registeredRunners holds all the types of runners known
+var registeredRunners = map[RunnerType]runnerRegistration{
+	RunnerTypeExec: {
+		optionsStruct: RunnerExecOptions{},
+		parseOptions:  func(options RunnerOptions) (interface{}, error) { return NewRunnerExecOptions(options) },
+		newRunner: func(options RunnerOptions, logger *common.Logger) (Runner, error) {
+			return NewRunnerExec(options, logger)
+		},
+		newBare: func(logger *common.Logger) Runner { return &RunnerExec{logger: logger} },
+	},
+	RunnerTypeSandboxExec: {
+		optionsStruct: RunnerSandboxExecOptions{},
+		parseOptions:  func(options RunnerOptions) (interface{}, error) { return NewRunnerSandboxExecOptions(options) },
+		newRunner: func(options RunnerOptions, logger *common.Logger) (Runner, error) {
+			return NewRunnerSandboxExec(options, logger)
+		},
+		newBare: func(logger *common.Logger) Runner { return &RunnerSandboxExec{logger: logger} },
+	},
+	RunnerTypeFirejail: {
+		optionsStruct: RunnerFirejailOptions{},
+		parseOptions:  func(options RunnerOptions) (interface{}, error) { return NewRunnerFirejailOptions(options) },
+		newRunner: func(options RunnerOptions, logger *common.Logger) (Runner, error) {
+			return NewRunnerFirejail(options, logger)
+		},
+		newBare: func(logger *common.Logger) Runner { return &RunnerFirejail{logger: logger} },
+	},
+	RunnerTypeNsjail: {
+		optionsStruct: RunnerNsjailOptions{},
+		parseOptions:  func(options RunnerOptions) (interface{}, error) { return NewRunnerNsjailOptions(options) },
+		newRunner: func(options RunnerOptions, logger *common.Logger) (Runner, error) {
+			return NewRunnerNsjail(options, logger)
+		},
+		newBare: func(logger *common.Logger) Runner { return &RunnerNsjail{logger: logger} },
+	},
+	RunnerTypeBubblewrap: {
+		optionsStruct: RunnerBubblewrapOptions{},
+		parseOptions:  func(options RunnerOptions) (interface{}, error) { return NewRunnerBubblewrapOptions(options) },
+		newRunner: func(options RunnerOptions, logger *common.Logger) (Runner, error) {
+			return NewRunnerBubblewrap(options, logger)
+		},
+		newBare: func(logger *common.Logger) Runner { return &RunnerBubblewrap{logger: logger} },
+	},
+	RunnerTypeDocker: {
+		optionsStruct: DockerRunnerOptions{},
+		parseOptions:  func(options RunnerOptions) (interface{}, error) { return NewDockerRunnerOptions(options) },
+		newRunner: func(options RunnerOptions, logger *common.Logger) (Runner, error) {
+			return NewDockerRunner(options, logger)
+		},
+		newBare: func(logger *common.Logger) Runner {
+			return &DockerRunner{logger: logger, opts: DockerRunnerOptions{DaemonCheckCache: defaultDaemonCheckCache}}
+		},
+		remote: true,
+	},
+	RunnerTypeSSH: {
+		optionsStruct: RunnerSSHOptions{},
+		parseOptions:  func(options RunnerOptions) (interface{}, error) { return NewRunnerSSHOptions(options) },
+		newRunner: func(options RunnerOptions, logger *common.Logger) (Runner, error) {
+			return NewRunnerSSH(options, logger)
+		},
+		newBare: func(logger *common.Logger) Runner { return &RunnerSSH{logger: logger} },
+		remote:  true,
+	},
+}
+
+// RunnerInfo describes a type of runner and whether it can be used in this system
+type RunnerInfo struct {
+	// Type is the type of the runner
+	Type RunnerType `json:"type"`
+
+	// Options are the options accepted by the runner, sorted by name
+	Options []string `json:"options"`
+
+	// Available is true when the implicit requirements of the runner are met
+	// (like the OS or the executables it needs)
+	Available bool `json:"available"`
+
+	// Reason is why the runner is not available (empty when it is available)
+	Reason string `json:"reason,omitempty"`
+}
+
+// ListRunners returns the information about all the registered runners, sorted by type,
+// checking their implicit requirements (see Runner.CheckImplicitRequirements)
+func ListRunners(logger *common.Logger) []RunnerInfo {
+	if logger == nil {
+		logger = common.GetLogger()
+	}
+
+	runners := make([]RunnerInfo, 0, len(registeredRunners))
+	for runnerType, registration := range registeredRunners {
+		info := RunnerInfo{
+			Type:      runnerType,
+			Options:   knownOptionKeys(registration.optionsStruct),
+			Available: true,
+		}
+		sort.Strings(info.Options)
+
+		if err := registration.newBare(logger).CheckImplicitRequirements(); err != nil {
+			info.Available = false
+			info.Reason = err.Error()
+		}
+
+		runners = append(runners, info)
+	}
+
+	sort.Slice(runners, func(i, j int) bool {
+		return runners[i].Type < runners[j].Type
+	})
+	return runners
+}
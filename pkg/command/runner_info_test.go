@@ -0,0 +1,59 @@
+package command
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestListRunners(t *testing.T) {
+	runners := ListRunners(testLogger)
+
+	if len(runners) != len(registeredRunners) {
+		t.Fatalf("Expected %d runners, got %d", len(registeredRunners), len(runners))
+	}
+
+	var exec *RunnerInfo
+	for i := range runners {
+		if runners[i].Type == RunnerTypeExec {
+			exec = &runners[i]
+		}
+		if !runners[i].Available && runners[i].Reason == "" {
+			t.Errorf("Expected a reason for the unavailable runner %s", runners[i].Type)
+		}
+	}
+
+	if exec == nil {
+		t.Fatal("Expected the exec runner in the list")
+	}
+	if !exec.Available {
+		t.Errorf("Expected the exec runner to be available, got: %s", exec.Reason)
+	}
+	if !slices.Equal(exec.Options, []string{"shell", "temp_dir"}) {
+		t.Errorf("Expected the exec runner options [shell temp_dir], got %v", exec.Options)
+	}
+}
+
+func TestRegisteredRunners(t *testing.T) {
+	for runnerType, registration := range registeredRunners {
+		if registration.optionsStruct == nil || registration.parseOptions == nil ||
+			registration.newRunner == nil || registration.newBare == nil {
+			t.Errorf("Incomplete registration of the %s runner", runnerType)
+			continue
+		}
+
+		// the options are parsed into the options struct of the runner
+		if options, err := registration.parseOptions(RunnerOptions{}); err == nil {
+			if got, want := reflect.TypeOf(options), reflect.TypeOf(registration.optionsStruct); got != want {
+				t.Errorf("The %s runner parses its options into %v, want %v", runnerType, got, want)
+			}
+		}
+	}
+
+	if _, err := NewRunner("unknown", RunnerOptions{}, testLogger); err == nil {
+		t.Error("NewRunner() with an unknown runner type should fail")
+	}
+	if err := ValidateRunnerOptions("unknown", RunnerOptions{}, false); err == nil {
+		t.Error("ValidateRunnerOptions() with an unknown runner type should fail")
+	}
+}
@@ -0,0 +1,267 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/inercia/MCPShell/pkg/common"
+)
+
+//go:embed runner_nsjail_config.tpl
+var nsjailConfigTemplate string
+
+// RunnerNsjail implements the Runner interface using nsjail on Linux
+type RunnerNsjail struct {
+	logger    *common.Logger
+	configTpl *template.Template
+	options   RunnerNsjailOptions
+}
+
+// RunnerNsjailOptions is the options for the RunnerNsjail
+type RunnerNsjailOptions struct {
+	Shell             string   `json:"shell"`
+	AllowNetworking   bool     `json:"allow_networking"`
+	AllowReadFolders  []string `json:"allow_read_folders"`
+	AllowWriteFolders []string `json:"allow_write_folders"`
+	AllowReadFiles    []string `json:"allow_read_files"`
+	AllowWriteFiles   []string `json:"allow_write_files"`
+	TimeLimit         int      `json:"time_limit"`   // in seconds (0 for no limit)
+	MemoryLimit       int      `json:"memory_limit"` // of the address space, in MB (0 for no limit)
+	CustomConfig      string   `json:"custom_config"`
+	ScriptWithShell   bool     `json:"script_with_shell"`
+	TempDir           string   `json:"temp_dir"`
+}
+
+// nsjailConfigData is the data the nsjail configuration template is rendered with
+type nsjailConfigData struct {
+	RunnerNsjailOptions
+
+	// Script is the temporary script with the command (if any), mounted in the jail
+	Script string
+}
+
+// NewRunnerNsjailOptions creates a new RunnerNsjailOptions from a RunnerOptions
+func NewRunnerNsjailOptions(options RunnerOptions) (RunnerNsjailOptions, error) {
+	var reopts RunnerNsjailOptions
+	opts, err := options.ToJSON()
+	if err != nil {
+		return RunnerNsjailOptions{}, err
+	}
+	err = json.Unmarshal([]byte(opts), &reopts)
+	return reopts, err
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// NewRunnerNsjail creates a new RunnerNsjail with the provided logger
+// If logger is nil, a default logger is created
+func NewRunnerNsjail(options RunnerOptions, logger *common.Logger) (*RunnerNsjail, error) {
+	if logger == nil {
+		logger = common.GetLogger()
+	}
+
+	// Parse the nsjail configuration template
+	configTpl, err := template.New("nsjail-config").Parse(nsjailConfigTemplate)
+	if err != nil {
+		logger.Debug("Failed to parse nsjail configuration template: %v", err)
+		return nil, err
+	}
+
+	// Parse nsjail-specific options
+	nsjailOpts, err := NewRunnerNsjailOptions(options)
+	if err != nil {
+		logger.Debug("Failed to parse nsjail options: %v", err)
+		return nil, fmt.Errorf("failed to parse nsjail options: %w", err)
+	}
+	warnUnknownOptionKeys(RunnerTypeNsjail, options, RunnerNsjailOptions{}, logger)
+
+	return &RunnerNsjail{
+		logger:    logger,
+		configTpl: configTpl,
+		options:   nsjailOpts,
+	}, nil
+}
+
+// Run executes a command inside the nsjail sandbox and returns the output
+// It implements the Runner interface
+//
+// When tmpfile is true, the command is written to a temporary script (in the temp_dir
+// option, or the default temporary directory) that is mounted in the jail; otherwise
+// it is passed to the shell with -c.
+func (r *RunnerNsjail) Run(ctx context.Context,
+	shell string, command string,
+	env []string, params map[string]interface{}, tmpfile bool,
+) (*RunResult, error) {
+	// Check if context is done
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		// Continue execution
+	}
+
+	cmdArgs, cleanup, err := r.commandArgs(shell, command, tmpfile)
+	defer cleanup()
+	if err != nil {
+		return nil, err
+	}
+
+	// The script (if any) is the last argument of the command line
+	script := ""
+	if tmpfile && len(cmdArgs) > 0 && cmdArgs[len(cmdArgs)-1] != command {
+		script = cmdArgs[len(cmdArgs)-1]
+	}
+
+	config, err := r.renderConfig(params, script)
+	if err != nil {
+		return nil, err
+	}
+	r.logger.Debug("Nsjail options: %+v", r.options)
+	r.logger.Debug("Generated nsjail configuration: %s", config)
+
+	// Create a temporary file for the nsjail configuration
+	configFile, err := os.CreateTemp("", "nsjail-config-*.cfg")
+	if err != nil {
+		r.logger.Debug("Failed to create temporary configuration file: %v", err)
+		return nil, fmt.Errorf("failed to create temporary configuration file: %w", err)
+	}
+	defer func() {
+		configFilePath := configFile.Name()
+		if err := configFile.Close(); err != nil {
+			r.logger.Debug("Warning: failed to close configuration file: %v", err)
+		}
+		if err := os.Remove(configFilePath); err != nil {
+			r.logger.Debug("Warning: failed to remove temporary configuration file: %v", err)
+		}
+	}()
+
+	// Write the configuration to the temporary file
+	if _, err := configFile.WriteString(config); err != nil {
+		r.logger.Debug("Failed to write configuration to temporary file: %v", err)
+		return nil, fmt.Errorf("failed to write configuration to temporary file: %w", err)
+	}
+
+	// Flush data to ensure it's written to disk
+	if err := configFile.Sync(); err != nil {
+		r.logger.Debug("Failed to sync configuration file: %v", err)
+		return nil, fmt.Errorf("failed to sync configuration file: %w", err)
+	}
+
+	execCmd := exec.CommandContext(ctx, "nsjail", append([]string{"--config", configFile.Name(), "--"}, cmdArgs...)...)
+
+	// Check if context is done
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		// Continue execution
+	}
+
+	r.logger.Debug("Created command: %s", execCmd.String())
+
+	// Set environment variables if provided (the jail keeps the environment)
+	if len(env) > 0 {
+		r.logger.Debug("Adding %d environment variables to command", len(env))
+		for _, e := range env {
+			r.logger.Debug("... adding environment variable: %s", e)
+		}
+		execCmd.Env = append(os.Environ(), env...)
+	}
+
+	// Capture output
+	var stdout, stderr bytes.Buffer
+	execCmd.Stdout = &stdout
+	execCmd.Stderr = &stderr
+
+	// Run the command
+	r.logger.Debug("Executing command")
+
+	runErr := execCmd.Run()
+	result, err := newRunResult(stdout.String(), stderr.String(), runErr, r.logger)
+	if err != nil {
+		return result, err
+	}
+
+	r.logger.Debug("Command executed successfully, output length: %d bytes", len(result.Stdout))
+	if result.Stderr != "" {
+		r.logger.Debug("Command generated stderr (but no error): %s", result.Stderr)
+	}
+
+	return result, nil
+}
+
+// renderConfig renders the nsjail configuration for running the command, with the
+// templates in the allowed folders and files replaced with the parameters
+func (r *RunnerNsjail) renderConfig(params map[string]interface{}, script string) (string, error) {
+	data := nsjailConfigData{RunnerNsjailOptions: r.options, Script: script}
+	if len(data.AllowReadFolders) > 0 {
+		data.AllowReadFolders = common.ProcessTemplateListFlexible(data.AllowReadFolders, params)
+	}
+	if len(data.AllowWriteFolders) > 0 {
+		data.AllowWriteFolders = common.ProcessTemplateListFlexible(data.AllowWriteFolders, params)
+	}
+	if len(data.AllowReadFiles) > 0 {
+		data.AllowReadFiles = common.ProcessTemplateListFlexible(data.AllowReadFiles, params)
+	}
+	if len(data.AllowWriteFiles) > 0 {
+		data.AllowWriteFiles = common.ProcessTemplateListFlexible(data.AllowWriteFiles, params)
+	}
+
+	// The paths are rendered as strings in the configuration, so they cannot contain
+	// anything that would end the string (and add other mounts, for example)
+	for _, path := range slices.Concat([]string{script}, data.AllowReadFolders, data.AllowWriteFolders, data.AllowReadFiles, data.AllowWriteFiles) {
+		if strings.ContainsFunc(path, isUnsafeNsjailPathRune) {
+			r.logger.Error("Refusing to mount the path %q in the nsjail sandbox", path)
+			return "", fmt.Errorf("invalid path %q for the nsjail sandbox: paths cannot contain quotes, backslashes or control characters", path)
+		}
+	}
+
+	var configBuf bytes.Buffer
+	if err := r.configTpl.Execute(&configBuf, data); err != nil {
+		r.logger.Debug("Failed to render nsjail configuration template: %v", err)
+		return "", fmt.Errorf("failed to render nsjail configuration: %w", err)
+	}
+	return configBuf.String(), nil
+}
+
+// isUnsafeNsjailPathRune returns true for the characters that cannot be used in the paths
+// rendered in the nsjail configuration
+func isUnsafeNsjailPathRune(r rune) bool {
+	return r == '"' || r == '\\' || unicode.IsControl(r)
+}
+
+// commandArgs returns the command line to run inside the sandbox (see sandboxCommandArgs),
+// using the shell from the runner options or the one for the tool
+func (r *RunnerNsjail) commandArgs(shell string, command string, tmpfile bool) ([]string, func(), error) {
+	scriptShell := r.options.Shell
+	if scriptShell == "" {
+		scriptShell = shell
+	}
+	return sandboxCommandArgs(command, scriptShell, tmpfile, r.options.TempDir, "nsjail-command-*.sh", r.options.ScriptWithShell, r.logger)
+}
+
+// CheckImplicitRequirements checks if the runner meets its implicit requirements
+// Nsjail runner requires Linux and the nsjail executable
+func (r *RunnerNsjail) CheckImplicitRequirements() error {
+	// Nsjail is Linux only
+	if runtime.GOOS != "linux" {
+		return fmt.Errorf("nsjail runner requires Linux")
+	}
+
+	// Check if nsjail is available
+	if !common.CheckExecutableExists("nsjail") {
+		return fmt.Errorf("nsjail executable not found in PATH")
+	}
+
+	return nil
+}
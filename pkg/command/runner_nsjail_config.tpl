@@ -0,0 +1,66 @@
+{{ if .CustomConfig }}
+{{ .CustomConfig }}
+{{ else }}
+# Basic configuration for nsjail
+# Applied restrictions based on provided options
+
+name: "mcpshell"
+mode: ONCE
+hostname: "mcpshell"
+cwd: "/tmp"
+keep_env: true
+log_level: ERROR
+
+# Limits (0 means no limit)
+time_limit: {{ .TimeLimit }}
+{{ if .MemoryLimit }}
+rlimit_as_type: VALUE
+rlimit_as: {{ .MemoryLimit }}
+{{ end }}
+
+# Network restrictions
+{{ if .AllowNetworking }}
+# Allow networking
+clone_newnet: false
+{{ else }}
+# Disable networking
+clone_newnet: true
+{{ end }}
+
+# System folders (read-only)
+mount { src: "/bin" dst: "/bin" is_bind: true mandatory: false }
+mount { src: "/sbin" dst: "/sbin" is_bind: true mandatory: false }
+mount { src: "/lib" dst: "/lib" is_bind: true mandatory: false }
+mount { src: "/lib64" dst: "/lib64" is_bind: true mandatory: false }
+mount { src: "/usr" dst: "/usr" is_bind: true }
+mount { src: "/etc" dst: "/etc" is_bind: true }
+mount { src: "/dev/null" dst: "/dev/null" is_bind: true rw: true }
+mount { src: "/dev/urandom" dst: "/dev/urandom" is_bind: true }
+mount { dst: "/proc" fstype: "proc" }
+mount { dst: "/tmp" fstype: "tmpfs" rw: true }
+
+{{ if .Script }}
+# The script with the command
+mount { src: "{{ .Script }}" dst: "{{ .Script }}" is_bind: true }
+{{ end }}
+
+# Allow specific read folders
+{{ range .AllowReadFolders }}
+mount { src: "{{ . }}" dst: "{{ . }}" is_bind: true }
+{{ end }}
+
+# Allow specific read files
+{{ range .AllowReadFiles }}
+mount { src: "{{ . }}" dst: "{{ . }}" is_bind: true }
+{{ end }}
+
+# Allow specific write folders
+{{ range .AllowWriteFolders }}
+mount { src: "{{ . }}" dst: "{{ . }}" is_bind: true rw: true }
+{{ end }}
+
+# Allow specific write files
+{{ range .AllowWriteFiles }}
+mount { src: "{{ . }}" dst: "{{ . }}" is_bind: true rw: true }
+{{ end }}
+{{ end }}
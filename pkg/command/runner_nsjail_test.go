@@ -0,0 +1,120 @@
+package command
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/inercia/MCPShell/pkg/common"
+)
+
+func TestRunnerNsjailConfig(t *testing.T) {
+	runner, err := NewRunnerNsjail(RunnerOptions{
+		"allow_read_folders":  []interface{}{"/data/{{ .project }}"},
+		"allow_write_folders": []interface{}{"/output"},
+		"time_limit":          30,
+		"memory_limit":        512,
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create nsjail runner: %v", err)
+	}
+
+	config, err := runner.renderConfig(map[string]interface{}{"project": "demo"}, "/tmp/nsjail-command-1.sh")
+	if err != nil {
+		t.Fatalf("renderConfig() error = %v", err)
+	}
+
+	for _, want := range []string{
+		"mode: ONCE",
+		"time_limit: 30",
+		"rlimit_as: 512",
+		"clone_newnet: true",
+		`mount { src: "/data/demo" dst: "/data/demo" is_bind: true }`,
+		`mount { src: "/output" dst: "/output" is_bind: true rw: true }`,
+		`mount { src: "/tmp/nsjail-command-1.sh" dst: "/tmp/nsjail-command-1.sh" is_bind: true }`,
+	} {
+		if !strings.Contains(config, want) {
+			t.Errorf("Expected the configuration to contain %q, got:\n%s", want, config)
+		}
+	}
+
+	// the options are not modified by the templates of the parameters
+	if runner.options.AllowReadFolders[0] != "/data/{{ .project }}" {
+		t.Errorf("Expected the options to keep the templates, got %q", runner.options.AllowReadFolders)
+	}
+
+	// networking can be allowed, and the limits are optional
+	runner, err = NewRunnerNsjail(RunnerOptions{"allow_networking": true}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create nsjail runner: %v", err)
+	}
+	config, err = runner.renderConfig(nil, "")
+	if err != nil {
+		t.Fatalf("renderConfig() error = %v", err)
+	}
+	if !strings.Contains(config, "clone_newnet: false") || !strings.Contains(config, "time_limit: 0") ||
+		strings.Contains(config, "rlimit_as:") {
+		t.Errorf("Unexpected configuration:\n%s", config)
+	}
+
+	// a custom configuration replaces the generated one
+	runner, err = NewRunnerNsjail(RunnerOptions{"custom_config": "mode: ONCE\ntime_limit: 5"}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create nsjail runner: %v", err)
+	}
+	config, err = runner.renderConfig(nil, "")
+	if err != nil {
+		t.Fatalf("renderConfig() error = %v", err)
+	}
+	if strings.TrimSpace(config) != "mode: ONCE\ntime_limit: 5" {
+		t.Errorf("Expected the custom configuration, got:\n%s", config)
+	}
+}
+
+func TestRunnerNsjailConfigInjection(t *testing.T) {
+	runner, err := NewRunnerNsjail(RunnerOptions{
+		"allow_read_folders": []interface{}{"/data/{{ .project }}"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create nsjail runner: %v", err)
+	}
+
+	for _, project := range []string{
+		`x" dst: "/x" is_bind: true }` + "\n" + `mount { src: "/" dst: "/host" is_bind: true rw: true } #`,
+		"x\nmount { src: \"/\" }",
+		`x\" `,
+	} {
+		config, err := runner.renderConfig(map[string]interface{}{"project": project}, "")
+		if err == nil {
+			t.Errorf("renderConfig(%q) error = nil, want an invalid path error, got:\n%s", project, config)
+		}
+	}
+
+	// paths with other special characters are fine
+	if _, err := runner.renderConfig(map[string]interface{}{"project": "my project (v2)"}, ""); err != nil {
+		t.Errorf("renderConfig() error = %v", err)
+	}
+}
+
+func TestRunnerNsjailRun(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Skipping nsjail tests on non-Linux platform")
+	}
+	if !common.CheckExecutableExists("nsjail") {
+		t.Skip("Skipping test because nsjail is not installed")
+	}
+
+	runner, err := NewRunner(RunnerTypeNsjail, RunnerOptions{}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create nsjail runner: %v", err)
+	}
+
+	output, err := stdoutOf(runner.Run(context.Background(), "/bin/sh", "echo hello | tr a-z A-Z", []string{"GREETING=hi"}, nil, true))
+	if err != nil {
+		t.Fatalf("Failed to run command: %v", err)
+	}
+	if output != "HELLO" {
+		t.Errorf("Expected 'HELLO', got '%s'", output)
+	}
+}
@@ -5,11 +5,11 @@ import (
 	"context"
 	_ "embed"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
 	"text/template"
@@ -36,7 +36,23 @@ type RunnerSandboxExecOptions struct {
 	AllowWriteFolders []string `json:"allow_write_folders"`
 	AllowReadFiles    []string `json:"allow_read_files"`
 	AllowWriteFiles   []string `json:"allow_write_files"`
+	AllowExecFolders  []string `json:"allow_exec_folders"` // only executables in these folders can be run (any when empty)
 	CustomProfile     string   `json:"custom_profile"`
+	ScriptWithShell   bool     `json:"script_with_shell"`
+	TempDir           string   `json:"temp_dir"`
+}
+
+// sandboxProfileData is the data the sandbox profile template is rendered with
+type sandboxProfileData struct {
+	RunnerSandboxExecOptions
+
+	// ExecFolderRegexes are the regular expressions matching the executables in the
+	// folders of AllowExecFolders
+	ExecFolderRegexes []string
+
+	// ExecFiles are the executables started for running the command (like the shell),
+	// that are always allowed
+	ExecFiles []string
 }
 
 // NewRunnerSandboxExecOptions creates a new RunnerSandboxExecOptions from a RunnerOptions
@@ -72,6 +88,7 @@ func NewRunnerSandboxExec(options RunnerOptions, logger *common.Logger) (*Runner
 		logger.Debug("Failed to parse sandbox options: %v", err)
 		return nil, fmt.Errorf("failed to parse sandbox options: %w", err)
 	}
+	warnUnknownOptionKeys(RunnerTypeSandboxExec, options, RunnerSandboxExecOptions{}, logger)
 
 	return &RunnerSandboxExec{
 		logger:     logger,
@@ -83,14 +100,15 @@ func NewRunnerSandboxExec(options RunnerOptions, logger *common.Logger) (*Runner
 // Run executes a command inside the macOS sandbox and returns the output
 // It implements the Runner interface
 //
-// note: tmpfile is ignored for sandbox because it's not supported
-func (r *RunnerSandboxExec) Run(ctx context.Context, shell string, command string, env []string, params map[string]interface{}, tmpfile bool) (string, error) {
+// When tmpfile is true, the command is written to a temporary script (in the temp_dir
+// option, or the default temporary directory); otherwise it is passed to the shell with -c.
+func (r *RunnerSandboxExec) Run(ctx context.Context, shell string, command string, env []string, params map[string]interface{}, tmpfile bool) (*RunResult, error) {
 	fullCmd := command
 
 	// Check if context is done
 	select {
 	case <-ctx.Done():
-		return "", ctx.Err()
+		return nil, ctx.Err()
 	default:
 		// Continue execution
 	}
@@ -131,11 +149,17 @@ func (r *RunnerSandboxExec) Run(ctx context.Context, shell string, command strin
 		}
 	}
 
+	cmdArgs, cleanup, err := r.commandArgs(shell, fullCmd, tmpfile)
+	defer cleanup()
+	if err != nil {
+		return nil, err
+	}
+
 	// Generate the profile by rendering the template
 	var profileBuf bytes.Buffer
-	if err := r.profileTpl.Execute(&profileBuf, r.options); err != nil {
+	if err := r.profileTpl.Execute(&profileBuf, r.profileData(params, cmdArgs)); err != nil {
 		r.logger.Debug("Failed to render sandbox profile template: %v", err)
-		return "", fmt.Errorf("failed to render sandbox profile: %w", err)
+		return nil, fmt.Errorf("failed to render sandbox profile: %w", err)
 	}
 
 	profile := profileBuf.String()
@@ -146,7 +170,7 @@ func (r *RunnerSandboxExec) Run(ctx context.Context, shell string, command strin
 	profileFile, err := os.CreateTemp("", "sandbox-profile-*.sb")
 	if err != nil {
 		r.logger.Debug("Failed to create temporary profile file: %v", err)
-		return "", fmt.Errorf("failed to create temporary profile file: %w", err)
+		return nil, fmt.Errorf("failed to create temporary profile file: %w", err)
 	}
 	defer func() {
 		profileFilePath := profileFile.Name()
@@ -161,59 +185,16 @@ func (r *RunnerSandboxExec) Run(ctx context.Context, shell string, command strin
 	// Write the profile to the temporary file
 	if _, err := profileFile.WriteString(profile); err != nil {
 		r.logger.Debug("Failed to write profile to temporary file: %v", err)
-		return "", fmt.Errorf("failed to write profile to temporary file: %w", err)
+		return nil, fmt.Errorf("failed to write profile to temporary file: %w", err)
 	}
 
 	// Flush data to ensure it's written to disk
 	if err := profileFile.Sync(); err != nil {
 		r.logger.Debug("Failed to sync profile file: %v", err)
-		return "", fmt.Errorf("failed to sync profile file: %w", err)
+		return nil, fmt.Errorf("failed to sync profile file: %w", err)
 	}
 
-	var execCmd *exec.Cmd
-
-	// Check if we can optimize by running a single executable directly
-	if isSingleExecutableCommand(fullCmd) {
-		r.logger.Debug("Optimization: running single executable command directly: %s", fullCmd)
-		execCmd = exec.CommandContext(ctx, "sandbox-exec", "-f", profileFile.Name(), fullCmd)
-	} else {
-		// Create a temporary file for the command
-		tmpScript, err := os.CreateTemp("", "sandbox-script-*.sh")
-		if err != nil {
-			r.logger.Debug("Failed to create temporary command file: %v", err)
-			return "", fmt.Errorf("failed to create temporary command file: %w", err)
-		}
-		// Ensure temporary file is deleted when this function exits
-		defer func() {
-			tmpScriptPath := tmpScript.Name()
-			if err := tmpScript.Close(); err != nil {
-				r.logger.Debug("Warning: failed to close script file: %v", err)
-			}
-			if err := os.Remove(tmpScriptPath); err != nil {
-				r.logger.Debug("Warning: failed to remove temporary script file: %v", err)
-			}
-		}()
-
-		// Write the command to the temporary file
-		if _, err := tmpScript.WriteString(fullCmd); err != nil {
-			r.logger.Debug("Failed to write command to temporary file: %v", err)
-			return "", fmt.Errorf("failed to write command to temporary file: %w", err)
-		}
-
-		// Flush data to ensure it's written to disk
-		if err := tmpScript.Sync(); err != nil {
-			r.logger.Debug("Failed to sync script file: %v", err)
-			return "", fmt.Errorf("failed to sync script file: %w", err)
-		}
-
-		// Make the temporary file executable
-		if err := os.Chmod(tmpScript.Name(), 0o700); err != nil {
-			r.logger.Debug("Failed to make temporary file executable: %v", err)
-			return "", fmt.Errorf("failed to make temporary file executable: %w", err)
-		}
-
-		execCmd = exec.CommandContext(ctx, "sandbox-exec", "-f", profileFile.Name(), tmpScript.Name())
-	}
+	execCmd := exec.CommandContext(ctx, "sandbox-exec", append([]string{"-f", profileFile.Name()}, cmdArgs...)...)
 
 	r.logger.Debug("Created command: %s", execCmd.String())
 
@@ -234,27 +215,56 @@ func (r *RunnerSandboxExec) Run(ctx context.Context, shell string, command strin
 	// Run the command
 	r.logger.Debug("Executing command")
 
-	if err := execCmd.Run(); err != nil {
-		// If there's error output, include it in the error
-		if stderr.Len() > 0 {
-			errMsg := strings.TrimSpace(stderr.String())
-			r.logger.Debug("Command failed with stderr: %s", errMsg)
-			return "", errors.New(errMsg)
-		}
-		r.logger.Debug("Command failed with error: %v", err)
-		return "", err
+	runErr := execCmd.Run()
+	result, err := newRunResult(stdout.String(), stderr.String(), runErr, r.logger)
+	if err != nil {
+		return result, err
+	}
+
+	r.logger.Debug("Command executed successfully, output length: %d bytes", len(result.Stdout))
+	if result.Stderr != "" {
+		r.logger.Debug("Command generated stderr (but no error): %s", result.Stderr)
+	}
+
+	return result, nil
+}
+
+// profileData returns the data for rendering the sandbox profile for running the command
+// line, with the templates in the folders executables can be run from replaced with the
+// parameters. When these folders are restricted, the executable starting the command
+// (like the shell, or the temporary script) is always allowed.
+func (r *RunnerSandboxExec) profileData(params map[string]interface{}, cmdArgs []string) sandboxProfileData {
+	data := sandboxProfileData{RunnerSandboxExecOptions: r.options}
+	if len(r.options.AllowExecFolders) == 0 {
+		return data
 	}
 
-	// Get the output
-	outputStr := strings.TrimSpace(stdout.String())
+	for _, folder := range common.ProcessTemplateListFlexible(r.options.AllowExecFolders, params) {
+		data.ExecFolderRegexes = append(data.ExecFolderRegexes, "^"+regexp.QuoteMeta(strings.TrimSuffix(folder, "/"))+"/")
+	}
 
-	r.logger.Debug("Command executed successfully, output length: %d bytes", len(outputStr))
-	if stderr.Len() > 0 {
-		r.logger.Debug("Command generated stderr (but no error): %s", strings.TrimSpace(stderr.String()))
+	if len(cmdArgs) > 0 {
+		executable := cmdArgs[0]
+		if path, err := exec.LookPath(executable); err == nil {
+			executable = path
+		}
+		data.ExecFiles = append(data.ExecFiles, executable)
+		if resolved, err := filepath.EvalSymlinks(executable); err == nil && resolved != executable {
+			data.ExecFiles = append(data.ExecFiles, resolved)
+		}
 	}
 
-	// Return the stdout output
-	return outputStr, nil
+	return data
+}
+
+// commandArgs returns the command line to run inside the sandbox (see sandboxCommandArgs),
+// using the shell from the runner options or the one for the tool
+func (r *RunnerSandboxExec) commandArgs(shell string, command string, tmpfile bool) ([]string, func(), error) {
+	scriptShell := r.options.Shell
+	if scriptShell == "" {
+		scriptShell = shell
+	}
+	return sandboxCommandArgs(command, scriptShell, tmpfile, r.options.TempDir, "sandbox-script-*.sh", r.options.ScriptWithShell, r.logger)
 }
 
 // CheckImplicitRequirements checks if the runner meets its implicit requirements
@@ -48,4 +48,15 @@
 (allow file-write* (literal "{{ . }}"))
 {{ end }}
 
+{{ if .ExecFolderRegexes }}
+;; Only allow running the executables in the allowed folders
+(deny process-exec*)
+{{ range .ExecFiles }}
+(allow process-exec* (literal "{{ . }}"))
+{{ end }}
+{{ range .ExecFolderRegexes }}
+(allow process-exec* (regex #"{{ . }}"))
+{{ end }}
+{{ end }}
+
 {{ end }}
\ No newline at end of file
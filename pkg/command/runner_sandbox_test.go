@@ -176,20 +176,25 @@ func TestRunnerSandboxExec_Run(t *testing.T) {
 			shouldSucceed: true,
 			expectedOut:   "success",
 		},
-		// 		{
-		// 			name:    "custom profile blocking all except echo",
-		// 			command: "echo 'only echo works'",
-		// 			args:    []string{},
-		// 			options: RunnerOptions{
-		// 				"custom_profile": `(version 1)
-		// (allow default)
-		// (deny process-exec*)
-		// (allow process-exec* (regex "^/bin/echo"))
-		// (allow process-exec* (regex "^/usr/bin/echo"))`,
-		// 			},
-		// 			shouldSucceed: true,
-		// 			expectedOut:   "only echo works",
-		// 		},
+		{
+			name:    "run executables in allowed folders",
+			command: "/bin/ls / > /dev/null && echo 'can exec'",
+			args:    []string{},
+			options: RunnerOptions{
+				"allow_exec_folders": []string{"/bin", "/usr/bin"},
+			},
+			shouldSucceed: true,
+			expectedOut:   "can exec",
+		},
+		{
+			name:    "run executables outside the allowed folders",
+			command: "/usr/sbin/sysctl -n hw.ncpu",
+			args:    []string{},
+			options: RunnerOptions{
+				"allow_exec_folders": []string{"/bin", "/usr/bin"},
+			},
+			shouldSucceed: false,
+		},
 		// New test cases for allow_read_folders
 		{
 			name:    "read from allowed folder using env variable",
@@ -321,7 +326,7 @@ func TestRunnerSandboxExec_Run(t *testing.T) {
 				t.Fatalf("Failed to create runner: %v", err)
 			}
 
-			output, err := runner.Run(ctx, shell, tt.command, []string{}, params, false) // No need for tmpfile here
+			output, err := stdoutOf(runner.Run(ctx, shell, tt.command, []string{}, params, false)) // No need for tmpfile here
 
 			// Check if success/failure matches expectations
 			if tt.shouldSucceed && err != nil {
@@ -342,6 +347,45 @@ func TestRunnerSandboxExec_Run(t *testing.T) {
 	}
 }
 
+func TestRunnerSandboxExec_AllowExecFolders(t *testing.T) {
+	runner, err := NewRunnerSandboxExec(RunnerOptions{
+		"allow_exec_folders": []interface{}{"/usr/bin/", "{{ .tools_dir }}"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create RunnerSandboxExec: %v", err)
+	}
+
+	var profile strings.Builder
+	data := runner.profileData(map[string]interface{}{"tools_dir": "/opt/my.tools"}, []string{"/bin/sh", "-c", "ls"})
+	if err := runner.profileTpl.Execute(&profile, data); err != nil {
+		t.Fatalf("Failed to render the profile: %v", err)
+	}
+
+	for _, want := range []string{
+		"(deny process-exec*)",
+		`(allow process-exec* (literal "/bin/sh"))`,
+		`(allow process-exec* (regex #"^/usr/bin/"))`,
+		`(allow process-exec* (regex #"^/opt/my\.tools/"))`,
+	} {
+		if !strings.Contains(profile.String(), want) {
+			t.Errorf("Expected the profile to contain %q, got:\n%s", want, profile.String())
+		}
+	}
+
+	// without allowed folders, any executable can be run
+	runner, err = NewRunnerSandboxExec(RunnerOptions{}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create RunnerSandboxExec: %v", err)
+	}
+	profile.Reset()
+	if err := runner.profileTpl.Execute(&profile, runner.profileData(nil, []string{"/bin/sh", "-c", "ls"})); err != nil {
+		t.Fatalf("Failed to render the profile: %v", err)
+	}
+	if strings.Contains(profile.String(), "process-exec") {
+		t.Errorf("Expected no exec rules in the profile, got:\n%s", profile.String())
+	}
+}
+
 func TestRunnerSandboxExec_Optimization_SingleExecutable(t *testing.T) {
 	if runtime.GOOS != "darwin" {
 		t.Skip("Skipping test on non-macOS platform")
@@ -352,7 +396,7 @@ func TestRunnerSandboxExec_Optimization_SingleExecutable(t *testing.T) {
 		t.Fatalf("Failed to create RunnerSandboxExec: %v", err)
 	}
 	// Should succeed: /bin/ls is a single executable
-	output, err := runner.Run(context.Background(), "", "/bin/ls", nil, nil, false)
+	output, err := stdoutOf(runner.Run(context.Background(), "", "/bin/ls", nil, nil, false))
 	if err != nil {
 		t.Errorf("Expected /bin/ls to run without error, got: %v", err)
 	}
@@ -0,0 +1,175 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/inercia/MCPShell/pkg/common"
+)
+
+// RunnerSSH implements the Runner interface running the commands in a remote host with ssh
+type RunnerSSH struct {
+	logger  *common.Logger
+	options RunnerSSHOptions
+}
+
+// defaultRemoteShell is the shell that runs the scripts in the remote host
+const defaultRemoteShell = "sh"
+
+// RunnerSSHOptions is the options for the RunnerSSH
+type RunnerSSHOptions struct {
+	// Host is the remote host the commands are run in (required)
+	Host string `json:"host"`
+
+	// User is the user for logging in the remote host (the ssh default when empty)
+	User string `json:"user"`
+
+	// Port is the port of the ssh server (the ssh default when 0)
+	Port int `json:"port"`
+
+	// KeyFile is the private key used for authenticating (the ssh default keys when empty)
+	KeyFile string `json:"key_file"`
+
+	// KnownHosts is the known hosts file the host key is checked against. When set,
+	// unknown or changed host keys are rejected
+	KnownHosts string `json:"known_hosts"`
+}
+
+// NewRunnerSSHOptions creates a new RunnerSSHOptions from a RunnerOptions
+func NewRunnerSSHOptions(options RunnerOptions) (RunnerSSHOptions, error) {
+	var sshOpts RunnerSSHOptions
+	opts, err := options.ToJSON()
+	if err != nil {
+		return RunnerSSHOptions{}, err
+	}
+	if err := json.Unmarshal([]byte(opts), &sshOpts); err != nil {
+		return RunnerSSHOptions{}, err
+	}
+	if sshOpts.Host == "" {
+		return sshOpts, fmt.Errorf("ssh runner requires 'host' option")
+	}
+	return sshOpts, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// NewRunnerSSH creates a new RunnerSSH with the provided options
+// If logger is nil, a default logger is created
+func NewRunnerSSH(options RunnerOptions, logger *common.Logger) (*RunnerSSH, error) {
+	if logger == nil {
+		logger = common.GetLogger()
+	}
+
+	sshOpts, err := NewRunnerSSHOptions(options)
+	if err != nil {
+		logger.Debug("Failed to parse ssh options: %v", err)
+		return nil, err
+	}
+	warnUnknownOptionKeys(RunnerTypeSSH, options, RunnerSSHOptions{}, logger)
+
+	return &RunnerSSH{
+		logger:  logger,
+		options: sshOpts,
+	}, nil
+}
+
+// Run executes a command in the remote host and returns the output
+// It implements the Runner interface
+//
+// The command is turned into a script (with the environment variables exported) that is
+// passed, quoted, as the argument of "-c" of the shell of the remote host (the tool shell,
+// or sh), so it is interpreted by the same shell whatever the login shell of the user in
+// the remote host. The standard input of the command is empty, so commands reading it
+// (like cat or read) do not wait for input.
+func (r *RunnerSSH) Run(ctx context.Context,
+	shell string, command string,
+	env []string, params map[string]interface{}, tmpfile bool,
+) (*RunResult, error) {
+	// Check if context is done
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		// Continue execution
+	}
+
+	// The local shell could not exist in the remote host: use sh unless the tool sets one
+	remoteShell := shell
+	if remoteShell == "" {
+		remoteShell = defaultRemoteShell
+	}
+
+	remoteCommand := remoteShell + " -c " + shellQuote(remoteScript(command, env))
+	execCmd := exec.CommandContext(ctx, "ssh", r.options.sshArgs(remoteCommand)...)
+	r.logger.Debug("Created command: %s", execCmd.String())
+
+	// Capture output
+	var stdout, stderr bytes.Buffer
+	execCmd.Stdout = &stdout
+	execCmd.Stderr = &stderr
+
+	// Run the command
+	r.logger.Debug("Executing command in %s", r.options.destination())
+
+	runErr := execCmd.Run()
+	result, err := newRunResult(stdout.String(), stderr.String(), runErr, r.logger)
+	if err != nil {
+		return result, fmt.Errorf("ssh command execution failed: %w", err)
+	}
+
+	r.logger.Debug("Command executed successfully, output length: %d bytes", len(result.Stdout))
+	return result, nil
+}
+
+// destination returns the destination of the ssh connection (user@host, or the host)
+func (o *RunnerSSHOptions) destination() string {
+	if o.User != "" {
+		return o.User + "@" + o.Host
+	}
+	return o.Host
+}
+
+// sshArgs returns the arguments of ssh for running the remote command. ssh is run in
+// batch mode, so it fails instead of asking for passwords or confirmations, with its
+// standard input redirected from /dev/null (-n), and the destination comes after "--",
+// so it is never taken as an option of ssh.
+func (o *RunnerSSHOptions) sshArgs(remoteCommand string) []string {
+	args := []string{"-n", "-o", "BatchMode=yes"}
+	if o.Port != 0 {
+		args = append(args, "-p", strconv.Itoa(o.Port))
+	}
+	if o.KeyFile != "" {
+		args = append(args, "-i", o.KeyFile, "-o", "IdentitiesOnly=yes")
+	}
+	if o.KnownHosts != "" {
+		args = append(args, "-o", "UserKnownHostsFile="+o.KnownHosts, "-o", "StrictHostKeyChecking=yes")
+	}
+	return append(args, "--", o.destination(), remoteCommand)
+}
+
+// remoteScript returns the script run in the remote host: the command, after
+// exporting the environment variables
+func remoteScript(command string, env []string) string {
+	var script strings.Builder
+	for _, e := range env {
+		if name, value, ok := strings.Cut(e, "="); ok {
+			fmt.Fprintf(&script, "export %s=%s\n", name, shellQuote(value))
+		}
+	}
+	script.WriteString(command)
+	return script.String()
+}
+
+// CheckImplicitRequirements checks if the runner meets its implicit requirements
+// SSH runner requires the ssh executable
+func (r *RunnerSSH) CheckImplicitRequirements() error {
+	if !common.CheckExecutableExists("ssh") {
+		return fmt.Errorf("ssh executable not found in PATH")
+	}
+	return nil
+}
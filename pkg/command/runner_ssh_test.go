@@ -0,0 +1,106 @@
+package command
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestNewRunnerSSHOptions(t *testing.T) {
+	if _, err := NewRunnerSSHOptions(RunnerOptions{"user": "deploy"}); err == nil || !strings.Contains(err.Error(), "'host'") {
+		t.Errorf("Expected an error for the missing host, got %v", err)
+	}
+
+	opts, err := NewRunnerSSHOptions(RunnerOptions{
+		"host":        "build.example.com",
+		"user":        "deploy",
+		"port":        2222.0,
+		"key_file":    "/keys/id_ed25519",
+		"known_hosts": "/keys/known_hosts",
+	})
+	if err != nil {
+		t.Fatalf("NewRunnerSSHOptions() error = %v", err)
+	}
+
+	want := []string{
+		"-n", "-o", "BatchMode=yes",
+		"-p", "2222",
+		"-i", "/keys/id_ed25519", "-o", "IdentitiesOnly=yes",
+		"-o", "UserKnownHostsFile=/keys/known_hosts", "-o", "StrictHostKeyChecking=yes",
+		"--", "deploy@build.example.com", "uptime",
+	}
+	if got := opts.sshArgs("uptime"); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("sshArgs() = %q, want %q", got, want)
+	}
+
+	minimal := RunnerSSHOptions{Host: "build.example.com"}
+	if got := strings.Join(minimal.sshArgs("uptime"), " "); got != "-n -o BatchMode=yes -- build.example.com uptime" {
+		t.Errorf("sshArgs() = %q for the minimal options", got)
+	}
+}
+
+func TestRunnerSSHRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping ssh runner tests on Windows")
+	}
+
+	// a fake ssh that records its arguments (one call per line) and runs the remote command locally
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	fakeSSH := "#!/bin/sh\nprintf %s \"$*\" | tr '\\n' '~' >> " + argsFile + "\necho >> " + argsFile + "\nfor arg; do last=$arg; done\neval \"$last\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "ssh"), []byte(fakeSSH), 0o755); err != nil {
+		t.Fatalf("Failed to write the fake ssh: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	runner, err := NewRunner(RunnerTypeSSH, RunnerOptions{"host": "remote", "user": "deploy"}, testLogger)
+	if err != nil {
+		t.Fatalf("NewRunner() error = %v", err)
+	}
+
+	// multi-line commands are sent as a script, with the environment exported
+	output, err := stdoutOf(runner.Run(context.Background(), "", "echo \"hello $NAME\"\necho bye", []string{"NAME=it's me"}, nil, false))
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if output != "hello it's me\nbye" {
+		t.Errorf("Run() = %q, want %q", output, "hello it's me\nbye")
+	}
+
+	// single-line commands are run by the same remote shell
+	output, err = stdoutOf(runner.Run(context.Background(), "", "printenv NAME", []string{"NAME=direct"}, nil, false))
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if output != "direct" {
+		t.Errorf("Run() = %q, want %q", output, "direct")
+	}
+
+	data, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatalf("Failed to read the ssh arguments: %v", err)
+	}
+	calls := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(calls) != 2 || !strings.HasPrefix(calls[0], "-n -o BatchMode=yes -- deploy@remote sh -c 'export NAME=") ||
+		calls[1] != `-n -o BatchMode=yes -- deploy@remote sh -c 'export NAME='\''direct'\''~printenv NAME'` {
+		t.Errorf("Unexpected ssh invocations: %q", calls)
+	}
+
+	// commands reading the standard input do not consume the rest of the script
+	output, err = stdoutOf(runner.Run(context.Background(), "", "cat\nread line || echo 'no input'\necho after", nil, nil, false))
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if output != "no input\nafter" {
+		t.Errorf("Run() = %q, want %q", output, "no input\nafter")
+	}
+
+	// failed remote commands report their exit code
+	result, err := runner.Run(context.Background(), "", "echo failed >&2\nexit 3", nil, nil, false)
+	if err == nil || result == nil || result.ExitCode != 3 || !strings.Contains(err.Error(), "failed") {
+		t.Errorf("Expected a failure with exit code 3, got %+v, %v", result, err)
+	}
+}
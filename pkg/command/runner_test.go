@@ -1,7 +1,11 @@
 package command
 
 import (
+	"os"
+	"os/exec"
+	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/inercia/MCPShell/pkg/common"
@@ -94,3 +98,192 @@ func TestImplicitRequirements(t *testing.T) {
 		}
 	})
 }
+
+// TestRunnerOptionsCheckUnknownKeys tests the detection of unknown (e.g. misspelled) runner options
+func TestRunnerOptionsCheckUnknownKeys(t *testing.T) {
+	tests := []struct {
+		name          string
+		runnerType    RunnerType
+		optionsStruct interface{}
+		options       RunnerOptions
+		wantErr       string
+	}{
+		{
+			name:          "Known firejail options",
+			runnerType:    RunnerTypeFirejail,
+			optionsStruct: RunnerFirejailOptions{},
+			options:       RunnerOptions{"allow_networking": true, "allow_read_folders": []interface{}{"/tmp"}},
+		},
+		{
+			name:          "Misspelled firejail option",
+			runnerType:    RunnerTypeFirejail,
+			optionsStruct: RunnerFirejailOptions{},
+			options:       RunnerOptions{"allow_network": false},
+			wantErr:       "unknown firejail runner option(s): 'allow_network' (did you mean 'allow_networking'?)",
+		},
+		{
+			name:          "Misspelled docker option",
+			runnerType:    RunnerTypeDocker,
+			optionsStruct: DockerRunnerOptions{},
+			options:       RunnerOptions{"image": "alpine:latest", "mount": []interface{}{"/tmp:/tmp"}},
+			wantErr:       "'mount' (did you mean 'mounts'?)",
+		},
+		{
+			name:          "Unknown option without suggestion",
+			runnerType:    RunnerTypeExec,
+			optionsStruct: RunnerExecOptions{},
+			options:       RunnerOptions{"completely_unrelated": "value"},
+			wantErr:       "unknown exec runner option(s): 'completely_unrelated'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.options.CheckUnknownKeys(tt.runnerType, tt.optionsStruct)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("CheckUnknownKeys() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("CheckUnknownKeys() expected error containing %q, got nil", tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("CheckUnknownKeys() error = %q, want error containing %q", err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestNewRunnerWarnsUnknownOptions tests that creating a runner with a misspelled option logs a warning
+func TestNewRunnerWarnsUnknownOptions(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "test.log")
+	logger, err := common.NewLogger("", logFile, common.LogLevelInfo, true)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer func() { _ = logger.Close() }()
+
+	if _, err := NewRunnerExec(RunnerOptions{"shel": "/bin/bash"}, logger); err != nil {
+		t.Fatalf("Failed to create exec runner: %v", err)
+	}
+
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	if !strings.Contains(string(content), "[WARN] unknown exec runner option(s): 'shel' (did you mean 'shell'?)") {
+		t.Errorf("Expected a warning about the misspelled option, got log:\n%s", content)
+	}
+}
+
+// TestValidateRunnerOptions tests the validation of runner options against the runner schemas
+func TestValidateRunnerOptions(t *testing.T) {
+	tests := []struct {
+		name          string
+		runnerType    RunnerType
+		options       RunnerOptions
+		rejectUnknown bool
+		wantErr       string
+	}{
+		{
+			name:       "Valid docker options",
+			runnerType: RunnerTypeDocker,
+			options:    RunnerOptions{"image": "alpine:latest"},
+		},
+		{
+			name:       "Docker options without image",
+			runnerType: RunnerTypeDocker,
+			options:    RunnerOptions{"allow_networking": false},
+			wantErr:    "invalid docker runner options: docker runner requires 'image' option",
+		},
+		{
+			name:       "Firejail option with wrong type",
+			runnerType: RunnerTypeFirejail,
+			options:    RunnerOptions{"allow_networking": "no"},
+			wantErr:    "invalid firejail runner options",
+		},
+		{
+			name:       "Unknown key allowed when not rejecting unknown keys",
+			runnerType: RunnerTypeExec,
+			options:    RunnerOptions{"shel": "bash"},
+		},
+		{
+			name:          "Unknown key rejected",
+			runnerType:    RunnerTypeExec,
+			options:       RunnerOptions{"shel": "bash"},
+			rejectUnknown: true,
+			wantErr:       "'shel' (did you mean 'shell'?)",
+		},
+		{
+			name:       "Unknown runner type",
+			runnerType: RunnerType("chroot"),
+			wantErr:    "unknown runner type: chroot",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRunnerOptions(tt.runnerType, tt.options, tt.rejectUnknown)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateRunnerOptions() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateRunnerOptions() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestScriptCommandArgs tests that scripts without the exec permission (like the
+// ones in a noexec /tmp) can still be run through the shell
+func TestScriptCommandArgs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping shell script test on Windows")
+	}
+
+	// a script without the exec permission, like in a noexec mount
+	scriptPath := filepath.Join(t.TempDir(), "script.sh")
+	if err := os.WriteFile(scriptPath, []byte("echo hello from script\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write script: %v", err)
+	}
+
+	args := scriptCommandArgs(scriptPath, "", false)
+	if len(args) != 1 || args[0] != scriptPath {
+		t.Errorf("Expected the script to be executed directly, got %v", args)
+	}
+	if err := exec.Command(args[0], args[1:]...).Run(); err == nil {
+		t.Errorf("Expected a permission error when executing the script directly")
+	}
+
+	args = scriptCommandArgs(scriptPath, "/bin/sh", true)
+	if len(args) != 2 || args[0] != "/bin/sh" || args[1] != scriptPath {
+		t.Fatalf("Expected the script to be run with /bin/sh, got %v", args)
+	}
+	output, err := exec.Command(args[0], args[1:]...).Output()
+	if err != nil {
+		t.Fatalf("Failed to run the script with the shell: %v", err)
+	}
+	if strings.TrimSpace(string(output)) != "hello from script" {
+		t.Errorf("Expected 'hello from script', got %q", output)
+	}
+}
+
+// TestRunsInHost tests that the timeout command is only used for the runners
+// whose commands run in this host
+func TestRunsInHost(t *testing.T) {
+	for _, runnerType := range []RunnerType{"", RunnerTypeExec, RunnerTypeFirejail, RunnerTypeBubblewrap} {
+		if !runsInHost(runnerType) {
+			t.Errorf("Expected the %q runner to run in the host", runnerType)
+		}
+	}
+	for _, runnerType := range []RunnerType{RunnerTypeDocker, RunnerTypeSSH} {
+		if runsInHost(runnerType) {
+			t.Errorf("Expected the %q runner not to run in the host", runnerType)
+		}
+	}
+}
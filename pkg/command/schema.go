@@ -0,0 +1,189 @@
+package command
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/inercia/MCPShell/pkg/common"
+)
+
+// compileParamPatterns compiles the patterns of the parameters that declare one
+func compileParamPatterns(params map[string]common.ParamConfig) (map[string]*regexp.Regexp, error) {
+	patterns := map[string]*regexp.Regexp{}
+	for name, param := range params {
+		if param.Pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(param.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern for parameter '%s': %w", name, err)
+		}
+		patterns[name] = re
+	}
+	return patterns, nil
+}
+
+// ValidateParams checks the declarations of the parameters of a tool (like their patterns
+// or transforms), returning an error for the first problem found
+func ValidateParams(params map[string]common.ParamConfig) error {
+	if _, err := compileParamPatterns(params); err != nil {
+		return err
+	}
+	return checkParamTransforms(params)
+}
+
+// validateArguments checks the arguments of a tool call against the declared parameters
+// (required, type, enum, pattern, minimum, maximum and length), before anything is executed.
+// It returns a message for every problem found, sorted by parameter name, or nil when
+// the arguments are valid. Arguments without a parameter declaration are ignored.
+func (h *CommandHandler) validateArguments(args map[string]interface{}) []string {
+	names := make([]string, 0, len(h.params))
+	for name := range h.params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []string
+	for _, name := range names {
+		param := h.params[name]
+
+		value, exists := args[name]
+		if !exists {
+			if param.Required && param.Default == nil {
+				problems = append(problems, fmt.Sprintf("parameter '%s' is required", name))
+			}
+			continue
+		}
+
+		if !matchesType(value, param) {
+			problems = append(problems, fmt.Sprintf("parameter '%s' must be of type %s, got %s", name, typeName(param), jsonTypeName(value)))
+			continue
+		}
+
+		if len(param.Enum) > 0 && !slices.ContainsFunc(param.Enum, func(allowed interface{}) bool {
+			return fmt.Sprint(allowed) == fmt.Sprint(value)
+		}) {
+			problems = append(problems, fmt.Sprintf("parameter '%s' must be one of %v, got %v", name, param.Enum, value))
+			continue
+		}
+
+		if re := h.paramPatterns[name]; re != nil {
+			if s, _ := value.(string); !re.MatchString(s) {
+				problems = append(problems, fmt.Sprintf("parameter '%s' must match the pattern '%s'", name, param.Pattern))
+				continue
+			}
+		}
+
+		if n, ok := toFloat(value); ok {
+			if minimum := param.GetMinimum(); minimum != nil && n < *minimum {
+				problems = append(problems, fmt.Sprintf("parameter '%s' must be >= %v, got %v", name, *minimum, value))
+			} else if maximum := param.GetMaximum(); maximum != nil && n > *maximum {
+				problems = append(problems, fmt.Sprintf("parameter '%s' must be <= %v, got %v", name, *maximum, value))
+			}
+		}
+
+		if s, ok := value.(string); ok {
+			length := utf8.RuneCountInString(s)
+			if param.MinLength != nil && length < *param.MinLength {
+				problems = append(problems, fmt.Sprintf("parameter '%s' must be at least %d characters long, got %d", name, *param.MinLength, length))
+			} else if param.MaxLength != nil && length > *param.MaxLength {
+				problems = append(problems, fmt.Sprintf("parameter '%s' must be at most %d characters long, got %d", name, *param.MaxLength, length))
+			}
+		}
+	}
+
+	return problems
+}
+
+// unknownArguments returns the names of the arguments that are not declared as parameters,
+// sorted. The runner options passed by the clients (in "options") are not considered.
+func (h *CommandHandler) unknownArguments(args map[string]interface{}) []string {
+	var unknown []string
+	for name := range args {
+		if _, declared := h.params[name]; declared || name == "options" {
+			continue
+		}
+		unknown = append(unknown, name)
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// matchesType returns true if the value (as decoded from JSON) is of the type of the parameter
+func matchesType(value interface{}, param common.ParamConfig) bool {
+	switch param.Type {
+	case "", "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := toFloat(value)
+		return ok
+	case "integer":
+		n, ok := toFloat(value)
+		return ok && n == math.Trunc(n)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return false
+		}
+		for _, item := range items {
+			if !matchesType(item, param.GetItems()) {
+				return false
+			}
+		}
+		return true
+	}
+	return true
+}
+
+// typeName returns the name of the type of a parameter, for the validation messages
+func typeName(param common.ParamConfig) string {
+	switch param.Type {
+	case "":
+		return "string"
+	case "array":
+		return "array of " + typeName(param.GetItems())
+	}
+	return param.Type
+}
+
+// toFloat converts a numeric value to a float64
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// jsonTypeName returns the JSON type name of a value, for the validation messages
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64, float32, int, int64:
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,91 @@
+package common
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+)
+
+const (
+	// ContextVariable is the name of the CEL variable holding the server context
+	// (the `context` map in the configuration) in tool conditions.
+	ContextVariable = "context"
+
+	// EnvVariable is the name of the CEL variable holding the environment
+	// variables of the server process in tool conditions.
+	EnvVariable = "env"
+)
+
+// compileCondition compiles a CEL condition expression, where the server context
+// and the environment variables are available as string maps.
+func compileCondition(expression string) (cel.Program, error) {
+	opts := []cel.EnvOption{
+		cel.Variable(ContextVariable, cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable(EnvVariable, cel.MapType(cel.StringType, cel.StringType)),
+	}
+	opts = append(opts, constraintFunctions()...)
+
+	env, err := cel.NewEnv(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CEL environment: %w", err)
+	}
+
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("failed to compile condition '%s': %w", expression, issues.Err())
+	}
+	if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+		return nil, fmt.Errorf("condition '%s' must be a boolean expression", expression)
+	}
+
+	prg, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create program for condition '%s': %w", expression, err)
+	}
+
+	return prg, nil
+}
+
+// ValidateCondition checks that a condition expression compiles to a boolean expression.
+func ValidateCondition(expression string) error {
+	_, err := compileCondition(expression)
+	return err
+}
+
+// EvaluateCondition evaluates a condition expression against the given server context,
+// returning whether the condition holds. The expression can use `context` (the server
+// context) and `env` (the environment variables), like `context.stage == "prod"`.
+func EvaluateCondition(expression string, context map[string]string) (bool, error) {
+	prg, err := compileCondition(expression)
+	if err != nil {
+		return false, err
+	}
+
+	if context == nil {
+		context = map[string]string{}
+	}
+
+	environ := map[string]string{}
+	for _, kv := range os.Environ() {
+		if name, value, ok := strings.Cut(kv, "="); ok {
+			environ[name] = value
+		}
+	}
+
+	out, _, err := prg.Eval(map[string]interface{}{
+		ContextVariable: context,
+		EnvVariable:     environ,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate condition '%s': %w", expression, err)
+	}
+
+	result, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("condition '%s' did not evaluate to a boolean", expression)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,73 @@
+package common
+
+import (
+	"testing"
+)
+
+func TestEvaluateCondition(t *testing.T) {
+	t.Setenv("MCPSHELL_TEST_CONDITION", "enabled")
+
+	tests := []struct {
+		name       string
+		expression string
+		context    map[string]string
+		want       bool
+		wantErr    bool
+	}{
+		{
+			name:       "Context value matches",
+			expression: "context.stage == 'prod'",
+			context:    map[string]string{"stage": "prod"},
+			want:       true,
+		},
+		{
+			name:       "Context value does not match",
+			expression: "context.stage == 'prod'",
+			context:    map[string]string{"stage": "dev"},
+			want:       false,
+		},
+		{
+			name:       "Missing context value with guard",
+			expression: "'stage' in context && context.stage == 'prod'",
+			want:       false,
+		},
+		{
+			name:       "Missing context value without guard",
+			expression: "context.stage == 'prod'",
+			wantErr:    true,
+		},
+		{
+			name:       "Environment variable",
+			expression: "env.MCPSHELL_TEST_CONDITION == 'enabled'",
+			want:       true,
+		},
+		{
+			name:       "Custom functions",
+			expression: "isJSON(context.payload)",
+			context:    map[string]string{"payload": `{"a": 1}`},
+			want:       true,
+		},
+		{
+			name:       "Not a boolean",
+			expression: "context.stage",
+			wantErr:    true,
+		},
+		{
+			name:       "Syntax error",
+			expression: "context.stage ==",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EvaluateCondition(tt.expression, tt.context)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EvaluateCondition() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("EvaluateCondition() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
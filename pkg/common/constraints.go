@@ -2,10 +2,16 @@ package common
 
 import (
 	"fmt"
+	"regexp"
 
 	"github.com/google/cel-go/cel"
 )
 
+// RunnerVariable is the name of the CEL variable holding the type of the runner selected
+// for executing the tool (like "exec" or "docker"), so constraints can depend on it.
+// A parameter with the same name takes precedence over it.
+const RunnerVariable = "runner"
+
 // CompiledConstraints holds the compiled CEL programs for a tool's constraints
 type CompiledConstraints struct {
 	programs    []cel.Program
@@ -17,6 +23,13 @@ type CompiledConstraints struct {
 // paramTypes is a map of parameter names to their types
 // logger is required for logging constraint compilation and evaluation information
 func NewCompiledConstraints(constraints []string, paramTypes map[string]ParamConfig, logger *Logger) (*CompiledConstraints, error) {
+	return NewCompiledConstraintsWithMacros(constraints, nil, paramTypes, logger)
+}
+
+// NewCompiledConstraintsWithMacros compiles a list of CEL constraint expressions,
+// expanding the references to the given macros before compilation.
+// macros is a map of macro names to CEL expression fragments (see ExpandConstraintMacros)
+func NewCompiledConstraintsWithMacros(constraints []string, macros map[string]string, paramTypes map[string]ParamConfig, logger *Logger) (*CompiledConstraints, error) {
 	if logger == nil {
 		return nil, fmt.Errorf("logger is required for constraint compilation")
 	}
@@ -26,38 +39,26 @@ func NewCompiledConstraints(constraints []string, paramTypes map[string]ParamCon
 		return &CompiledConstraints{logger: logger}, nil
 	}
 
-	// Create a new CEL environment with the parameter declarations
-	var envOpts []cel.EnvOption
-
-	// Add parameter declarations based on their types
-	for name, param := range paramTypes {
-		paramType := param.Type
-		if paramType == "" {
-			paramType = "string"
-		}
-
-		switch paramType {
-		case "string":
-			envOpts = append(envOpts, cel.Variable(name, cel.StringType))
-		case "number", "integer":
-			envOpts = append(envOpts, cel.Variable(name, cel.DoubleType))
-		case "boolean":
-			envOpts = append(envOpts, cel.Variable(name, cel.BoolType))
-		default:
-			return nil, fmt.Errorf("unsupported parameter type for CEL: %s", paramType)
-		}
+	// Expand macros before compiling, keeping the original expressions for reporting
+	expandedConstraints, err := ExpandConstraintMacros(constraints, macros, paramTypes)
+	if err != nil {
+		return nil, err
 	}
 
-	env, err := cel.NewEnv(envOpts...)
+	env, err := newConstraintsEnv(paramTypes)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create CEL environment: %w", err)
+		return nil, err
 	}
 
 	// Compile each constraint expression
 	var programs []cel.Program
 	var expressions []string
-	for _, expr := range constraints {
-		ast, issues := env.Compile(expr)
+	for i, expr := range constraints {
+		if expandedConstraints[i] != expr {
+			logger.Debug("Expanded constraint '%s' to '%s'", expr, expandedConstraints[i])
+		}
+
+		ast, issues := env.Compile(expandedConstraints[i])
 		if issues != nil && issues.Err() != nil {
 			return nil, fmt.Errorf("failed to compile constraint '%s': %w", expr, issues.Err())
 		}
@@ -79,6 +80,99 @@ func NewCompiledConstraints(constraints []string, paramTypes map[string]ParamCon
 	}, nil
 }
 
+// undeclaredReferenceRegex matches the CEL errors about references to unknown names
+var undeclaredReferenceRegex = regexp.MustCompile(`undeclared reference to '([^']+)'`)
+
+// ApplicableConstraints returns the constraints that can be evaluated with the given
+// parameters, skipping the ones referencing variables that are not declared (like the
+// parameters of other tools). Constraints with other problems (like unknown functions)
+// are kept, so their errors are reported when they are compiled.
+func ApplicableConstraints(constraints []string, macros map[string]string, paramTypes map[string]ParamConfig) []string {
+	env, err := newConstraintsEnv(paramTypes)
+	if err != nil {
+		return constraints
+	}
+
+	applicable := make([]string, 0, len(constraints))
+	for _, expr := range constraints {
+		if expanded, err := ExpandConstraintMacros([]string{expr}, macros, paramTypes); err == nil {
+			if _, issues := env.Compile(expanded[0]); issues != nil && issues.Err() != nil &&
+				onlyUndeclaredVariables(expanded[0], issues.Err().Error()) {
+				continue
+			}
+		}
+		applicable = append(applicable, expr)
+	}
+
+	return applicable
+}
+
+// onlyUndeclaredVariables returns true if the compilation errors of an expression are
+// all references to unknown variables (and not to unknown functions)
+func onlyUndeclaredVariables(expr string, errors string) bool {
+	refs := undeclaredReferenceRegex.FindAllStringSubmatch(errors, -1)
+	if len(refs) == 0 {
+		return false
+	}
+	for _, ref := range refs {
+		call := regexp.MustCompile(`\b` + regexp.QuoteMeta(ref[1]) + `\s*\(`)
+		if call.MatchString(expr) {
+			return false
+		}
+	}
+	return true
+}
+
+// newConstraintsEnv creates the CEL environment used for compiling constraints,
+// with a variable declared for each parameter (and the runner variable)
+func newConstraintsEnv(paramTypes map[string]ParamConfig) (*cel.Env, error) {
+	var envOpts []cel.EnvOption
+
+	if _, isParam := paramTypes[RunnerVariable]; !isParam {
+		envOpts = append(envOpts, cel.Variable(RunnerVariable, cel.StringType))
+	}
+
+	// Add parameter declarations based on their types
+	for name, param := range paramTypes {
+		t, err := celParamType(param)
+		if err != nil {
+			return nil, err
+		}
+		envOpts = append(envOpts, cel.Variable(name, t))
+	}
+
+	// Add the custom functions available in constraints
+	envOpts = append(envOpts, constraintFunctions()...)
+
+	env, err := cel.NewEnv(envOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CEL environment: %w", err)
+	}
+
+	return env, nil
+}
+
+// celParamType returns the CEL type of the variable for a parameter, where arrays
+// are lists of the type of their items
+func celParamType(param ParamConfig) (*cel.Type, error) {
+	switch param.Type {
+	case "string", "":
+		return cel.StringType, nil
+	case "number", "integer":
+		return cel.DoubleType, nil
+	case "boolean":
+		return cel.BoolType, nil
+	case "array":
+		itemsType, err := celParamType(param.GetItems())
+		if err != nil {
+			return nil, err
+		}
+		return cel.ListType(itemsType), nil
+	default:
+		return nil, fmt.Errorf("unsupported parameter type for CEL: %s", param.Type)
+	}
+}
+
 // Evaluate evaluates all compiled constraints against the provided arguments
 // and returns details about which constraints failed.
 //
@@ -91,6 +185,12 @@ func NewCompiledConstraints(constraints []string, paramTypes map[string]ParamCon
 //   - slice of strings containing the failed constraint expressions
 //   - error if evaluation fails or if a required parameter is missing
 func (cc *CompiledConstraints) Evaluate(args map[string]interface{}, params map[string]ParamConfig) (bool, []string, error) {
+	return cc.EvaluateWithRunner(args, params, "")
+}
+
+// EvaluateWithRunner evaluates all compiled constraints like Evaluate, exposing the
+// type of the runner selected for the tool in the RunnerVariable variable.
+func (cc *CompiledConstraints) EvaluateWithRunner(args map[string]interface{}, params map[string]ParamConfig, runner string) (bool, []string, error) {
 	if cc == nil {
 		return true, nil, nil
 	}
@@ -110,6 +210,11 @@ func (cc *CompiledConstraints) Evaluate(args map[string]interface{}, params map[
 		cc.logger.Debug("Argument provided: %s = %v", k, v)
 	}
 
+	// Expose the runner, unless there is a parameter with the same name
+	if _, isParam := params[RunnerVariable]; !isParam {
+		evalArgs[RunnerVariable] = runner
+	}
+
 	// Ensure all parameters have at least empty values if not provided
 	for name, param := range params {
 		if _, exists := evalArgs[name]; !exists {
@@ -124,6 +229,9 @@ func (cc *CompiledConstraints) Evaluate(args map[string]interface{}, params map[
 			case "boolean":
 				evalArgs[name] = false
 				cc.logger.Debug("Adding default false value for missing parameter: %s", name)
+			case "array":
+				evalArgs[name] = []interface{}{}
+				cc.logger.Debug("Adding default empty list for missing parameter: %s", name)
 			}
 		}
 	}
@@ -0,0 +1,350 @@
+package common
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	celast "github.com/google/cel-go/common/ast"
+	"github.com/google/cel-go/common/operators"
+	"github.com/google/cel-go/common/types"
+)
+
+// constraintAtom is a simple condition comparing a parameter with a constant,
+// like `value > 0.0`, `name == 'foo'` or `!flag`
+type constraintAtom struct {
+	variable string
+	op       string
+	value    interface{} // float64, string or bool
+}
+
+// AnalyzeConstraints performs a best-effort static check of constraint expressions,
+// returning warnings for constraints that can never be true (and would block every
+// call) or that are always true (and are probably a mistake).
+//
+// The check looks at constant expressions and at simple comparisons between a
+// parameter and a constant that are AND-ed (for contradictions) or OR-ed
+// (for tautologies) together. Other expressions are not reported.
+func AnalyzeConstraints(constraints []string, macros map[string]string, paramTypes map[string]ParamConfig) ([]string, error) {
+	if len(constraints) == 0 {
+		return nil, nil
+	}
+
+	expanded, err := ExpandConstraintMacros(constraints, macros, paramTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	env, err := newConstraintsEnv(paramTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []string
+	for i, expr := range constraints {
+		checked, issues := env.Compile(expanded[i])
+		if issues != nil && issues.Err() != nil {
+			return nil, fmt.Errorf("failed to compile constraint '%s': %w", expr, issues.Err())
+		}
+		root := checked.NativeRep().Expr()
+
+		// constant expressions do not depend on the parameters at all
+		if !referencesIdents(root) {
+			prg, err := env.Program(checked)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create program for constraint '%s': %w", expr, err)
+			}
+			if val, _, err := prg.Eval(map[string]interface{}{}); err == nil {
+				if b, ok := val.Value().(bool); ok {
+					if b {
+						warnings = append(warnings, fmt.Sprintf("constraint '%s' is always true", expr))
+					} else {
+						warnings = append(warnings, fmt.Sprintf("constraint '%s' can never be true", expr))
+					}
+				}
+			}
+			continue
+		}
+
+		for _, variable := range contradictoryVariables(flattenCall(root, operators.LogicalAnd), paramTypes) {
+			warnings = append(warnings, fmt.Sprintf("constraint '%s' can never be true (the conditions on '%s' are contradictory)", expr, variable))
+		}
+		for _, variable := range tautologicalVariables(flattenCall(root, operators.LogicalOr), paramTypes) {
+			warnings = append(warnings, fmt.Sprintf("constraint '%s' is always true (the conditions on '%s' cover every value)", expr, variable))
+		}
+	}
+
+	return warnings, nil
+}
+
+// contradictoryVariables returns the variables whose AND-ed conditions cannot be satisfied together
+func contradictoryVariables(conjuncts []celast.Expr, paramTypes map[string]ParamConfig) []string {
+	var result []string
+	for variable, atoms := range groupAtoms(conjuncts, paramTypes) {
+		if len(atoms) < 2 {
+			continue
+		}
+		satisfiable := false
+		for _, candidate := range atomCandidates(atoms) {
+			if satisfiesAll(atoms, candidate) {
+				satisfiable = true
+				break
+			}
+		}
+		if !satisfiable {
+			result = append(result, variable)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// tautologicalVariables returns the variables whose OR-ed conditions are satisfied by any value
+func tautologicalVariables(disjuncts []celast.Expr, paramTypes map[string]ParamConfig) []string {
+	var result []string
+	for variable, atoms := range groupAtoms(disjuncts, paramTypes) {
+		if len(atoms) < 2 {
+			continue
+		}
+		covered := true
+		for _, candidate := range atomCandidates(atoms) {
+			if !satisfiesAny(atoms, candidate) {
+				covered = false
+				break
+			}
+		}
+		if covered {
+			result = append(result, variable)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// flattenCall flattens nested calls of the given (associative) operator into a list of operands
+func flattenCall(e celast.Expr, function string) []celast.Expr {
+	if e.Kind() == celast.CallKind && e.AsCall().FunctionName() == function {
+		var result []celast.Expr
+		for _, arg := range e.AsCall().Args() {
+			result = append(result, flattenCall(arg, function)...)
+		}
+		return result
+	}
+	return []celast.Expr{e}
+}
+
+// referencesIdents returns true if the expression references any identifier
+func referencesIdents(e celast.Expr) bool {
+	switch e.Kind() {
+	case celast.IdentKind:
+		return true
+	case celast.SelectKind:
+		return referencesIdents(e.AsSelect().Operand())
+	case celast.CallKind:
+		call := e.AsCall()
+		if call.IsMemberFunction() && referencesIdents(call.Target()) {
+			return true
+		}
+		for _, arg := range call.Args() {
+			if referencesIdents(arg) {
+				return true
+			}
+		}
+		return false
+	case celast.ListKind:
+		for _, elem := range e.AsList().Elements() {
+			if referencesIdents(elem) {
+				return true
+			}
+		}
+		return false
+	case celast.LiteralKind:
+		return false
+	default:
+		// be conservative with comprehensions, maps, etc.
+		return true
+	}
+}
+
+// groupAtoms extracts the simple conditions from a list of expressions, grouped by variable
+func groupAtoms(exprs []celast.Expr, paramTypes map[string]ParamConfig) map[string][]constraintAtom {
+	groups := make(map[string][]constraintAtom)
+	for _, e := range exprs {
+		if atom, ok := parseAtom(e, paramTypes); ok {
+			groups[atom.variable] = append(groups[atom.variable], atom)
+		}
+	}
+	return groups
+}
+
+// flippedOps maps comparison operators to their equivalent with swapped operands
+var flippedOps = map[string]string{
+	operators.Equals:        operators.Equals,
+	operators.NotEquals:     operators.NotEquals,
+	operators.Less:          operators.Greater,
+	operators.LessEquals:    operators.GreaterEquals,
+	operators.Greater:       operators.Less,
+	operators.GreaterEquals: operators.LessEquals,
+}
+
+// parseAtom tries to interpret an expression as a simple condition on a parameter
+func parseAtom(e celast.Expr, paramTypes map[string]ParamConfig) (constraintAtom, bool) {
+	switch e.Kind() {
+	case celast.IdentKind:
+		// a boolean parameter used as a condition
+		if paramKind(paramTypes, e.AsIdent()) == "boolean" {
+			return constraintAtom{variable: e.AsIdent(), op: operators.Equals, value: true}, true
+		}
+	case celast.CallKind:
+		call := e.AsCall()
+		args := call.Args()
+		fn := call.FunctionName()
+
+		if fn == operators.LogicalNot && len(args) == 1 && args[0].Kind() == celast.IdentKind {
+			if paramKind(paramTypes, args[0].AsIdent()) == "boolean" {
+				return constraintAtom{variable: args[0].AsIdent(), op: operators.Equals, value: false}, true
+			}
+			return constraintAtom{}, false
+		}
+
+		if _, isComparison := flippedOps[fn]; !isComparison || len(args) != 2 {
+			return constraintAtom{}, false
+		}
+
+		ident, literal, op := args[0], args[1], fn
+		if ident.Kind() != celast.IdentKind {
+			ident, literal, op = args[1], args[0], flippedOps[fn]
+		}
+		if ident.Kind() != celast.IdentKind || literal.Kind() != celast.LiteralKind {
+			return constraintAtom{}, false
+		}
+
+		value, kind := literalValue(literal)
+		if kind == "" || kind != paramKind(paramTypes, ident.AsIdent()) {
+			return constraintAtom{}, false
+		}
+		// only equality is analyzed for strings and booleans
+		if kind != "number" && op != operators.Equals && op != operators.NotEquals {
+			return constraintAtom{}, false
+		}
+		return constraintAtom{variable: ident.AsIdent(), op: op, value: value}, true
+	}
+	return constraintAtom{}, false
+}
+
+// paramKind returns the normalized kind ("string", "number" or "boolean") of a parameter
+func paramKind(paramTypes map[string]ParamConfig, name string) string {
+	param, ok := paramTypes[name]
+	if !ok {
+		return ""
+	}
+	switch param.Type {
+	case "", "string":
+		return "string"
+	case "number", "integer":
+		return "number"
+	case "boolean":
+		return "boolean"
+	}
+	return ""
+}
+
+// literalValue returns the Go value and normalized kind of a CEL literal
+func literalValue(e celast.Expr) (interface{}, string) {
+	switch v := e.AsLiteral().(type) {
+	case types.Double:
+		return float64(v), "number"
+	case types.Int:
+		return float64(v), "number"
+	case types.Uint:
+		return float64(v), "number"
+	case types.String:
+		return string(v), "string"
+	case types.Bool:
+		return bool(v), "boolean"
+	}
+	return nil, ""
+}
+
+// atomCandidates returns a set of values that is representative of all the possible
+// values of a variable for the given conditions: any value satisfies exactly the
+// same conditions as one of the candidates.
+func atomCandidates(atoms []constraintAtom) []interface{} {
+	switch atoms[0].value.(type) {
+	case bool:
+		return []interface{}{true, false}
+	case string:
+		var candidates []interface{}
+		var consts []string
+		for _, atom := range atoms {
+			if s, ok := atom.value.(string); ok {
+				candidates = append(candidates, s)
+				consts = append(consts, s)
+			}
+		}
+		// a string different from all the constants
+		return append(candidates, "~"+strings.Join(consts, "~"))
+	case float64:
+		var consts []float64
+		for _, atom := range atoms {
+			if f, ok := atom.value.(float64); ok {
+				consts = append(consts, f)
+			}
+		}
+		sort.Float64s(consts)
+		candidates := []interface{}{consts[0] - 1, consts[len(consts)-1] + 1}
+		for i, c := range consts {
+			candidates = append(candidates, c)
+			if i > 0 && consts[i-1] != c {
+				candidates = append(candidates, (consts[i-1]+c)/2)
+			}
+		}
+		return candidates
+	}
+	return nil
+}
+
+func satisfiesAll(atoms []constraintAtom, value interface{}) bool {
+	for _, atom := range atoms {
+		if !atom.satisfiedBy(value) {
+			return false
+		}
+	}
+	return true
+}
+
+func satisfiesAny(atoms []constraintAtom, value interface{}) bool {
+	for _, atom := range atoms {
+		if atom.satisfiedBy(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// satisfiedBy returns true if the condition holds when the variable takes the given value
+func (a constraintAtom) satisfiedBy(value interface{}) bool {
+	switch a.op {
+	case operators.Equals:
+		return value == a.value
+	case operators.NotEquals:
+		return value != a.value
+	}
+
+	v, ok1 := value.(float64)
+	c, ok2 := a.value.(float64)
+	if !ok1 || !ok2 {
+		return false
+	}
+	switch a.op {
+	case operators.Less:
+		return v < c
+	case operators.LessEquals:
+		return v <= c
+	case operators.Greater:
+		return v > c
+	case operators.GreaterEquals:
+		return v >= c
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package common
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeConstraints(t *testing.T) {
+	paramTypes := map[string]ParamConfig{
+		"value": {Type: "number"},
+		"name":  {Type: "string"},
+		"force": {Type: "boolean"},
+	}
+
+	tests := []struct {
+		name         string
+		constraints  []string
+		macros       map[string]string
+		wantWarnings []string
+	}{
+		{
+			name:        "Valid constraints produce no warnings",
+			constraints: []string{"value > 0.0 && value < 10.0", "name.size() < 10", "name == 'a' || name == 'b'", "!force"},
+		},
+		{
+			name:         "Contradictory numeric range",
+			constraints:  []string{"value > 0.0 && value < 0.0"},
+			wantWarnings: []string{"can never be true (the conditions on 'value' are contradictory)"},
+		},
+		{
+			name:         "Contradictory range mixed with other conditions",
+			constraints:  []string{"name.size() > 2 && value >= 10.0 && 5.0 > value"},
+			wantWarnings: []string{"can never be true"},
+		},
+		{
+			name:         "Contradictory string equalities",
+			constraints:  []string{"name == 'a' && name == 'b'"},
+			wantWarnings: []string{"can never be true (the conditions on 'name' are contradictory)"},
+		},
+		{
+			name:         "Contradictory boolean",
+			constraints:  []string{"force && !force"},
+			wantWarnings: []string{"can never be true (the conditions on 'force' are contradictory)"},
+		},
+		{
+			name:         "Tautological numeric range",
+			constraints:  []string{"value > 5.0 || value <= 5.0"},
+			wantWarnings: []string{"is always true (the conditions on 'value' cover every value)"},
+		},
+		{
+			name:         "Tautological string inequalities",
+			constraints:  []string{"name != 'a' || name != 'b'"},
+			wantWarnings: []string{"is always true"},
+		},
+		{
+			name:         "Constant false expression",
+			constraints:  []string{"1 > 2"},
+			wantWarnings: []string{"can never be true"},
+		},
+		{
+			name:         "Constant true expression",
+			constraints:  []string{"true"},
+			wantWarnings: []string{"is always true"},
+		},
+		{
+			name:         "Contradiction introduced by a macro",
+			constraints:  []string{"positive && value < 0.0"},
+			macros:       map[string]string{"positive": "value > 0.0"},
+			wantWarnings: []string{"constraint 'positive && value < 0.0' can never be true"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings, err := AnalyzeConstraints(tt.constraints, tt.macros, paramTypes)
+			if err != nil {
+				t.Fatalf("AnalyzeConstraints() error = %v", err)
+			}
+			if len(warnings) != len(tt.wantWarnings) {
+				t.Fatalf("AnalyzeConstraints() returned %d warnings %v, want %d", len(warnings), warnings, len(tt.wantWarnings))
+			}
+			for i, want := range tt.wantWarnings {
+				if !strings.Contains(warnings[i], want) {
+					t.Errorf("AnalyzeConstraints() warning = '%s', want it to contain '%s'", warnings[i], want)
+				}
+			}
+		})
+	}
+}
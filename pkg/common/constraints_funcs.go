@@ -0,0 +1,281 @@
+package common
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+)
+
+// constraintFunctions returns the custom functions available in constraint expressions
+// (and tool conditions). New helpers only need to be added here.
+// Functions are declared with typed overloads, so wrong usages are rejected when the
+// constraints are compiled.
+func constraintFunctions() []cel.EnvOption {
+	return []cel.EnvOption{
+		// isBase64(s) returns true if s is valid base64 (standard or URL-safe alphabet,
+		// with or without padding)
+		cel.Function("isBase64",
+			cel.Overload("isBase64_string", []*cel.Type{cel.StringType}, cel.BoolType,
+				cel.UnaryBinding(func(arg ref.Val) ref.Val {
+					s, ok := arg.Value().(string)
+					if !ok {
+						return types.MaybeNoSuchOverloadErr(arg)
+					}
+					return types.Bool(isBase64(s))
+				}),
+			),
+		),
+
+		// isJSON(s) returns true if s is a valid JSON document
+		cel.Function("isJSON",
+			cel.Overload("isJSON_string", []*cel.Type{cel.StringType}, cel.BoolType,
+				cel.UnaryBinding(func(arg ref.Val) ref.Val {
+					s, ok := arg.Value().(string)
+					if !ok {
+						return types.MaybeNoSuchOverloadErr(arg)
+					}
+					return types.Bool(json.Valid([]byte(s)))
+				}),
+			),
+		),
+
+		// jsonGet(s, path) parses s as JSON and returns the value at the dot-separated
+		// path (numeric segments index arrays), or null if the path does not exist
+		cel.Function("jsonGet",
+			cel.Overload("jsonGet_string_string", []*cel.Type{cel.StringType, cel.StringType}, cel.DynType,
+				cel.BinaryBinding(func(lhs, rhs ref.Val) ref.Val {
+					s, ok := lhs.Value().(string)
+					if !ok {
+						return types.MaybeNoSuchOverloadErr(lhs)
+					}
+					path, ok := rhs.Value().(string)
+					if !ok {
+						return types.MaybeNoSuchOverloadErr(rhs)
+					}
+
+					var doc interface{}
+					if err := json.Unmarshal([]byte(s), &doc); err != nil {
+						return types.NewErr("jsonGet: invalid JSON: %v", err)
+					}
+
+					value, found := jsonLookup(doc, path)
+					if !found || value == nil {
+						return types.NullValue
+					}
+					return types.DefaultTypeAdapter.NativeToValue(value)
+				}),
+			),
+		),
+
+		// lower(s) returns s in lower case, for case-insensitive comparisons
+		cel.Function("lower",
+			cel.Overload("lower_string", []*cel.Type{cel.StringType}, cel.StringType,
+				cel.UnaryBinding(func(arg ref.Val) ref.Val {
+					s, ok := arg.Value().(string)
+					if !ok {
+						return types.MaybeNoSuchOverloadErr(arg)
+					}
+					return types.String(strings.ToLower(s))
+				}),
+			),
+		),
+
+		// upper(s) returns s in upper case
+		cel.Function("upper",
+			cel.Overload("upper_string", []*cel.Type{cel.StringType}, cel.StringType,
+				cel.UnaryBinding(func(arg ref.Val) ref.Val {
+					s, ok := arg.Value().(string)
+					if !ok {
+						return types.MaybeNoSuchOverloadErr(arg)
+					}
+					return types.String(strings.ToUpper(s))
+				}),
+			),
+		),
+
+		// containsIgnoreCase(s, sub) returns true if s contains sub, ignoring the case
+		cel.Function("containsIgnoreCase",
+			cel.Overload("containsIgnoreCase_string_string", []*cel.Type{cel.StringType, cel.StringType}, cel.BoolType,
+				cel.BinaryBinding(func(lhs, rhs ref.Val) ref.Val {
+					s, ok := lhs.Value().(string)
+					if !ok {
+						return types.MaybeNoSuchOverloadErr(lhs)
+					}
+					sub, ok := rhs.Value().(string)
+					if !ok {
+						return types.MaybeNoSuchOverloadErr(rhs)
+					}
+					return types.Bool(strings.Contains(strings.ToLower(s), strings.ToLower(sub)))
+				}),
+			),
+		),
+
+		// charLength(s) returns the number of characters (Unicode code points) in s
+		cel.Function("charLength",
+			cel.Overload("charLength_string", []*cel.Type{cel.StringType}, cel.IntType,
+				cel.UnaryBinding(func(arg ref.Val) ref.Val {
+					s, ok := arg.Value().(string)
+					if !ok {
+						return types.MaybeNoSuchOverloadErr(arg)
+					}
+					return types.Int(utf8.RuneCountInString(s))
+				}),
+			),
+		),
+
+		// byteLength(s) returns the number of bytes of s (in UTF-8)
+		cel.Function("byteLength",
+			cel.Overload("byteLength_string", []*cel.Type{cel.StringType}, cel.IntType,
+				cel.UnaryBinding(func(arg ref.Val) ref.Val {
+					s, ok := arg.Value().(string)
+					if !ok {
+						return types.MaybeNoSuchOverloadErr(arg)
+					}
+					return types.Int(len(s))
+				}),
+			),
+		),
+
+		// isAbsPath(s) returns true if s is an absolute path
+		stringPredicate("isAbsPath", filepath.IsAbs),
+
+		// isUnderDir(s, dir) returns true if the path s is dir or is inside dir, once both
+		// are cleaned (so "/home/user/../../etc" is not under "/home"). Symlinks are not resolved.
+		cel.Function("isUnderDir",
+			cel.Overload("isUnderDir_string_string", []*cel.Type{cel.StringType, cel.StringType}, cel.BoolType,
+				cel.BinaryBinding(func(lhs, rhs ref.Val) ref.Val {
+					s, ok := lhs.Value().(string)
+					if !ok {
+						return types.MaybeNoSuchOverloadErr(lhs)
+					}
+					dir, ok := rhs.Value().(string)
+					if !ok {
+						return types.MaybeNoSuchOverloadErr(rhs)
+					}
+					return types.Bool(isUnderDir(s, dir))
+				}),
+			),
+		),
+
+		// isValidHostname(s) returns true if s is a valid hostname (RFC 1123)
+		stringPredicate("isValidHostname", isValidHostname),
+
+		// isPrivateIP(s) returns true if s is an IP address that is not publicly routable:
+		// private (like 10.0.0.0/8 or fc00::/7), loopback or link-local
+		stringPredicate("isPrivateIP", isPrivateIP),
+	}
+}
+
+// stringPredicate declares a function named name, taking a string and returning the
+// result of calling fn with it
+func stringPredicate(name string, fn func(string) bool) cel.EnvOption {
+	return cel.Function(name,
+		cel.Overload(name+"_string", []*cel.Type{cel.StringType}, cel.BoolType,
+			cel.UnaryBinding(func(arg ref.Val) ref.Val {
+				s, ok := arg.Value().(string)
+				if !ok {
+					return types.MaybeNoSuchOverloadErr(arg)
+				}
+				return types.Bool(fn(s))
+			}),
+		),
+	)
+}
+
+// isUnderDir checks whether the path is the directory or is inside it (lexically)
+func isUnderDir(path, dir string) bool {
+	if path == "" || dir == "" {
+		return false
+	}
+	rel, err := filepath.Rel(filepath.Clean(dir), filepath.Clean(path))
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// isValidHostname checks whether s is a valid hostname, made of dot-separated labels of
+// up to 63 letters, digits and hyphens (not at the beginning or the end of the label)
+func isValidHostname(s string) bool {
+	s = strings.TrimSuffix(s, ".")
+	if s == "" || len(s) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(s, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '-' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isPrivateIP checks whether s is an IP address that is not publicly routable
+func isPrivateIP(s string) bool {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return false
+	}
+	return ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsUnspecified()
+}
+
+// isBase64 checks whether s decodes with any of the common base64 encodings
+func isBase64(s string) bool {
+	if s == "" {
+		return false
+	}
+	encodings := []*base64.Encoding{
+		base64.StdEncoding,
+		base64.RawStdEncoding,
+		base64.URLEncoding,
+		base64.RawURLEncoding,
+	}
+	for _, enc := range encodings {
+		if _, err := enc.DecodeString(s); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonLookup walks a decoded JSON document following a dot-separated path.
+// An empty path returns the whole document.
+func jsonLookup(doc interface{}, path string) (interface{}, bool) {
+	if path == "" {
+		return doc, true
+	}
+
+	current := doc
+	for _, segment := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, exists := node[segment]
+			if !exists {
+				return nil, false
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+			current = node[index]
+		default:
+			return nil, false
+		}
+	}
+
+	return current, true
+}
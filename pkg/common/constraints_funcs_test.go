@@ -0,0 +1,289 @@
+package common
+
+import (
+	"testing"
+)
+
+// TestConstraintFunctions tests the custom functions available in constraints
+func TestConstraintFunctions(t *testing.T) {
+	paramTypes := map[string]ParamConfig{
+		"payload": {Type: "string", Description: "Payload"},
+		"count":   {Type: "number", Description: "Count"},
+	}
+
+	tests := []struct {
+		name           string
+		constraints    []string
+		args           map[string]interface{}
+		wantCompileErr bool
+		wantEvalResult bool
+		wantEvalErr    bool
+	}{
+		{
+			name:           "isBase64 with valid base64",
+			constraints:    []string{"isBase64(payload)"},
+			args:           map[string]interface{}{"payload": "aGVsbG8gd29ybGQ="},
+			wantEvalResult: true,
+		},
+		{
+			name:           "isBase64 with URL-safe base64",
+			constraints:    []string{"isBase64(payload)"},
+			args:           map[string]interface{}{"payload": "Pz8_Pw"},
+			wantEvalResult: true,
+		},
+		{
+			name:           "isBase64 with invalid base64",
+			constraints:    []string{"isBase64(payload)"},
+			args:           map[string]interface{}{"payload": "not base64!"},
+			wantEvalResult: false,
+		},
+		{
+			name:           "isJSON with valid JSON",
+			constraints:    []string{"isJSON(payload)"},
+			args:           map[string]interface{}{"payload": `{"name": "test"}`},
+			wantEvalResult: true,
+		},
+		{
+			name:           "isJSON with invalid JSON",
+			constraints:    []string{"isJSON(payload)"},
+			args:           map[string]interface{}{"payload": `{"name": `},
+			wantEvalResult: false,
+		},
+		{
+			name:           "valid JSON containing the required key",
+			constraints:    []string{"isJSON(payload) && jsonGet(payload, 'name') != null"},
+			args:           map[string]interface{}{"payload": `{"name": "test", "size": 3}`},
+			wantEvalResult: true,
+		},
+		{
+			name:           "valid JSON missing the required key",
+			constraints:    []string{"isJSON(payload) && jsonGet(payload, 'name') != null"},
+			args:           map[string]interface{}{"payload": `{"size": 3}`},
+			wantEvalResult: false,
+		},
+		{
+			name:           "invalid JSON short-circuits the key check",
+			constraints:    []string{"isJSON(payload) && jsonGet(payload, 'name') != null"},
+			args:           map[string]interface{}{"payload": "not json"},
+			wantEvalResult: false,
+		},
+		{
+			name:           "jsonGet with nested path and array index",
+			constraints:    []string{"jsonGet(payload, 'items.1.id') == 'b'"},
+			args:           map[string]interface{}{"payload": `{"items": [{"id": "a"}, {"id": "b"}]}`},
+			wantEvalResult: true,
+		},
+		{
+			name:           "jsonGet with numeric value",
+			constraints:    []string{"jsonGet(payload, 'size') <= count"},
+			args:           map[string]interface{}{"payload": `{"size": 3}`, "count": 5.0},
+			wantEvalResult: true,
+		},
+		{
+			name:        "jsonGet on invalid JSON is an evaluation error",
+			constraints: []string{"jsonGet(payload, 'name') == 'test'"},
+			args:        map[string]interface{}{"payload": "not json"},
+			wantEvalErr: true,
+		},
+		{
+			name:           "case-insensitive whitelist with an allowed value",
+			constraints:    []string{"lower(payload) in ['ls', 'pwd']"},
+			args:           map[string]interface{}{"payload": "LS"},
+			wantEvalResult: true,
+		},
+		{
+			name:           "case-insensitive whitelist with a forbidden value",
+			constraints:    []string{"lower(payload) in ['ls', 'pwd']"},
+			args:           map[string]interface{}{"payload": "Rm"},
+			wantEvalResult: false,
+		},
+		{
+			name:           "upper comparison",
+			constraints:    []string{"upper(payload) == 'GET'"},
+			args:           map[string]interface{}{"payload": "get"},
+			wantEvalResult: true,
+		},
+		{
+			name:           "containsIgnoreCase with a match in a different case",
+			constraints:    []string{"!containsIgnoreCase(payload, 'drop table')"},
+			args:           map[string]interface{}{"payload": "select 1; DROP Table users"},
+			wantEvalResult: false,
+		},
+		{
+			name:           "containsIgnoreCase without a match",
+			constraints:    []string{"!containsIgnoreCase(payload, 'drop table')"},
+			args:           map[string]interface{}{"payload": "select * from users"},
+			wantEvalResult: true,
+		},
+		{
+			name:           "charLength counts the characters of a multibyte string",
+			constraints:    []string{"charLength(payload) == 5"},
+			args:           map[string]interface{}{"payload": "héllo"},
+			wantEvalResult: true,
+		},
+		{
+			name:           "byteLength counts the bytes of a multibyte string",
+			constraints:    []string{"byteLength(payload) == 6"},
+			args:           map[string]interface{}{"payload": "héllo"},
+			wantEvalResult: true,
+		},
+		{
+			name:           "size counts characters like charLength",
+			constraints:    []string{"payload.size() == charLength(payload)"},
+			args:           map[string]interface{}{"payload": "héllo"},
+			wantEvalResult: true,
+		},
+		{
+			name:           "byteLength limit exceeded by a multibyte string",
+			constraints:    []string{"charLength(payload) <= 4 && byteLength(payload) <= 4"},
+			args:           map[string]interface{}{"payload": "日本"},
+			wantEvalResult: false,
+		},
+		{
+			name:           "byteLength with wrong argument type",
+			constraints:    []string{"byteLength(count) > 0"},
+			wantCompileErr: true,
+		},
+		{
+			name:           "lower with wrong argument type",
+			constraints:    []string{"lower(count) == 'a'"},
+			wantCompileErr: true,
+		},
+		{
+			name:           "isBase64 with wrong argument type",
+			constraints:    []string{"isBase64(count)"},
+			wantCompileErr: true,
+		},
+		{
+			name:           "isAbsPath with an absolute path",
+			constraints:    []string{"isAbsPath(payload)"},
+			args:           map[string]interface{}{"payload": "/home/user/file.txt"},
+			wantEvalResult: true,
+		},
+		{
+			name:           "isAbsPath with a relative path",
+			constraints:    []string{"isAbsPath(payload)"},
+			args:           map[string]interface{}{"payload": "docs/file.txt"},
+			wantEvalResult: false,
+		},
+		{
+			name:           "isUnderDir with a path inside the directory",
+			constraints:    []string{"isUnderDir(payload, '/home')"},
+			args:           map[string]interface{}{"payload": "/home/user/file.txt"},
+			wantEvalResult: true,
+		},
+		{
+			name:           "isUnderDir with the directory itself",
+			constraints:    []string{"isUnderDir(payload, '/home/')"},
+			args:           map[string]interface{}{"payload": "/home"},
+			wantEvalResult: true,
+		},
+		{
+			name:           "isUnderDir with a path escaping the directory",
+			constraints:    []string{"isUnderDir(payload, '/home')"},
+			args:           map[string]interface{}{"payload": "/home/user/../../etc/passwd"},
+			wantEvalResult: false,
+		},
+		{
+			name:           "isUnderDir with a sibling directory sharing the prefix",
+			constraints:    []string{"isUnderDir(payload, '/home')"},
+			args:           map[string]interface{}{"payload": "/homeless/file.txt"},
+			wantEvalResult: false,
+		},
+		{
+			name:           "isUnderDir with a relative path",
+			constraints:    []string{"isUnderDir(payload, '/home')"},
+			args:           map[string]interface{}{"payload": "user/file.txt"},
+			wantEvalResult: false,
+		},
+		{
+			name:           "isValidHostname with a valid hostname",
+			constraints:    []string{"isValidHostname(payload)"},
+			args:           map[string]interface{}{"payload": "api-1.example.com"},
+			wantEvalResult: true,
+		},
+		{
+			name:           "isValidHostname with a trailing dot",
+			constraints:    []string{"isValidHostname(payload)"},
+			args:           map[string]interface{}{"payload": "example.com."},
+			wantEvalResult: true,
+		},
+		{
+			name:           "isValidHostname with invalid characters",
+			constraints:    []string{"isValidHostname(payload)"},
+			args:           map[string]interface{}{"payload": "example.com; rm -rf /"},
+			wantEvalResult: false,
+		},
+		{
+			name:           "isValidHostname with a label starting with a hyphen",
+			constraints:    []string{"isValidHostname(payload)"},
+			args:           map[string]interface{}{"payload": "-bad.example.com"},
+			wantEvalResult: false,
+		},
+		{
+			name:           "isPrivateIP with a private IPv4 address",
+			constraints:    []string{"isPrivateIP(payload)"},
+			args:           map[string]interface{}{"payload": "192.168.1.10"},
+			wantEvalResult: true,
+		},
+		{
+			name:           "isPrivateIP with a loopback address",
+			constraints:    []string{"isPrivateIP(payload)"},
+			args:           map[string]interface{}{"payload": "127.0.0.1"},
+			wantEvalResult: true,
+		},
+		{
+			name:           "isPrivateIP with a private IPv6 address",
+			constraints:    []string{"isPrivateIP(payload)"},
+			args:           map[string]interface{}{"payload": "fd00::1"},
+			wantEvalResult: true,
+		},
+		{
+			name:           "isPrivateIP with a public address",
+			constraints:    []string{"isPrivateIP(payload)"},
+			args:           map[string]interface{}{"payload": "8.8.8.8"},
+			wantEvalResult: false,
+		},
+		{
+			name:           "isPrivateIP with a hostname",
+			constraints:    []string{"isPrivateIP(payload)"},
+			args:           map[string]interface{}{"payload": "localhost"},
+			wantEvalResult: false,
+		},
+		{
+			name:           "isUnderDir with wrong argument type",
+			constraints:    []string{"isUnderDir(payload, count)"},
+			wantCompileErr: true,
+		},
+		{
+			name:           "isPrivateIP with wrong argument type",
+			constraints:    []string{"isPrivateIP(count)"},
+			wantCompileErr: true,
+		},
+		{
+			name:           "jsonGet with missing path argument",
+			constraints:    []string{"jsonGet(payload) != null"},
+			wantCompileErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compiled, err := NewCompiledConstraints(tt.constraints, paramTypes, testLogger)
+			if (err != nil) != tt.wantCompileErr {
+				t.Fatalf("NewCompiledConstraints() error = %v, wantCompileErr %v", err, tt.wantCompileErr)
+			}
+			if err != nil {
+				return
+			}
+
+			got, _, err := compiled.Evaluate(tt.args, paramTypes)
+			if (err != nil) != tt.wantEvalErr {
+				t.Fatalf("CompiledConstraints.Evaluate() error = %v, wantEvalErr %v", err, tt.wantEvalErr)
+			}
+			if err == nil && got != tt.wantEvalResult {
+				t.Errorf("CompiledConstraints.Evaluate() = %v, want %v", got, tt.wantEvalResult)
+			}
+		})
+	}
+}
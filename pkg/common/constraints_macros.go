@@ -0,0 +1,121 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxMacroDepth limits how deeply macros can reference other macros
+const maxMacroDepth = 10
+
+// ExpandConstraintMacros replaces references to macros in constraint expressions
+// with their (parenthesized) CEL fragments.
+//
+// A macro is referenced by using its name as an identifier in a constraint.
+// Macros can reference other macros, but cycles are rejected. Names inside
+// string literals and field selections (e.g. `obj.name`) are not expanded, and
+// parameters take precedence over macros with the same name.
+func ExpandConstraintMacros(constraints []string, macros map[string]string, paramTypes map[string]ParamConfig) ([]string, error) {
+	if len(macros) == 0 {
+		return constraints, nil
+	}
+
+	expanded := make([]string, 0, len(constraints))
+	for _, expr := range constraints {
+		result, err := expandMacros(expr, macros, paramTypes, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand macros in constraint '%s': %w", expr, err)
+		}
+		expanded = append(expanded, result)
+	}
+
+	return expanded, nil
+}
+
+// expandMacros expands the macros found in expr, keeping track of the chain of
+// macros being expanded in order to detect cycles
+func expandMacros(expr string, macros map[string]string, paramTypes map[string]ParamConfig, stack []string) (string, error) {
+	if len(stack) > maxMacroDepth {
+		return "", fmt.Errorf("macro expansion too deep: %s", strings.Join(stack, " -> "))
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(expr); {
+		c := expr[i]
+
+		// copy string literals verbatim
+		if c == '"' || c == '\'' {
+			end := i + 1
+			for end < len(expr) && expr[end] != c {
+				if expr[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end < len(expr) {
+				end++
+			}
+			sb.WriteString(expr[i:end])
+			i = end
+			continue
+		}
+
+		if !isIdentStart(c) {
+			sb.WriteByte(c)
+			i++
+			continue
+		}
+
+		end := i + 1
+		for end < len(expr) && isIdentPart(expr[end]) {
+			end++
+		}
+		ident := expr[i:end]
+
+		fragment, isMacro := macros[ident]
+		_, isParam := paramTypes[ident]
+		if !isMacro || isParam || isFieldSelection(expr, i) {
+			sb.WriteString(ident)
+			i = end
+			continue
+		}
+
+		for _, name := range stack {
+			if name == ident {
+				return "", fmt.Errorf("macro cycle detected: %s -> %s", strings.Join(stack, " -> "), ident)
+			}
+		}
+
+		sub, err := expandMacros(fragment, macros, paramTypes, append(stack, ident))
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString("(" + sub + ")")
+		i = end
+	}
+
+	return sb.String(), nil
+}
+
+// isFieldSelection returns true if the identifier starting at pos is preceded by a '.'
+func isFieldSelection(expr string, pos int) bool {
+	for j := pos - 1; j >= 0; j-- {
+		switch expr[j] {
+		case ' ', '\t', '\n':
+			continue
+		case '.':
+			return true
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,78 @@
+package common
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpandConstraintMacros(t *testing.T) {
+	paramTypes := map[string]ParamConfig{
+		"path": {Type: "string"},
+		"safe": {Type: "boolean"},
+	}
+
+	tests := []struct {
+		name        string
+		constraints []string
+		macros      map[string]string
+		want        []string
+		wantErr     string
+	}{
+		{
+			name:        "No macros",
+			constraints: []string{"path.size() < 10"},
+			want:        []string{"path.size() < 10"},
+		},
+		{
+			name:        "Simple macro",
+			constraints: []string{"no_traversal && path.size() < 10"},
+			macros:      map[string]string{"no_traversal": "!path.contains('../')"},
+			want:        []string{"(!path.contains('../')) && path.size() < 10"},
+		},
+		{
+			name:        "Nested macros",
+			constraints: []string{"safe_path"},
+			macros: map[string]string{
+				"safe_path":    "no_traversal && path.startsWith('/tmp/')",
+				"no_traversal": "!path.contains('../')",
+			},
+			want: []string{"((!path.contains('../')) && path.startsWith('/tmp/'))"},
+		},
+		{
+			name:        "Names in strings and field selections are not expanded",
+			constraints: []string{"path != 'no_traversal' && jsonGet(path, 'x').no_traversal"},
+			macros:      map[string]string{"no_traversal": "true"},
+			want:        []string{"path != 'no_traversal' && jsonGet(path, 'x').no_traversal"},
+		},
+		{
+			name:        "Parameters take precedence over macros",
+			constraints: []string{"safe"},
+			macros:      map[string]string{"safe": "false"},
+			want:        []string{"safe"},
+		},
+		{
+			name:        "Macro cycle",
+			constraints: []string{"a"},
+			macros:      map[string]string{"a": "b", "b": "a"},
+			wantErr:     "macro cycle detected",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandConstraintMacros(tt.constraints, tt.macros, paramTypes)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ExpandConstraintMacros() error = %v, want error containing '%s'", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExpandConstraintMacros() unexpected error = %v", err)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("ExpandConstraintMacros() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
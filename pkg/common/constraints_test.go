@@ -1,6 +1,7 @@
 package common
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -153,6 +154,48 @@ func TestConstraints(t *testing.T) {
 			wantEvalResult: false,
 			wantEvalErr:    false,
 		},
+		{
+			name:        "Array of strings - pass",
+			constraints: []string{"files.all(f, f.endsWith('.txt'))", "files.size() <= 3"},
+			paramTypes: map[string]ParamConfig{
+				"files": {Type: "array", Items: &ParamConfig{Type: "string"}, Description: "Files"},
+			},
+			args:           map[string]interface{}{"files": []interface{}{"a.txt", "b.txt"}},
+			wantCompileErr: false,
+			wantEvalResult: true,
+			wantEvalErr:    false,
+		},
+		{
+			name:        "Array of strings - fail",
+			constraints: []string{"files.all(f, f.endsWith('.txt'))"},
+			paramTypes: map[string]ParamConfig{
+				"files": {Type: "array", Description: "Files (strings by default)"},
+			},
+			args:           map[string]interface{}{"files": []interface{}{"a.txt", "/etc/passwd"}},
+			wantCompileErr: false,
+			wantEvalResult: false,
+			wantEvalErr:    false,
+		},
+		{
+			name:        "Array of numbers - pass",
+			constraints: []string{"ports.all(p, p > 1024.0)"},
+			paramTypes: map[string]ParamConfig{
+				"ports": {Type: "array", Items: &ParamConfig{Type: "integer"}, Description: "Ports"},
+			},
+			args:           map[string]interface{}{"ports": []interface{}{8080.0, 9090.0}},
+			wantCompileErr: false,
+			wantEvalResult: true,
+			wantEvalErr:    false,
+		},
+		{
+			name:        "Array items type checked at compile time",
+			constraints: []string{"ports.all(p, p.startsWith('8'))"},
+			paramTypes: map[string]ParamConfig{
+				"ports": {Type: "array", Items: &ParamConfig{Type: "number"}, Description: "Ports"},
+			},
+			skipEvaluation: true,
+			wantCompileErr: true,
+		},
 		{
 			name:        "Numeric comparison - pass",
 			constraints: []string{"value > 0.0", "value <= 100.0"},
@@ -348,6 +391,61 @@ func TestConstraints(t *testing.T) {
 			wantEvalResult: true,
 			wantEvalErr:    false,
 		},
+		{
+			name:        "Conversion of a number to an integer",
+			constraints: []string{"int(value) % 2 == 0"},
+			paramTypes: map[string]ParamConfig{
+				"value": {Type: "number", Description: "Value"},
+			},
+			args:           map[string]interface{}{"value": 4.0},
+			wantCompileErr: false,
+			wantEvalResult: true,
+			wantEvalErr:    false,
+		},
+		{
+			name:        "Conversion of an odd number to an integer",
+			constraints: []string{"int(value) % 2 == 0"},
+			paramTypes: map[string]ParamConfig{
+				"value": {Type: "number", Description: "Value"},
+			},
+			args:           map[string]interface{}{"value": 3.0},
+			wantCompileErr: false,
+			wantEvalResult: false,
+			wantEvalErr:    false,
+		},
+		{
+			name:        "Conversion of a string to numbers",
+			constraints: []string{"int(value) % 2 == 0", "double(value) < 100.0"},
+			paramTypes: map[string]ParamConfig{
+				"value": {Type: "string", Description: "Value"},
+			},
+			args:           map[string]interface{}{"value": "42"},
+			wantCompileErr: false,
+			wantEvalResult: true,
+			wantEvalErr:    false,
+		},
+		{
+			name:        "Conversion of a number to a string",
+			constraints: []string{"string(int(value)).size() <= 3"},
+			paramTypes: map[string]ParamConfig{
+				"value": {Type: "number", Description: "Value"},
+			},
+			args:           map[string]interface{}{"value": 1234.0},
+			wantCompileErr: false,
+			wantEvalResult: false,
+			wantEvalErr:    false,
+		},
+		{
+			name:        "Conversion of an invalid string to an integer",
+			constraints: []string{"int(value) % 2 == 0"},
+			paramTypes: map[string]ParamConfig{
+				"value": {Type: "string", Description: "Value"},
+			},
+			args:           map[string]interface{}{"value": "four"},
+			wantCompileErr: false,
+			wantEvalResult: false,
+			wantEvalErr:    true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -426,3 +524,69 @@ func TestConstraints(t *testing.T) {
 		}
 	})
 }
+
+// TestConstraintsRunnerVariable tests that constraints can depend on the selected runner
+func TestConstraintsRunnerVariable(t *testing.T) {
+	paramTypes := map[string]ParamConfig{
+		"remote": {Type: "boolean"},
+	}
+	compiled, err := NewCompiledConstraints([]string{"runner == 'docker' || !remote"}, paramTypes, testLogger)
+	if err != nil {
+		t.Fatalf("Failed to compile constraints: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		runner string
+		remote bool
+		want   bool
+	}{
+		{name: "local target with exec", runner: "exec", remote: false, want: true},
+		{name: "remote target with exec", runner: "exec", remote: true, want: false},
+		{name: "remote target with docker", runner: "docker", remote: true, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, failed, err := compiled.EvaluateWithRunner(map[string]interface{}{"remote": tt.remote}, paramTypes, tt.runner)
+			if err != nil {
+				t.Fatalf("EvaluateWithRunner() error = %v", err)
+			}
+			if ok != tt.want {
+				t.Errorf("EvaluateWithRunner() = %v (failed: %v), want %v", ok, failed, tt.want)
+			}
+		})
+	}
+
+	// a parameter named like the runner variable takes precedence
+	paramTypes = map[string]ParamConfig{RunnerVariable: {Type: "string"}}
+	compiled, err = NewCompiledConstraints([]string{"runner == 'fast'"}, paramTypes, testLogger)
+	if err != nil {
+		t.Fatalf("Failed to compile constraints: %v", err)
+	}
+	ok, _, err := compiled.EvaluateWithRunner(map[string]interface{}{"runner": "fast"}, paramTypes, "exec")
+	if err != nil || !ok {
+		t.Errorf("EvaluateWithRunner() with a 'runner' parameter = %v, %v, want true", ok, err)
+	}
+}
+
+func TestApplicableConstraints(t *testing.T) {
+	params := map[string]ParamConfig{"path": {Type: "string"}}
+	macros := map[string]string{"no_semicolon": "!path.contains(';')"}
+
+	constraints := []string{
+		"!path.contains(';')",         // applies
+		"no_semicolon",                // applies, through a macro
+		"runner != 'exec'",            // applies, the runner is always declared
+		"!message.contains(';')",      // skipped, 'message' is not a parameter
+		"path.size() < max_length",    // skipped, 'max_length' is not a parameter
+		"isSafePath(path)",            // kept, so the unknown function is reported
+		"path.startsWith('/') && 1 +", // kept, so the syntax error is reported
+	}
+
+	got := ApplicableConstraints(constraints, macros, params)
+	want := []string{"!path.contains(';')", "no_semicolon", "runner != 'exec'", "isSafePath(path)", "path.startsWith('/') && 1 +"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ApplicableConstraints() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,19 @@
+package common
+
+import (
+	"os"
+	"regexp"
+)
+
+// envReferenceRegex matches the ${VAR} references to environment variables
+var envReferenceRegex = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// ExpandEnv replaces the ${VAR} references in a string with the values of the
+// environment variables (an empty string for unset variables). Unlike os.ExpandEnv,
+// bare $VAR references are left untouched, so texts like prompts or shell snippets
+// can contain dollar signs.
+func ExpandEnv(s string) string {
+	return envReferenceRegex.ReplaceAllStringFunc(s, func(ref string) string {
+		return os.Getenv(envReferenceRegex.FindStringSubmatch(ref)[1])
+	})
+}
@@ -0,0 +1,27 @@
+package common
+
+import "testing"
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("MCPSHELL_TEST_REGION", "eu-west-1")
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"whole value", "${MCPSHELL_TEST_REGION}", "eu-west-1"},
+		{"inside text", "deploy to ${MCPSHELL_TEST_REGION} now", "deploy to eu-west-1 now"},
+		{"unset variable", "[${MCPSHELL_TEST_UNSET}]", "[]"},
+		{"bare reference untouched", "cost: $MCPSHELL_TEST_REGION $5", "cost: $MCPSHELL_TEST_REGION $5"},
+		{"no references", "plain text", "plain text"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExpandEnv(tt.input); got != tt.want {
+				t.Errorf("ExpandEnv(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"log"
 	"os"
+	"sync"
 )
 
 // Global application logger
@@ -52,6 +53,8 @@ type Logger struct {
 	filePath string
 	// The log file handle (if used)
 	file *os.File
+	// Protects the file handle when reopening
+	mu sync.Mutex
 }
 
 // NewLogger creates a new Logger instance
@@ -115,12 +118,41 @@ func NewLogger(prefix string, filePath string, level LogLevel, truncate bool) (*
 
 // Close closes the log file if it's open
 func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
 	if l.file != nil {
 		return l.file.Close()
 	}
 	return nil
 }
 
+// Reopen closes and reopens the log file, so logging continues in a new file
+// at the same path after it has been moved by an external log rotator (like logrotate).
+// It does nothing if the logger is not writing to a file.
+func (l *Logger) Reopen() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+
+	file, err := os.OpenFile(l.filePath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		return fmt.Errorf("failed to reopen log file: %w", err)
+	}
+
+	l.SetOutput(io.MultiWriter(os.Stderr, file))
+	oldFile := l.file
+	l.file = file
+
+	if err := oldFile.Close(); err != nil {
+		return fmt.Errorf("failed to close previous log file: %w", err)
+	}
+	return nil
+}
+
 // Debug logs a message at debug level
 func (l *Logger) Debug(format string, v ...interface{}) {
 	if l.level >= LogLevelDebug {
@@ -0,0 +1,64 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoggerReopen(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "mcpshell.log")
+	rotatedPath := logPath + ".1"
+
+	logger, err := NewLogger("", logPath, LogLevelInfo, true)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer func() { _ = logger.Close() }()
+
+	logger.Info("before rotation")
+
+	// simulate an external log rotator: move the file away and signal the reopen
+	if err := os.Rename(logPath, rotatedPath); err != nil {
+		t.Fatalf("Failed to rename log file: %v", err)
+	}
+	logger.Info("still in the rotated file")
+	if err := logger.Reopen(); err != nil {
+		t.Fatalf("Reopen() error = %v", err)
+	}
+	logger.Info("after rotation")
+
+	rotated, err := os.ReadFile(rotatedPath)
+	if err != nil {
+		t.Fatalf("Failed to read rotated log file: %v", err)
+	}
+	if !strings.Contains(string(rotated), "before rotation") || !strings.Contains(string(rotated), "still in the rotated file") {
+		t.Errorf("Expected the rotated file to contain the messages written before the reopen, got:\n%s", rotated)
+	}
+	if strings.Contains(string(rotated), "after rotation") {
+		t.Errorf("Expected no messages written after the reopen in the rotated file, got:\n%s", rotated)
+	}
+
+	current, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Failed to read new log file: %v", err)
+	}
+	if !strings.Contains(string(current), "after rotation") {
+		t.Errorf("Expected the new log file to contain the messages written after the reopen, got:\n%s", current)
+	}
+	if strings.Contains(string(current), "before rotation") {
+		t.Errorf("Expected the new log file to only contain new messages, got:\n%s", current)
+	}
+}
+
+func TestLoggerReopenWithoutFile(t *testing.T) {
+	logger, err := NewLogger("", "", LogLevelInfo, false)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	if err := logger.Reopen(); err != nil {
+		t.Errorf("Reopen() without a log file should be a no-op, got error = %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package common
 
 import (
 	"bytes"
+	"fmt"
 	"strings"
 	"text/template"
 
@@ -30,7 +31,7 @@ func ProcessTemplate(text string, args map[string]interface{}) (string, error) {
 
 	// Execute the template with the arguments
 	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, args); err != nil {
+	if err := tmpl.Execute(&buf, templateArgs(args)); err != nil {
 		return "", err
 	}
 
@@ -41,6 +42,40 @@ func ProcessTemplate(text string, args map[string]interface{}) (string, error) {
 	return res, nil
 }
 
+// templateList is a list argument of a template, rendered as its elements joined
+// by spaces (instead of the Go syntax, like "[a b c]"), while still supporting
+// functions like range, index, len or join.
+type templateList []interface{}
+
+// String returns the elements of the list joined by spaces
+func (l templateList) String() string {
+	elements := make([]string, len(l))
+	for i, e := range l {
+		elements[i] = fmt.Sprint(e)
+	}
+	return strings.Join(elements, " ")
+}
+
+// templateArgs returns the arguments of a template, with the lists converted to templateLists
+func templateArgs(args map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(args))
+	for k, v := range args {
+		switch list := v.(type) {
+		case []interface{}:
+			res[k] = templateList(list)
+		case []string:
+			l := make(templateList, len(list))
+			for i, e := range list {
+				l[i] = e
+			}
+			res[k] = l
+		default:
+			res[k] = v
+		}
+	}
+	return res
+}
+
 // ProcessTemplateListFlexible processes a list of templates with the given arguments.
 // It uses Go's template engine to substitute variables in the templates.
 // If the template processing fails, the original text is added to the result list.
@@ -0,0 +1,36 @@
+package common
+
+import "testing"
+
+func TestProcessTemplate_Arrays(t *testing.T) {
+	args := map[string]interface{}{
+		"files": []interface{}{"a.txt", "b.txt"},
+		"ports": []interface{}{8080.0, 9090.0},
+		"names": []string{"x", "y"},
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{"joined with spaces", "ls {{ .files }}", "ls a.txt b.txt"},
+		{"numbers", "nc -z host {{ .ports }}", "nc -z host 8080 9090"},
+		{"string slices", "echo {{ .names }}", "echo x y"},
+		{"range", "{{ range .files }}cat '{{ . }}'; {{ end }}", "cat 'a.txt'; cat 'b.txt'; "},
+		{"index and len", "{{ index .files 1 }} of {{ len .files }}", "b.txt of 2"},
+		{"sprig join", "{{ join \",\" .files }}", "a.txt,b.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ProcessTemplate(tt.template, args)
+			if err != nil {
+				t.Fatalf("ProcessTemplate() error = %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("ProcessTemplate() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package common
 
 import (
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 )
@@ -12,13 +13,176 @@ type OutputConfig struct {
 	// Prefix is a template string that gets prepended to the command output.
 	// It can use the same template variables as the command itself.
 	Prefix string `yaml:"prefix,omitempty"`
+
+	// Postfix is a template string that gets appended to the command output.
+	// It can use the same template variables as the command itself.
+	Postfix string `yaml:"postfix,omitempty"`
+
+	// Template is a template string that renders the whole output, instead of the
+	// Prefix and Postfix. It can use the same template variables as the command itself,
+	// as well as ".output" for the output of the command.
+	Template string `yaml:"template,omitempty"`
+
+	// IncludeCommand prepends the resolved command (after template processing)
+	// to the output, so clients can see exactly what was executed.
+	IncludeCommand bool `yaml:"include_command,omitempty"`
+
+	// IncludeStderr appends the standard error of the command (when not empty) to the
+	// output, after a delimiter. Otherwise stderr is only returned when the command fails.
+	IncludeStderr bool `yaml:"include_stderr,omitempty"`
+
+	// ExposeStderr attaches the standard error of successful commands (when not empty)
+	// to the `stderr` field of the result metadata, without changing the output.
+	ExposeStderr bool `yaml:"expose_stderr,omitempty"`
+
+	// PreserveWhitespace keeps the whitespace surrounding the output of the command
+	// (like the trailing newline), that is trimmed otherwise.
+	PreserveWhitespace bool `yaml:"preserve_whitespace,omitempty"`
+
+	// StderrOnly returns the standard error of the command as the output, instead of
+	// the standard output (for tools writing their results to stderr).
+	StderrOnly bool `yaml:"stderr_only,omitempty"`
+
+	// Normalize trims the trailing whitespace of every line and collapses consecutive
+	// blank lines in the command output, so it wastes fewer tokens.
+	Normalize bool `yaml:"normalize,omitempty"`
+
+	// Summarize runs the command output through a summarization command when
+	// the output is larger than a threshold.
+	Summarize *SummarizeConfig `yaml:"summarize,omitempty"`
+
+	// RedactURLs rewrites the URLs found in the command output, so internal
+	// URLs are not leaked to the LLM.
+	RedactURLs *RedactURLsConfig `yaml:"redact_urls,omitempty"`
+
+	// AsResource stores the output as an MCP resource and returns a link to it,
+	// instead of returning the output in the tool result.
+	AsResource bool `yaml:"as_resource,omitempty"`
+
+	// Format is the format of the standard output of the command: "text" (the default)
+	// or "json", where the output is validated and pretty-printed.
+	Format string `yaml:"format,omitempty"`
+
+	// JSONPath extracts a subtree of the JSON output (like "$.items[0].name"),
+	// implying the "json" format.
+	JSONPath string `yaml:"jsonpath,omitempty"`
+
+	// Regex extracts the parts of the standard output matching the regular expression,
+	// returning the matches one per line.
+	Regex string `yaml:"regex,omitempty"`
+
+	// RegexReplace replaces the matches of Regex in the standard output with this
+	// template (where "$1" or "${name}" are the groups of the match), instead of
+	// extracting them. It can be empty for removing the matches.
+	RegexReplace *string `yaml:"regex_replace,omitempty"`
+
+	// MaxBytes is the maximum size of the output: longer outputs are truncated, with
+	// a notice of the bytes omitted. Defaults to DefaultOutputMaxBytes (negative for
+	// no limit), except for outputs stored as resources, that are not limited by default.
+	MaxBytes int `yaml:"max_bytes,omitempty"`
+
+	// MaxLines is the maximum number of lines of the output: only the first lines are
+	// kept, with a notice of the lines omitted. There is no limit when it is not set.
+	MaxLines int `yaml:"max_lines,omitempty"`
+}
+
+// DefaultOutputMaxBytes is the maximum size (in bytes) of the output of the tools
+// that do not configure one, so huge outputs do not fill the context of the LLM.
+const DefaultOutputMaxBytes = 100 * 1024
+
+// GetMaxBytes returns the maximum size of the output, or 0 when there is no limit
+func (o *OutputConfig) GetMaxBytes() int {
+	switch {
+	case o.MaxBytes < 0:
+		return 0
+	case o.MaxBytes == 0 && o.AsResource:
+		// outputs stored as resources are not sent to the LLM, so they can be large
+		return 0
+	case o.MaxBytes == 0:
+		return DefaultOutputMaxBytes
+	}
+	return o.MaxBytes
+}
+
+// GetRegex compiles the Regex of the output, returning nil when there is none
+func (o *OutputConfig) GetRegex() (*regexp.Regexp, error) {
+	if o.Regex == "" {
+		if o.RegexReplace != nil {
+			return nil, fmt.Errorf("regex_replace requires a regex")
+		}
+		return nil, nil
+	}
+
+	re, err := regexp.Compile(o.Regex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex '%s': %w", o.Regex, err)
+	}
+	return re, nil
+}
+
+// Formats of the output of the tools
+const (
+	// OutputFormatText returns the output as it is
+	OutputFormatText = "text"
+
+	// OutputFormatJSON validates the output as JSON and pretty-prints it
+	OutputFormatJSON = "json"
+)
+
+// DefaultSummarizeThreshold is the output size (in bytes) above which the output
+// is summarized when no threshold is configured.
+const DefaultSummarizeThreshold = 8000
+
+// SummarizeConfig defines how oversized tool outputs are summarized.
+type SummarizeConfig struct {
+	// Threshold is the output size (in bytes) above which the output is summarized.
+	// Defaults to DefaultSummarizeThreshold.
+	Threshold int `yaml:"threshold,omitempty"`
+
+	// Command is a shell command that receives the output in its standard input
+	// and writes the summary to its standard output.
+	Command string `yaml:"command"`
+}
+
+// DefaultRedactedURLPlaceholder is the text that replaces the redacted URLs
+// when no placeholder is configured.
+const DefaultRedactedURLPlaceholder = "[REDACTED URL]"
+
+// RedactURLsConfig defines how the URLs in the tool outputs are redacted.
+type RedactURLsConfig struct {
+	// KeepHost keeps the scheme and the host of the URLs, stripping only the rest
+	// (path, query, fragment and credentials). Otherwise, the whole URL is replaced.
+	KeepHost bool `yaml:"keep_host,omitempty"`
+
+	// Placeholder is the text that replaces the URLs (when KeepHost is false).
+	// Defaults to DefaultRedactedURLPlaceholder.
+	Placeholder string `yaml:"placeholder,omitempty"`
+}
+
+// GetPlaceholder returns the configured placeholder, or the default one if not set
+func (r *RedactURLsConfig) GetPlaceholder() string {
+	if r.Placeholder == "" {
+		return DefaultRedactedURLPlaceholder
+	}
+	return r.Placeholder
+}
+
+// GetThreshold returns the configured threshold, or the default one if not set
+func (s *SummarizeConfig) GetThreshold() int {
+	if s.Threshold <= 0 {
+		return DefaultSummarizeThreshold
+	}
+	return s.Threshold
 }
 
 // ParamConfig defines the configuration for a single parameter in a tool.
 type ParamConfig struct {
-	// Type specifies the parameter data type. Valid values: "string" (default), "number"/"integer", "boolean"
+	// Type specifies the parameter data type. Valid values: "string" (default), "number"/"integer", "boolean", "array"
 	Type string `yaml:"type,omitempty"`
 
+	// Items defines the type of the elements of "array" parameters (strings when not specified)
+	Items *ParamConfig `yaml:"items,omitempty"`
+
 	// Description provides information about the parameter's purpose
 	Description string `yaml:"description"`
 
@@ -27,6 +191,59 @@ type ParamConfig struct {
 
 	// Default specifies a default value to use when the parameter is not provided
 	Default interface{} `yaml:"default,omitempty"`
+
+	// Enum restricts the parameter to one of the given values
+	Enum []interface{} `yaml:"enum,omitempty"`
+
+	// Pattern is a regular expression that the values of string parameters must match
+	Pattern string `yaml:"pattern,omitempty"`
+
+	// Minimum and Maximum are the (inclusive) bounds for the values of numeric parameters
+	Minimum *float64 `yaml:"minimum,omitempty"`
+	Maximum *float64 `yaml:"maximum,omitempty"`
+
+	// Min and Max are short forms of Minimum and Maximum
+	Min *float64 `yaml:"min,omitempty"`
+	Max *float64 `yaml:"max,omitempty"`
+
+	// MinLength and MaxLength are the (inclusive) bounds for the length (in characters)
+	// of the values of string parameters
+	MinLength *int `yaml:"min_length,omitempty"`
+	MaxLength *int `yaml:"max_length,omitempty"`
+
+	// Transform is a list of operations applied (in order) to the values of string
+	// parameters before they are checked and used: "trim", "lower", "upper", and
+	// "default" (that replaces empty values with the Default)
+	Transform []string `yaml:"transform,omitempty"`
+
+	// ValueMap translates the values of string parameters (like aliases) to canonical
+	// values, after the Transform. Values not in the map are kept as they are.
+	ValueMap map[string]string `yaml:"value_map,omitempty"`
+}
+
+// GetMinimum returns the minimum value of a numeric parameter (from Minimum or Min), or nil
+func (p ParamConfig) GetMinimum() *float64 {
+	if p.Minimum != nil {
+		return p.Minimum
+	}
+	return p.Min
+}
+
+// GetMaximum returns the maximum value of a numeric parameter (from Maximum or Max), or nil
+func (p ParamConfig) GetMaximum() *float64 {
+	if p.Maximum != nil {
+		return p.Maximum
+	}
+	return p.Max
+}
+
+// GetItems returns the configuration of the elements of an "array" parameter,
+// defaulting to strings when the items are not specified
+func (p ParamConfig) GetItems() ParamConfig {
+	if p.Items == nil {
+		return ParamConfig{Type: "string"}
+	}
+	return *p.Items
 }
 
 // LoggingConfig defines configuration options for application logging.
@@ -88,3 +305,25 @@ func ConvertStringToType(value string, paramType string) (interface{}, error) {
 		return nil, fmt.Errorf("unsupported parameter type: %s", paramType)
 	}
 }
+
+// ConvertStringToParam converts a string value to the type of the given parameter, like
+// ConvertStringToType, but also supporting "array" parameters, given as comma-separated
+// lists of elements (converted to the type of the items).
+func ConvertStringToParam(value string, param ParamConfig) (interface{}, error) {
+	if param.Type != "array" {
+		return ConvertStringToType(value, param.Type)
+	}
+
+	items := []interface{}{}
+	if value == "" {
+		return items, nil
+	}
+	for _, element := range strings.Split(value, ",") {
+		item, err := ConvertStringToParam(strings.TrimSpace(element), param.GetItems())
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
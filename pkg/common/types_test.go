@@ -1,6 +1,7 @@
 package common
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -45,6 +46,34 @@ func TestConvertStringToType(t *testing.T) {
 	}
 }
 
+func TestConvertStringToParam(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		param       ParamConfig
+		expected    interface{}
+		expectError bool
+	}{
+		{"scalar value", "42", ParamConfig{Type: "integer"}, int64(42), false},
+		{"array of strings", "a.txt, b.txt", ParamConfig{Type: "array"}, []interface{}{"a.txt", "b.txt"}, false},
+		{"array of numbers", "1,2.5", ParamConfig{Type: "array", Items: &ParamConfig{Type: "number"}}, []interface{}{1.0, 2.5}, false},
+		{"empty array", "", ParamConfig{Type: "array"}, []interface{}{}, false},
+		{"invalid array item", "1,two", ParamConfig{Type: "array", Items: &ParamConfig{Type: "number"}}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ConvertStringToParam(tt.value, tt.param)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("ConvertStringToParam() error = %v, expectError %v", err, tt.expectError)
+			}
+			if !tt.expectError && !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Expected %v (%T), got %v (%T)", tt.expected, tt.expected, result, result)
+			}
+		})
+	}
+}
+
 // Mock CommandHandler to test default parameter values
 type mockCommandHandler struct {
 	params map[string]ParamConfig
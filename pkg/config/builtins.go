@@ -0,0 +1,53 @@
+package config
+
+import (
+	"github.com/inercia/MCPShell/pkg/common"
+)
+
+// BuiltinToolPrefix is the prefix of the names of the built-in tools, so they
+// cannot clash with the tools of a configuration file
+const BuiltinToolPrefix = "__"
+
+// BuiltinTools returns the built-in diagnostic tools, which can be used for
+// smoke-testing a deployment without any configuration file.
+// They are defined like any other tool, so they go through the same
+// constraints and runners pipeline as the tools of a configuration file.
+func BuiltinTools() []MCPToolConfig {
+	return []MCPToolConfig{
+		{
+			Name:        BuiltinToolPrefix + "echo",
+			Description: "Diagnostic tool: returns the given message",
+			Params: map[string]common.ParamConfig{
+				"message": {Type: "string", Description: "The message to echo", Required: true},
+			},
+			Constraints: []string{`!message.contains("'")`},
+			Run: MCPToolRunConfig{
+				Command: "printf '%s\\n' '{{ .message }}'",
+			},
+			ReadOnly:   true,
+			Idempotent: true,
+		},
+		{
+			Name:        BuiltinToolPrefix + "sleep",
+			Description: "Diagnostic tool: waits for the given number of seconds (at most 60)",
+			Params: map[string]common.ParamConfig{
+				"seconds": {Type: "number", Description: "The number of seconds to wait", Required: true},
+			},
+			Constraints: []string{"seconds >= 0.0", "seconds <= 60.0"},
+			Run: MCPToolRunConfig{
+				Command: "sleep {{ .seconds }} && echo 'done'",
+			},
+			ReadOnly:   true,
+			Idempotent: true,
+		},
+		{
+			Name:        BuiltinToolPrefix + "env",
+			Description: "Diagnostic tool: returns the environment variables seen by the tools",
+			Run: MCPToolRunConfig{
+				Command: "env | sort",
+			},
+			ReadOnly:   true,
+			Idempotent: true,
+		},
+	}
+}
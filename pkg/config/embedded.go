@@ -0,0 +1,75 @@
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/inercia/MCPShell/pkg/common"
+)
+
+// ResolveEmbeddedConfig resolves the configuration from the YAML files found in a
+// filesystem (like the tools embedded in the binary), merging them like the files of
+// a configuration directory.
+// Returns the local path to the configuration file and a cleanup function
+// that should be deferred to remove the temporary files.
+func ResolveEmbeddedConfig(fsys fs.FS, logger *common.Logger) (string, func(), error) {
+	noopCleanup := func() {}
+
+	if fsys == nil {
+		return "", noopCleanup, fmt.Errorf("no embedded configuration available")
+	}
+
+	// Copy the YAML files to a temporary directory, so they are parsed like any other directory
+	tmpDir, err := os.MkdirTemp("", "mcp-config-embedded-*")
+	if err != nil {
+		return "", noopCleanup, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	removeTmpDir := func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			logger.Error("Failed to remove temporary embedded config directory: %v", err)
+		}
+		logger.Debug("Cleaned up temporary embedded configuration directory: %s", tmpDir)
+	}
+
+	err = fs.WalkDir(fsys, ".", func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		ext := strings.ToLower(path.Ext(filePath))
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			return nil
+		}
+
+		data, err := fs.ReadFile(fsys, filePath)
+		if err != nil {
+			return err
+		}
+
+		localPath := filepath.Join(tmpDir, filepath.FromSlash(filePath))
+		if err := os.MkdirAll(filepath.Dir(localPath), 0o700); err != nil {
+			return err
+		}
+		logger.Debug("Found embedded YAML file: %s", filePath)
+		return os.WriteFile(localPath, data, 0o600)
+	})
+	if err != nil {
+		removeTmpDir()
+		return "", noopCleanup, fmt.Errorf("failed to read embedded configuration: %w", err)
+	}
+
+	configPath, cleanup, err := resolveConfigDirectory(tmpDir, logger)
+	if err != nil {
+		removeTmpDir()
+		return "", noopCleanup, err
+	}
+
+	return configPath, func() {
+		cleanup()
+		removeTmpDir()
+	}, nil
+}
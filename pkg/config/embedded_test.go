@@ -0,0 +1,67 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/inercia/MCPShell/pkg/common"
+)
+
+func TestResolveEmbeddedConfig(t *testing.T) {
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+
+	fsys := fstest.MapFS{
+		"files.yaml": &fstest.MapFile{Data: []byte(`mcp:
+  description: "Embedded tools"
+  tools:
+    - name: "list_files"
+      description: "List files"
+      run:
+        command: "ls"
+`)},
+		"net/ping.yml": &fstest.MapFile{Data: []byte(`mcp:
+  tools:
+    - name: "ping"
+      description: "Ping a host"
+      run:
+        command: "ping -c 1 localhost"
+`)},
+		"README.md": &fstest.MapFile{Data: []byte("not a configuration file")},
+	}
+
+	configPath, cleanup, err := ResolveEmbeddedConfig(fsys, logger)
+	if err != nil {
+		t.Fatalf("ResolveEmbeddedConfig() error = %v", err)
+	}
+
+	cfg, err := NewConfigFromFile(configPath)
+	if err != nil {
+		t.Fatalf("NewConfigFromFile() error = %v", err)
+	}
+	if cfg.MCP.Description != "Embedded tools" {
+		t.Errorf("Expected the description of the embedded config, got %q", cfg.MCP.Description)
+	}
+
+	names := map[string]bool{}
+	for _, tool := range cfg.MCP.Tools {
+		names[tool.Name] = true
+	}
+	if len(cfg.MCP.Tools) != 2 || !names["list_files"] || !names["ping"] {
+		t.Errorf("Expected the tools of all the embedded files, got %v", names)
+	}
+
+	// the temporary files are removed by the cleanup
+	cleanup()
+	if _, err := os.Stat(configPath); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed, got %v", configPath, err)
+	}
+
+	// without embedded files there is nothing to resolve
+	if _, _, err := ResolveEmbeddedConfig(fstest.MapFS{}, logger); err == nil {
+		t.Errorf("Expected an error for an empty embedded configuration")
+	}
+	if _, _, err := ResolveEmbeddedConfig(nil, logger); err == nil {
+		t.Errorf("Expected an error without an embedded configuration")
+	}
+}
@@ -0,0 +1,200 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/inercia/MCPShell/pkg/common"
+)
+
+// loadConfigWithOverlay loads a base configuration file and deep-merges an overlay
+// configuration file on top of it.
+//
+// Unlike LoadAndMergeConfigs (that appends the tools of all the files), the overlay
+// can override any field of the base configuration:
+// - Maps are merged recursively, with the overlay values taking precedence
+// - Lists of named items (like tools or runners) are merged by name: items in the
+// overlay are merged into the base item with the same name, or appended if not found
+// - Other lists and scalar values in the overlay replace the base ones
+// - A null value in the overlay removes the field from the base
+//
+// Parameters:
+//   - basePath: Path to the base YAML configuration file
+//   - overlayPath: Path to the overlay YAML configuration file
+//
+// Returns:
+//   - A pointer to the resulting Config structure
+//   - An error if loading or merging fails
+func loadConfigWithOverlay(basePath, overlayPath string) (*ToolsConfig, error) {
+	data, err := mergeOverlayFiles(basePath, overlayPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var config ToolsConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse overlaid configuration: %w", err)
+	}
+
+	return &config, nil
+}
+
+// ResolveConfigPathsWithOverlay resolves the configuration paths like ResolveMultipleConfigPaths,
+// and then applies the overlay configuration (if not empty) on top of the result.
+// The overlay path is resolved like any other configuration path (so it can be a
+// name in the tools directory, a relative path or a URL).
+// Returns the local path to the resulting configuration file and a cleanup function.
+func ResolveConfigPathsWithOverlay(configPaths []string, overlay string, logger *common.Logger) (string, func(), error) {
+	basePath, baseCleanup, err := ResolveMultipleConfigPaths(configPaths, logger)
+	if err != nil {
+		return "", func() {}, err
+	}
+	if overlay == "" {
+		return basePath, baseCleanup, nil
+	}
+
+	overlayPath, overlayCleanup, err := ResolveConfigPath(overlay, logger)
+	if err != nil {
+		baseCleanup()
+		return "", func() {}, fmt.Errorf("failed to resolve overlay configuration %s: %w", overlay, err)
+	}
+
+	cleanup := func() {
+		overlayCleanup()
+		baseCleanup()
+	}
+
+	data, err := mergeOverlayFiles(basePath, overlayPath)
+	if err != nil {
+		cleanup()
+		return "", func() {}, err
+	}
+
+	tmpFile, err := os.CreateTemp(os.TempDir(), "mcp-config-overlay-*.yaml")
+	if err != nil {
+		cleanup()
+		return "", func() {}, fmt.Errorf("failed to create temporary overlaid config file: %w", err)
+	}
+	tmpFilePath := tmpFile.Name()
+
+	finalCleanup := func() {
+		if err := os.Remove(tmpFilePath); err != nil {
+			logger.Error("Failed to remove temporary overlaid config file: %v", err)
+		}
+		logger.Debug("Cleaned up temporary overlaid configuration file: %s", tmpFilePath)
+		cleanup()
+	}
+
+	if _, err := tmpFile.Write(data); err != nil {
+		_ = tmpFile.Close()
+		finalCleanup()
+		return "", func() {}, fmt.Errorf("failed to write overlaid configuration to temporary file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		finalCleanup()
+		return "", func() {}, fmt.Errorf("failed to close temporary overlaid config file: %w", err)
+	}
+
+	logger.Info("Applied overlay configuration %s: %s", overlayPath, tmpFilePath)
+	return tmpFilePath, finalCleanup, nil
+}
+
+// mergeOverlayFiles deep-merges the overlay file into the base file, returning the resulting YAML
+func mergeOverlayFiles(basePath, overlayPath string) ([]byte, error) {
+	base, err := readYAMLDocument(basePath)
+	if err != nil {
+		return nil, err
+	}
+	overlay, err := readYAMLDocument(overlayPath)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := yaml.Marshal(deepMerge(base, overlay))
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize overlaid configuration: %w", err)
+	}
+	return data, nil
+}
+
+// readYAMLDocument reads a YAML file into a generic structure
+func readYAMLDocument(path string) (interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	if doc == nil {
+		doc = map[string]interface{}{}
+	}
+	return doc, nil
+}
+
+// deepMerge merges the overlay value into the base value (see loadConfigWithOverlay)
+func deepMerge(base, overlay interface{}) interface{} {
+	switch overlayValue := overlay.(type) {
+	case map[string]interface{}:
+		baseMap, ok := base.(map[string]interface{})
+		if !ok {
+			return overlayValue
+		}
+		result := make(map[string]interface{}, len(baseMap))
+		for k, v := range baseMap {
+			result[k] = v
+		}
+		for k, v := range overlayValue {
+			if v == nil {
+				delete(result, k)
+				continue
+			}
+			result[k] = deepMerge(result[k], v)
+		}
+		return result
+
+	case []interface{}:
+		baseList, ok := base.([]interface{})
+		if !ok || !isNamedList(baseList) || !isNamedList(overlayValue) {
+			return overlayValue
+		}
+		result := make([]interface{}, len(baseList))
+		copy(result, baseList)
+		for _, item := range overlayValue {
+			name := item.(map[string]interface{})["name"]
+			merged := false
+			for i, baseItem := range result {
+				if baseItem.(map[string]interface{})["name"] == name {
+					result[i] = deepMerge(baseItem, item)
+					merged = true
+					break
+				}
+			}
+			if !merged {
+				result = append(result, item)
+			}
+		}
+		return result
+
+	default:
+		return overlay
+	}
+}
+
+// isNamedList returns true if all the items in the list are maps with a "name"
+func isNamedList(list []interface{}) bool {
+	for _, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if _, hasName := m["name"]; !hasName {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/inercia/MCPShell/pkg/common"
+)
+
+const overlayTestBase = `mcp:
+  description: "Base tools"
+  run:
+    shell: bash
+  tools:
+    - name: "list_files"
+      description: "List files"
+      params:
+        path:
+          type: string
+          description: "Path to list"
+      constraints:
+        - "path.size() < 100"
+      run:
+        command: "ls -la {{ .path }}"
+        runners:
+          - name: exec
+    - name: "disk_usage"
+      description: "Show disk usage"
+      run:
+        command: "df -h"
+`
+
+const overlayTestProd = `mcp:
+  description: "Production tools"
+  tools:
+    - name: "list_files"
+      constraints:
+        - "path.startsWith('/var/log/')"
+        - "!path.contains('../')"
+      run:
+        command: "ls {{ .path }}"
+    - name: "uptime"
+      description: "Show uptime"
+      run:
+        command: "uptime"
+`
+
+func writeOverlayTestFiles(t *testing.T) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "base.yaml")
+	prodPath := filepath.Join(dir, "prod.yaml")
+	if err := os.WriteFile(basePath, []byte(overlayTestBase), 0o644); err != nil {
+		t.Fatalf("Failed to write base config: %v", err)
+	}
+	if err := os.WriteFile(prodPath, []byte(overlayTestProd), 0o644); err != nil {
+		t.Fatalf("Failed to write overlay config: %v", err)
+	}
+	return basePath, prodPath
+}
+
+func TestLoadConfigWithOverlay(t *testing.T) {
+	basePath, prodPath := writeOverlayTestFiles(t)
+
+	cfg, err := loadConfigWithOverlay(basePath, prodPath)
+	if err != nil {
+		t.Fatalf("loadConfigWithOverlay() error = %v", err)
+	}
+
+	if cfg.MCP.Description != "Production tools" {
+		t.Errorf("Expected overridden description, got %q", cfg.MCP.Description)
+	}
+	if cfg.MCP.Run.Shell != "bash" {
+		t.Errorf("Expected shell from the base config, got %q", cfg.MCP.Run.Shell)
+	}
+
+	if len(cfg.MCP.Tools) != 3 {
+		t.Fatalf("Expected 3 tools, got %d", len(cfg.MCP.Tools))
+	}
+
+	listFiles := cfg.MCP.Tools[0]
+	if listFiles.Name != "list_files" {
+		t.Fatalf("Expected the first tool to be 'list_files', got %q", listFiles.Name)
+	}
+	if listFiles.Run.Command != "ls {{ .path }}" {
+		t.Errorf("Expected overridden command, got %q", listFiles.Run.Command)
+	}
+	if len(listFiles.Constraints) != 2 || listFiles.Constraints[0] != "path.startsWith('/var/log/')" {
+		t.Errorf("Expected overridden constraints, got %v", listFiles.Constraints)
+	}
+	// fields not present in the overlay are kept from the base
+	if listFiles.Description != "List files" {
+		t.Errorf("Expected description from the base tool, got %q", listFiles.Description)
+	}
+	if _, ok := listFiles.Params["path"]; !ok {
+		t.Errorf("Expected params from the base tool, got %v", listFiles.Params)
+	}
+	if len(listFiles.Run.Runners) != 1 || listFiles.Run.Runners[0].Name != "exec" {
+		t.Errorf("Expected runners from the base tool, got %v", listFiles.Run.Runners)
+	}
+
+	if cfg.MCP.Tools[1].Name != "disk_usage" || cfg.MCP.Tools[1].Run.Command != "df -h" {
+		t.Errorf("Expected the 'disk_usage' tool to be unchanged, got %+v", cfg.MCP.Tools[1])
+	}
+	if cfg.MCP.Tools[2].Name != "uptime" {
+		t.Errorf("Expected the new 'uptime' tool to be appended, got %q", cfg.MCP.Tools[2].Name)
+	}
+}
+
+func TestDeepMerge_NullRemovesField(t *testing.T) {
+	base := map[string]interface{}{
+		"constraints": []interface{}{"a", "b"},
+		"command":     "ls",
+	}
+	overlay := map[string]interface{}{
+		"constraints": nil,
+	}
+
+	merged := deepMerge(base, overlay).(map[string]interface{})
+	if _, exists := merged["constraints"]; exists {
+		t.Errorf("Expected 'constraints' to be removed, got %v", merged)
+	}
+	if merged["command"] != "ls" {
+		t.Errorf("Expected 'command' to be kept, got %v", merged["command"])
+	}
+}
+
+func TestResolveConfigPathsWithOverlay(t *testing.T) {
+	basePath, prodPath := writeOverlayTestFiles(t)
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+
+	// without overlay, the base path is used as is
+	path, cleanup, err := ResolveConfigPathsWithOverlay([]string{basePath}, "", logger)
+	if err != nil {
+		t.Fatalf("ResolveConfigPathsWithOverlay() error = %v", err)
+	}
+	cleanup()
+	if path != basePath {
+		t.Errorf("Expected %s without overlay, got %s", basePath, path)
+	}
+
+	path, cleanup, err = ResolveConfigPathsWithOverlay([]string{basePath}, prodPath, logger)
+	if err != nil {
+		t.Fatalf("ResolveConfigPathsWithOverlay() error = %v", err)
+	}
+
+	cfg, err := NewConfigFromFile(path)
+	if err != nil {
+		t.Fatalf("Failed to load overlaid config: %v", err)
+	}
+	if cfg.MCP.Tools[0].Run.Command != "ls {{ .path }}" {
+		t.Errorf("Expected overridden command, got %q", cfg.MCP.Tools[0].Run.Command)
+	}
+
+	cleanup()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the overlaid config file to be removed by the cleanup")
+	}
+}
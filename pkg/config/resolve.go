@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/inercia/MCPShell/pkg/common"
 	"github.com/inercia/MCPShell/pkg/utils"
@@ -78,28 +79,10 @@ func ResolveConfigPath(configPath string, logger *common.Logger) (string, func()
 			logger.Debug("Cleaned up temporary configuration file: %s", tmpFilePath)
 		}
 
-		// Download the file
-		resp, err := http.Get(configPath)
-		if err != nil {
+		// Download the file (resuming it if the download is interrupted)
+		if err := downloadConfig(configPath, tmpFile, logger); err != nil {
 			cleanup()
-			return "", noopCleanup, fmt.Errorf("failed to download configuration: %w", err)
-		}
-		defer func() {
-			if err := resp.Body.Close(); err != nil {
-				logger.Error("Failed to close response body: %v", err)
-			}
-		}()
-
-		if resp.StatusCode != http.StatusOK {
-			cleanup()
-			return "", noopCleanup, fmt.Errorf("failed to download configuration, status code: %d", resp.StatusCode)
-		}
-
-		// Copy the response body to the temporary file
-		_, err = io.Copy(tmpFile, resp.Body)
-		if err != nil {
-			cleanup()
-			return "", noopCleanup, fmt.Errorf("failed to write configuration to temporary file: %w", err)
+			return "", noopCleanup, err
 		}
 
 		// Close the file after writing
@@ -115,6 +98,100 @@ func ResolveConfigPath(configPath string, logger *common.Logger) (string, func()
 	return "", noopCleanup, fmt.Errorf("unsupported URL scheme: %s", parsedURL.Scheme)
 }
 
+// configDownloadRetries is the number of times an interrupted configuration download is retried
+const configDownloadRetries = 3
+
+// configDownloadRetryDelay is the delay between configuration download retries
+var configDownloadRetryDelay = time.Second
+
+// downloadConfig downloads the configuration at the given URL into the file.
+// If the download is interrupted, it is retried a few times. When the server supports
+// HTTP ranges, the retry only requests the remaining part of the file instead of
+// downloading it from scratch.
+func downloadConfig(configURL string, file *os.File, logger *common.Logger) error {
+	var written int64
+	var lastErr error
+	acceptsRanges := false
+
+	for attempt := 0; attempt <= configDownloadRetries; attempt++ {
+		if attempt > 0 {
+			logger.Info("Retrying configuration download (attempt %d of %d): %v", attempt, configDownloadRetries, lastErr)
+			time.Sleep(configDownloadRetryDelay)
+		}
+
+		req, err := http.NewRequest(http.MethodGet, configURL, nil)
+		if err != nil {
+			return fmt.Errorf("failed to download configuration: %w", err)
+		}
+		if written > 0 && acceptsRanges {
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", written))
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		switch {
+		case resp.StatusCode == http.StatusOK:
+			// we get the whole file: start again from the beginning
+			if written > 0 {
+				if err := resetFile(file); err != nil {
+					_ = resp.Body.Close()
+					return err
+				}
+				written = 0
+			}
+			acceptsRanges = resp.Header.Get("Accept-Ranges") == "bytes"
+
+		case resp.StatusCode == http.StatusPartialContent:
+			if !strings.HasPrefix(resp.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", written)) {
+				// unexpected range: do not trust ranges anymore and download the whole file
+				_ = resp.Body.Close()
+				lastErr = fmt.Errorf("unexpected content range: %s", resp.Header.Get("Content-Range"))
+				acceptsRanges = false
+				continue
+			}
+			logger.Debug("Resuming configuration download from byte %d", written)
+
+		case resp.StatusCode >= http.StatusInternalServerError:
+			_ = resp.Body.Close()
+			lastErr = fmt.Errorf("status code: %d", resp.StatusCode)
+			continue
+
+		default:
+			_ = resp.Body.Close()
+			return fmt.Errorf("failed to download configuration, status code: %d", resp.StatusCode)
+		}
+
+		n, err := io.Copy(file, resp.Body)
+		written += n
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			logger.Error("Failed to close response body: %v", closeErr)
+		}
+		if err == nil {
+			return nil
+		}
+
+		lastErr = err
+		logger.Info("Configuration download interrupted after %d bytes", written)
+	}
+
+	return fmt.Errorf("failed to download configuration after %d attempts: %w", configDownloadRetries+1, lastErr)
+}
+
+// resetFile truncates the file and moves to its beginning
+func resetFile(file *os.File) error {
+	if err := file.Truncate(0); err != nil {
+		return fmt.Errorf("failed to truncate temporary file: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind temporary file: %w", err)
+	}
+	return nil
+}
+
 // resolveConfigDirectory finds all YAML files in a directory and creates a merged configuration file.
 // Returns the path to the merged configuration file and a cleanup function.
 func resolveConfigDirectory(dirPath string, logger *common.Logger) (string, func(), error) {
@@ -284,3 +361,35 @@ func ResolveMultipleConfigPaths(configPaths []string, logger *common.Logger) (st
 	logger.Info("Successfully resolved and merged %d configuration paths", len(configPaths))
 	return mergedPath, finalCleanup, nil
 }
+
+// LocalConfigPaths returns the local files and directories the configuration paths
+// are resolved from (for example, for watching them for changes). Remote URLs are
+// skipped, directories are returned as they are, and files are resolved like
+// ResolveConfigPath does (so names in the tools directory are found too).
+func LocalConfigPaths(configPaths []string) []string {
+	var paths []string
+	for _, configPath := range configPaths {
+		parsedURL, err := url.Parse(configPath)
+		if err != nil || configPath == "" {
+			continue
+		}
+		if parsedURL.Scheme != "" && parsedURL.Scheme != "file" {
+			continue
+		}
+
+		localPath := configPath
+		if parsedURL.Scheme == "file" {
+			localPath = parsedURL.Path
+		}
+
+		if info, statErr := os.Stat(localPath); statErr == nil && info.IsDir() {
+			paths = append(paths, localPath)
+			continue
+		}
+
+		if resolvedPath, err := utils.ResolveToolsFile(localPath); err == nil {
+			paths = append(paths, resolvedPath)
+		}
+	}
+	return paths
+}
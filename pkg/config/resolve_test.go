@@ -0,0 +1,108 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/inercia/MCPShell/pkg/common"
+)
+
+func TestResolveConfigPath_ResumeInterruptedDownload(t *testing.T) {
+	configDownloadRetryDelay = 0
+	defer func() { configDownloadRetryDelay = time.Second }()
+
+	content := "mcp:\n  description: \"Remote tools\"\n  tools:\n" + strings.Repeat("    # padding for a large configuration file\n", 200) +
+		"    - name: \"hello\"\n      description: \"Say hello\"\n      run:\n        command: \"echo hello\"\n"
+	half := len(content) / 2
+
+	var mu sync.Mutex
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		first := len(ranges) == 1
+		mu.Unlock()
+
+		if first {
+			// send half of the file and drop the connection
+			w.Header().Set("Accept-Ranges", "bytes")
+			w.Header().Set("Content-Length", fmt.Sprintf("%d", len(content)))
+			_, _ = w.Write([]byte(content[:half]))
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeContent(w, r, "config.yaml", time.Time{}, strings.NewReader(content))
+	}))
+	defer srv.Close()
+
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+	path, cleanup, err := ResolveConfigPath(srv.URL+"/config.yaml", logger)
+	if err != nil {
+		t.Fatalf("ResolveConfigPath() error = %v", err)
+	}
+	defer cleanup()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read downloaded config: %v", err)
+	}
+	if string(data) != content {
+		t.Errorf("Downloaded config does not match the original (got %d bytes, want %d)", len(data), len(content))
+	}
+
+	if len(ranges) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(ranges))
+	}
+	if ranges[0] != "" {
+		t.Errorf("Expected no range in the first request, got %q", ranges[0])
+	}
+	if want := fmt.Sprintf("bytes=%d-", half); ranges[1] != want {
+		t.Errorf("Expected the retry to request the range %q, got %q", want, ranges[1])
+	}
+
+	cfg, err := NewConfigFromFile(path)
+	if err != nil {
+		t.Fatalf("Failed to load downloaded config: %v", err)
+	}
+	if len(cfg.MCP.Tools) != 1 || cfg.MCP.Tools[0].Name != "hello" {
+		t.Errorf("Expected the 'hello' tool in the downloaded config, got %+v", cfg.MCP.Tools)
+	}
+}
+
+func TestResolveConfigPath_DownloadNotFound(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+	_, _, err := ResolveConfigPath(srv.URL+"/missing.yaml", logger)
+	if err == nil || !strings.Contains(err.Error(), "status code: 404") {
+		t.Errorf("ResolveConfigPath() error = %v, want a 404 error", err)
+	}
+}
+
+func TestLocalConfigPaths(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "tools.yaml")
+	if err := os.WriteFile(file, []byte("mcp: {}\n"), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	got := LocalConfigPaths([]string{
+		file,
+		"file://" + file,
+		dir,
+		"https://example.com/tools.yaml",
+		filepath.Join(dir, "missing.yaml"),
+	})
+	want := []string{file, file, dir}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("LocalConfigPaths() = %v, want %v", got, want)
+	}
+}
@@ -1,6 +1,11 @@
 package config
 
 import (
+	"context"
+	"os/exec"
+	"runtime"
+	"time"
+
 	"github.com/mark3labs/mcp-go/mcp"
 
 	"github.com/inercia/MCPShell/pkg/common"
@@ -17,6 +22,25 @@ type Tool struct {
 	// SelectedRunner is the runner that will be used to execute the tool command
 	// This is set during validation when a suitable runner is found
 	SelectedRunner *MCPToolRunner
+
+	// DisabledRunners is the list of runner names forbidden by the server policy
+	DisabledRunners []string
+
+	// ConstraintMacros are the CEL macros that can be referenced in the tool constraints
+	ConstraintMacros map[string]string
+
+	// ConstraintLog is the file where the decisions of the tool constraints are recorded
+	ConstraintLog string
+}
+
+// IsRunnerDisabled returns true if the given runner name is forbidden by the server policy.
+func (t *Tool) IsRunnerDisabled(name string) bool {
+	for _, disabled := range t.DisabledRunners {
+		if disabled == name {
+			return true
+		}
+	}
+	return false
 }
 
 // CheckToolRequirements checks if the tool has at least one runner that meets
@@ -38,6 +62,9 @@ func (t *Tool) CheckToolRequirements() bool {
 func (t *Tool) findSuitableRunner() bool {
 	// If no runners are defined, use a default "exec" runner with no requirements
 	if len(t.Config.Run.Runners) == 0 {
+		if t.IsRunnerDisabled("exec") {
+			return false
+		}
 		defaultRunner := MCPToolRunner{
 			Name: "exec",
 		}
@@ -47,26 +74,7 @@ func (t *Tool) findSuitableRunner() bool {
 
 	// Check each defined runner
 	for i, runner := range t.Config.Run.Runners {
-		// Skip runners with invalid or empty names
-		if runner.Name == "" {
-			continue
-		}
-
-		// Check if OS matches (if specified)
-		if runner.Requirements.OS != "" && !common.CheckOSMatches(runner.Requirements.OS) {
-			continue
-		}
-
-		// Check if all required executables exist
-		allExecutablesExist := true
-		for _, execName := range runner.Requirements.Executables {
-			if !common.CheckExecutableExists(execName) {
-				allExecutablesExist = false
-				break
-			}
-		}
-
-		if !allExecutablesExist {
+		if !t.runnerMeetsRequirements(runner) {
 			continue
 		}
 
@@ -79,6 +87,86 @@ func (t *Tool) findSuitableRunner() bool {
 	return false
 }
 
+// HasProbes returns true if any runner of the tool has a probe, so its availability
+// can change while the server runs
+func (t *Tool) HasProbes() bool {
+	for _, runner := range t.Config.Run.Runners {
+		if runner.Requirements.Probe != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// probeTimeout is the maximum duration of the probe of a runner
+const probeTimeout = 10 * time.Second
+
+// runProbe runs the probe command of a runner with sh, returning true when it succeeds
+func runProbe(probe string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+	return exec.CommandContext(ctx, shell, flag, probe).Run() == nil
+}
+
+// runnerMeetsRequirements returns true if the runner has a name, it is not forbidden by
+// the server policy, and its requirements (OS, executables and probe) are met
+func (t *Tool) runnerMeetsRequirements(runner MCPToolRunner) bool {
+	// Skip runners with invalid or empty names
+	if runner.Name == "" {
+		return false
+	}
+
+	// Skip runners forbidden by the server policy
+	if t.IsRunnerDisabled(runner.Name) {
+		return false
+	}
+
+	// Check if OS matches (if specified)
+	if runner.Requirements.OS != "" && !common.CheckOSMatches(runner.Requirements.OS) {
+		return false
+	}
+
+	// Check if all required executables exist
+	for _, execName := range runner.Requirements.Executables {
+		if !common.CheckExecutableExists(execName) {
+			return false
+		}
+	}
+
+	// Check the dependencies of the runner are available (the last, as it is the slowest check)
+	if runner.Requirements.Probe != "" && !runProbe(runner.Requirements.Probe) {
+		return false
+	}
+
+	return true
+}
+
+// GetFallbackRunners returns the runners defined after the selected one that meet their
+// requirements, in order, to be used when the selected runner is not healthy.
+func (t *Tool) GetFallbackRunners() []MCPToolRunner {
+	if t.SelectedRunner == nil {
+		return nil
+	}
+
+	var fallbacks []MCPToolRunner
+	found := false
+	for i := range t.Config.Run.Runners {
+		if !found {
+			found = &t.Config.Run.Runners[i] == t.SelectedRunner
+			continue
+		}
+		if t.runnerMeetsRequirements(t.Config.Run.Runners[i]) {
+			fallbacks = append(fallbacks, t.Config.Run.Runners[i])
+		}
+	}
+	return fallbacks
+}
+
 // GetEffectiveCommand returns the command template that should be used.
 // Since the command is now always defined at the MCPToolRunConfig level,
 // we simply return it directly.
@@ -118,8 +206,13 @@ func (t *Tool) GetEffectiveOptions() map[string]interface{} {
 func CreateMCPTool(config MCPToolConfig) mcp.Tool {
 	var options []mcp.ToolOption
 
-	// Add description
-	options = append(options, mcp.WithDescription(config.Description))
+	// Add description (with a deprecation notice if needed)
+	options = append(options, mcp.WithDescription(toolDescription(config)))
+
+	// Add annotations
+	options = append(options, mcp.WithIdempotentHintAnnotation(config.Idempotent))
+	options = append(options, mcp.WithReadOnlyHintAnnotation(config.ReadOnly))
+	options = append(options, mcp.WithDestructiveHintAnnotation(config.Destructive))
 
 	// Add parameters
 	for name, param := range config.Params {
@@ -162,6 +255,29 @@ func CreateMCPTool(config MCPToolConfig) mcp.Tool {
 			}
 		}
 
+		// Add the restrictions on the values
+		if len(param.Enum) > 0 {
+			enum := param.Enum
+			paramOptions = append(paramOptions, func(schema map[string]any) {
+				schema["enum"] = enum
+			})
+		}
+		if param.Pattern != "" {
+			paramOptions = append(paramOptions, mcp.Pattern(param.Pattern))
+		}
+		if minimum := param.GetMinimum(); minimum != nil {
+			paramOptions = append(paramOptions, mcp.Min(*minimum))
+		}
+		if maximum := param.GetMaximum(); maximum != nil {
+			paramOptions = append(paramOptions, mcp.Max(*maximum))
+		}
+		if param.MinLength != nil {
+			paramOptions = append(paramOptions, mcp.MinLength(*param.MinLength))
+		}
+		if param.MaxLength != nil {
+			paramOptions = append(paramOptions, mcp.MaxLength(*param.MaxLength))
+		}
+
 		// Create parameter with the appropriate type
 		switch paramType {
 		case "string":
@@ -170,8 +286,53 @@ func CreateMCPTool(config MCPToolConfig) mcp.Tool {
 			options = append(options, mcp.WithNumber(name, paramOptions...))
 		case "boolean":
 			options = append(options, mcp.WithBoolean(name, paramOptions...))
+		case "array":
+			paramOptions = append(paramOptions, mcp.Items(itemsSchema(param.GetItems())))
+			options = append(options, mcp.WithArray(name, paramOptions...))
 		}
 	}
 
-	return mcp.NewTool(config.Name, options...)
+	tool := mcp.NewTool(config.Name, options...)
+
+	// Report the deprecation in the metadata too, for clients that can handle it
+	if config.Deprecated {
+		fields := map[string]any{"deprecated": true}
+		if config.DeprecationMessage != "" {
+			fields["deprecationMessage"] = config.DeprecationMessage
+		}
+		tool.Meta = &mcp.Meta{AdditionalFields: fields}
+	}
+
+	return tool
+}
+
+// itemsSchema returns the JSON schema of the elements of an array parameter
+func itemsSchema(items common.ParamConfig) map[string]any {
+	itemsType := items.Type
+	if itemsType == "" {
+		itemsType = "string"
+	}
+
+	schema := map[string]any{"type": itemsType}
+	if itemsType == "array" {
+		schema["items"] = itemsSchema(items.GetItems())
+	}
+	return schema
+}
+
+// toolDescription returns the description of the tool, prefixed with a
+// deprecation notice when the tool is deprecated
+func toolDescription(config MCPToolConfig) string {
+	if !config.Deprecated {
+		return config.Description
+	}
+
+	notice := "DEPRECATED"
+	if config.DeprecationMessage != "" {
+		notice += ": " + config.DeprecationMessage
+	}
+	if config.Description == "" {
+		return "[" + notice + "]"
+	}
+	return "[" + notice + "] " + config.Description
 }
@@ -22,6 +22,10 @@ type ToolsConfig struct {
 
 	// MCP contains the configuration specific to the MCP server and tools
 	MCP MCPConfig `yaml:"mcp"`
+
+	// Agent is the configuration for the agent embedded in the tools configuration
+	// (like the model to use). It is kept as it is, as it is parsed by the agent
+	Agent map[string]interface{} `yaml:"agent,omitempty"`
 }
 
 // MCPConfig represents the MCP server configuration section.
@@ -32,6 +36,38 @@ type MCPConfig struct {
 	// Run contains runtime configuration
 	Run MCPRunConfig `yaml:"run,omitempty"`
 
+	// Output contains the defaults for the output of the tools
+	Output MCPOutputConfig `yaml:"output,omitempty"`
+
+	// DisabledRunners is a list of runner names (e.g., "exec") that tools are not
+	// allowed to use. Runners in this list are skipped during runner selection.
+	DisabledRunners []string `yaml:"disabled_runners,omitempty"`
+
+	// Macros maps names to reusable CEL expression fragments that can be
+	// referenced by name in the constraints of any tool
+	Macros map[string]string `yaml:"macros,omitempty"`
+
+	// GlobalConstraints are constraints added to the constraints of every tool, evaluated
+	// with the parameters of each tool. A global constraint referencing a parameter a tool
+	// does not declare is skipped for that tool
+	GlobalConstraints []string `yaml:"global_constraints,omitempty"`
+
+	// Context is a map of values describing the server context (like the stage or the
+	// team), that tool conditions (see MCPToolConfig.EnabledIf) can check
+	Context map[string]string `yaml:"context,omitempty"`
+
+	// ConstraintLog is the path of a file where a JSON record is appended for every
+	// evaluation of the constraints of a tool (whatever the log level)
+	ConstraintLog string `yaml:"constraint_log,omitempty"`
+
+	// MaxConcurrent is the maximum number of tool calls (of any tool) run at the same
+	// time, where calls beyond the limit wait for a free slot (0 for no limit)
+	MaxConcurrent int `yaml:"max_concurrent,omitempty"`
+
+	// UnknownParams is the default policy for the arguments of the tools that are not
+	// declared as parameters (see MCPToolConfig.UnknownParams)
+	UnknownParams string `yaml:"unknown_params,omitempty"`
+
 	// Tools is a list of tool definitions that will be provided to clients
 	Tools []MCPToolConfig `yaml:"tools"`
 }
@@ -40,6 +76,24 @@ type MCPConfig struct {
 type MCPRunConfig struct {
 	// Shell is the shell to use for executing commands (e.g., bash, sh, zsh)
 	Shell string `yaml:"shell,omitempty"`
+
+	// ShellFlags is the default for the ShellFlags of the tools (see MCPToolRunConfig)
+	ShellFlags string `yaml:"shell_flags,omitempty"`
+
+	// Timeout is the default for the Timeout of the tools (see MCPToolRunConfig)
+	Timeout string `yaml:"timeout,omitempty"`
+
+	// ProbeInterval is the interval (e.g., "1m") at which the tools are loaded again while
+	// the server runs, so the probes of the runners (see MCPToolRequirements.Probe) are run
+	// again and the tools are offered or withdrawn as their dependencies come and go.
+	// When empty, the probes are only run when the server starts
+	ProbeInterval string `yaml:"probe_interval,omitempty"`
+}
+
+// MCPOutputConfig represents the defaults for the output of the tools.
+type MCPOutputConfig struct {
+	// MaxBytes is the default for the MaxBytes of the output of the tools (see common.OutputConfig)
+	MaxBytes int `yaml:"max_bytes,omitempty"`
 }
 
 // MCPToolConfig represents a single tool configuration.
@@ -56,11 +110,90 @@ type MCPToolConfig struct {
 	// Constraints are expressions that limit when the tool can be executed
 	Constraints []string `yaml:"constraints,omitempty"`
 
+	// Warnings are advisory constraints: when they are not satisfied the tool is still
+	// executed, but a warning is prepended to its output
+	Warnings []string `yaml:"warnings,omitempty"`
+
 	// Run specifies how to execute the tool
 	Run MCPToolRunConfig `yaml:"run"`
 
 	// Output specifies how to format the tool's output
 	Output common.OutputConfig `yaml:"output,omitempty"`
+
+	// Idempotent declares that running the tool repeatedly with the same arguments
+	// has no additional effect, so clients can safely retry it
+	Idempotent bool `yaml:"idempotent,omitempty"`
+
+	// ReadOnly declares that the tool does not modify its environment
+	ReadOnly bool `yaml:"read_only,omitempty"`
+
+	// Destructive declares that the tool may perform destructive updates
+	// (like deleting files), so clients should ask for confirmation before running it
+	Destructive bool `yaml:"destructive,omitempty"`
+
+	// Deprecated marks the tool as deprecated: it is still available, but clients
+	// are told (in the description) that it should not be used anymore
+	Deprecated bool `yaml:"deprecated,omitempty"`
+
+	// DeprecationMessage explains why the tool is deprecated (e.g. which tool to use instead)
+	DeprecationMessage string `yaml:"deprecation_message,omitempty"`
+
+	// LongRunning declares that the tool may take a long time to complete, so progress
+	// notifications are sent periodically to the clients that ask for them
+	LongRunning bool `yaml:"long_running,omitempty"`
+
+	// EnabledIf is a CEL expression evaluated against the server context (`context`)
+	// and the environment variables (`env`): the tool is only provided when it is true
+	EnabledIf string `yaml:"enabled_if,omitempty"`
+
+	// UnknownParams is the policy for the arguments that are not declared as parameters:
+	// "strict" rejects the call, "ignore" drops them and "passthrough" (the default)
+	// keeps them, so they can still be used in the command templates
+	UnknownParams string `yaml:"unknown_params,omitempty"`
+
+	// MaxConcurrent is the maximum number of calls of the tool run at the same time,
+	// where calls beyond the limit wait for a free slot (0 for no limit)
+	MaxConcurrent int `yaml:"max_concurrent,omitempty"`
+
+	// Priority orders the tools when only some of them can be exposed (like with the
+	// --max-tools flag of the agent): tools with higher priorities are kept first
+	Priority int `yaml:"priority,omitempty"`
+
+	// Cache keeps the outputs of the successful calls of the tool for some time, and
+	// returns them to the calls with the same arguments without running the command again
+	Cache *CacheConfig `yaml:"cache,omitempty"`
+
+	// Examples are example calls of the tool, with their expected outcomes, that
+	// are run by the "test" command
+	Examples []ToolExample `yaml:"examples,omitempty"`
+}
+
+// ToolExample is an example call of a tool and its expected outcome
+type ToolExample struct {
+	// Description is an optional description of the example
+	Description string `yaml:"description,omitempty"`
+
+	// Args are the arguments of the call
+	Args map[string]interface{} `yaml:"args,omitempty"`
+
+	// ExpectError is true when the call is expected to fail (e.g., blocked by the constraints)
+	ExpectError bool `yaml:"expect_error,omitempty"`
+
+	// ExpectOutput is a text the output of a successful call is expected to contain
+	ExpectOutput string `yaml:"expect_output,omitempty"`
+}
+
+// DefaultCacheMaxEntries is the default maximum number of outputs cached for a tool
+const DefaultCacheMaxEntries = 100
+
+// CacheConfig configures the cache of the outputs of a tool.
+type CacheConfig struct {
+	// TTL is how long the outputs are cached (e.g., "30s", "5m")
+	TTL string `yaml:"ttl"`
+
+	// MaxEntries is the maximum number of outputs cached, where the least recently
+	// used ones are evicted first. Defaults to DefaultCacheMaxEntries
+	MaxEntries int `yaml:"max_entries,omitempty"`
 }
 
 // MCPToolRequirements represents a prerequisite tool configuration.
@@ -73,6 +206,51 @@ type MCPToolRequirements struct {
 
 	// Executables is a list of executable names that must be present in the system
 	Executables []string `yaml:"executables"`
+
+	// HealthCheck is an optional shell command run before every call of the tool, telling
+	// whether the runner can be used right now (e.g., Docker is not overloaded): when it
+	// fails, the next runner of the tool meeting its requirements is used instead
+	HealthCheck string `yaml:"health_check,omitempty"`
+
+	// Probe is an optional shell command run when the tools are loaded, telling whether a
+	// dependency of the runner is available (e.g., a database is reachable): when it fails,
+	// the runner is not used, and the tool is not provided when no other runner can be used
+	Probe string `yaml:"probe,omitempty"`
+}
+
+// Cleanup policies for the output directories of the tools
+const (
+	// OutputDirRemove removes the output directory once the tool has finished
+	OutputDirRemove = "remove"
+
+	// OutputDirKeep keeps the output directory (and the files in it) once the tool has finished
+	OutputDirKeep = "keep"
+)
+
+// Policies for the arguments of the tools that are not declared as parameters
+const (
+	// UnknownParamsStrict rejects the calls with undeclared arguments
+	UnknownParamsStrict = "strict"
+
+	// UnknownParamsIgnore drops the undeclared arguments before running the tool
+	UnknownParamsIgnore = "ignore"
+
+	// UnknownParamsPassthrough keeps the undeclared arguments
+	UnknownParamsPassthrough = "passthrough"
+)
+
+// OutputDirConfig configures the per-call output directory of a tool.
+type OutputDirConfig struct {
+	// Parent is the directory where the output directories are created.
+	// If not specified, the server default (or the temporary directory) is used
+	Parent string `yaml:"parent,omitempty"`
+
+	// Cleanup is what happens with the output directory once the tool has finished:
+	// "remove" (the default) or "keep"
+	Cleanup string `yaml:"cleanup,omitempty"`
+
+	// ListFiles appends the list of files created in the output directory to the output
+	ListFiles bool `yaml:"list_files,omitempty"`
 }
 
 // MCPToolRunner represents a specific execution environment for a tool.
@@ -95,10 +273,30 @@ type MCPToolRunConfig struct {
 	// Env is a list of environment variable names to pass from the parent process
 	Env []string `yaml:"env,omitempty"`
 
-	// Timeout is the maximum duration for command execution (e.g., "30s", "5m")
-	// If not specified, no timeout is applied
+	// RequireEnv is a list of environment variable names that must be set (and not empty)
+	// in the parent process for the tool to be executed
+	RequireEnv []string `yaml:"require_env,omitempty"`
+
+	// ShellFlags are shell commands (e.g., "set -euo pipefail") prepended to the command,
+	// so every script runs with them. They must be supported by the shell used
+	ShellFlags string `yaml:"shell_flags,omitempty"`
+
+	// Timeout is the maximum duration for command execution (e.g., "30s", "5m"), where "0"
+	// means no timeout. If not specified, the default timeout of the configuration is applied
 	Timeout string `yaml:"timeout,omitempty"`
 
+	// ProgressInterval is the interval between progress notifications for long-running
+	// tools (e.g., "5s"). If not specified, progress is sent every 10 seconds
+	ProgressInterval string `yaml:"progress_interval,omitempty"`
+
+	// OutputDir creates a new directory for every call of the tool, available in the
+	// command templates as `{{ .output_dir }}`, for tools producing files
+	OutputDir *OutputDirConfig `yaml:"output_dir,omitempty"`
+
+	// SuccessExitCodes are the exit codes of the command considered successful
+	// (e.g., [0, 1] for grep or diff). If not specified, only 0 is a success
+	SuccessExitCodes []int `yaml:"success_exit_codes,omitempty"`
+
 	// Runners is a list of possible runner configurations
 	Runners []MCPToolRunner `yaml:"runners,omitempty"`
 }
@@ -147,16 +345,70 @@ func NewConfigFromFile(filepath string) (*ToolsConfig, error) {
 //   - A slice of ToolDefinition objects
 func (c *ToolsConfig) GetTools() []Tool {
 	var tools []Tool
+	for _, tool := range c.GetConfiguredTools() {
+		// Check prerequisites before creating the tool
+		if !tool.CheckToolRequirements() {
+			continue // Skip this tool if prerequisites are not met
+		}
+
+		tools = append(tools, tool)
+	}
+
+	return tools
+}
+
+// GetConfiguredTools returns the tools enabled in the configuration, like GetTools,
+// but without checking their requirements (so they have no runner selected yet).
+//
+// Returns:
+//   - A slice of ToolDefinition objects
+func (c *ToolsConfig) GetConfiguredTools() []Tool {
+	var tools []Tool
+
+	for _, expr := range c.UnusedGlobalConstraints() {
+		common.GetLogger().Warn("Global constraint '%s' does not apply to any tool "+
+			"(it references parameters not declared by the tools), it will never be evaluated", expr)
+	}
 
 	for _, toolConfig := range c.MCP.Tools {
-		tool := Tool{
-			MCPTool: CreateMCPTool(toolConfig),
-			Config:  toolConfig,
+		// Skip the tool if it is disabled by its condition
+		if !c.isToolEnabled(toolConfig) {
+			continue
 		}
 
-		// Check prerequisites before creating the tool
-		if !tool.CheckToolRequirements() {
-			continue // Skip this tool if prerequisites are not met
+		// Tools inherit the default shell flags unless they set their own
+		if toolConfig.Run.ShellFlags == "" {
+			toolConfig.Run.ShellFlags = c.MCP.Run.ShellFlags
+		}
+
+		// ... and the same for the timeout
+		if toolConfig.Run.Timeout == "" {
+			toolConfig.Run.Timeout = c.MCP.Run.Timeout
+		}
+
+		// ... and the maximum size of the output (not for the outputs stored as resources,
+		// that are not limited by default)
+		if toolConfig.Output.MaxBytes == 0 && !toolConfig.Output.AsResource {
+			toolConfig.Output.MaxBytes = c.MCP.Output.MaxBytes
+		}
+
+		// ... and the policy for the unknown parameters
+		if toolConfig.UnknownParams == "" {
+			toolConfig.UnknownParams = c.MCP.UnknownParams
+		}
+
+		// Add the global constraints that apply to the parameters of the tool
+		if len(c.MCP.GlobalConstraints) > 0 {
+			global := common.ApplicableConstraints(c.MCP.GlobalConstraints, c.MCP.Macros, toolConfig.Params)
+			toolConfig.Constraints = append(global, toolConfig.Constraints...)
+		}
+
+		tool := Tool{
+			MCPTool:          CreateMCPTool(toolConfig),
+			Config:           toolConfig,
+			DisabledRunners:  c.MCP.DisabledRunners,
+			ConstraintMacros: c.MCP.Macros,
+			ConstraintLog:    c.MCP.ConstraintLog,
 		}
 
 		tools = append(tools, tool)
@@ -165,6 +417,44 @@ func (c *ToolsConfig) GetTools() []Tool {
 	return tools
 }
 
+// UnusedGlobalConstraints returns the global constraints that do not apply to any tool
+// of the configuration (enabled or not), like the ones referencing a parameter that no
+// tool declares (e.g. because of a typo), as they would be silently skipped.
+func (c *ToolsConfig) UnusedGlobalConstraints() []string {
+	used := make(map[string]bool, len(c.MCP.GlobalConstraints))
+	for _, toolConfig := range c.MCP.Tools {
+		for _, expr := range common.ApplicableConstraints(c.MCP.GlobalConstraints, c.MCP.Macros, toolConfig.Params) {
+			used[expr] = true
+		}
+	}
+
+	var unused []string
+	for _, expr := range c.MCP.GlobalConstraints {
+		if !used[expr] {
+			unused = append(unused, expr)
+		}
+	}
+
+	return unused
+}
+
+// isToolEnabled evaluates the EnabledIf condition of a tool against the server context.
+// Tools without a condition are always enabled, while tools with a condition that
+// cannot be evaluated are disabled.
+func (c *ToolsConfig) isToolEnabled(toolConfig MCPToolConfig) bool {
+	if toolConfig.EnabledIf == "" {
+		return true
+	}
+
+	enabled, err := common.EvaluateCondition(toolConfig.EnabledIf, c.MCP.Context)
+	if err != nil {
+		common.GetLogger().Warn("Disabling tool '%s': %v", toolConfig.Name, err)
+		return false
+	}
+
+	return enabled
+}
+
 // ToYAML serializes the configuration back to YAML format.
 //
 // Returns:
@@ -178,7 +468,11 @@ func (c *ToolsConfig) ToYAML() ([]byte, error) {
 // The merging strategy is:
 // - Prompts are concatenated from all files
 // - MCP description from the first file is used (others are ignored)
-// - MCP run config from the first file is used (others are ignored)
+// - MCP run and output configs from the first file are used (others are ignored)
+// - The agent configuration from the first file with one is used
+// - Disabled runners from all files are combined
+// - Macros from all files are combined (later files override earlier ones)
+// - Context values from all files are combined (later files override earlier ones)
 // - Tools from all files are combined
 //
 // Parameters:
@@ -209,9 +503,39 @@ func LoadAndMergeConfigs(filepaths []string) (*ToolsConfig, error) {
 		if isFirstFile {
 			mergedConfig.MCP.Description = config.MCP.Description
 			mergedConfig.MCP.Run = config.MCP.Run
+			mergedConfig.MCP.Output = config.MCP.Output
+			mergedConfig.MCP.UnknownParams = config.MCP.UnknownParams
+			mergedConfig.MCP.MaxConcurrent = config.MCP.MaxConcurrent
 			isFirstFile = false
 		}
 
+		// Use the first agent configuration found
+		if mergedConfig.Agent == nil {
+			mergedConfig.Agent = config.Agent
+		}
+
+		// Merge disabled runners (a runner disabled in any file stays disabled)
+		mergedConfig.MCP.DisabledRunners = append(mergedConfig.MCP.DisabledRunners, config.MCP.DisabledRunners...)
+
+		// Merge global constraints (the constraints of all the files apply)
+		mergedConfig.MCP.GlobalConstraints = append(mergedConfig.MCP.GlobalConstraints, config.MCP.GlobalConstraints...)
+
+		// Merge macros (later definitions override earlier ones)
+		for name, fragment := range config.MCP.Macros {
+			if mergedConfig.MCP.Macros == nil {
+				mergedConfig.MCP.Macros = make(map[string]string)
+			}
+			mergedConfig.MCP.Macros[name] = fragment
+		}
+
+		// Merge context values (later definitions override earlier ones)
+		for name, value := range config.MCP.Context {
+			if mergedConfig.MCP.Context == nil {
+				mergedConfig.MCP.Context = make(map[string]string)
+			}
+			mergedConfig.MCP.Context[name] = value
+		}
+
 		// Merge tools (combine from all files)
 		mergedConfig.MCP.Tools = append(mergedConfig.MCP.Tools, config.MCP.Tools...)
 	}
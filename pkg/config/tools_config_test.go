@@ -1,8 +1,12 @@
 package config
 
 import (
+	"reflect"
 	"runtime"
+	"strings"
 	"testing"
+
+	"github.com/inercia/MCPShell/pkg/common"
 )
 
 func TestCheckToolPrerequisites(t *testing.T) {
@@ -157,3 +161,450 @@ func TestCreateTools_Prerequisites(t *testing.T) {
 		t.Errorf("Expected tool named 'tool1', got '%s'", tools[0].MCPTool.Name)
 	}
 }
+
+func TestGetTools_RequirementsProbe(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test on Windows")
+	}
+
+	probeRunner := func(probe string) []MCPToolRunner {
+		return []MCPToolRunner{{Name: "exec", Requirements: MCPToolRequirements{Probe: probe}}}
+	}
+	cfg := &ToolsConfig{
+		MCP: MCPConfig{
+			Tools: []MCPToolConfig{
+				{Name: "healthy", Run: MCPToolRunConfig{Command: "echo 'healthy'", Runners: probeRunner("exit 0")}},
+				{Name: "unhealthy", Run: MCPToolRunConfig{Command: "echo 'unhealthy'", Runners: probeRunner("exit 1")}},
+				{
+					Name: "fallback",
+					Run: MCPToolRunConfig{
+						Command: "echo 'fallback'",
+						Runners: append(probeRunner("test -e /non-existent-file-12345"), MCPToolRunner{Name: "exec"}),
+					},
+				},
+			},
+		},
+	}
+
+	tools := map[string]Tool{}
+	for _, tool := range cfg.GetTools() {
+		tools[tool.MCPTool.Name] = tool
+	}
+
+	if _, ok := tools["healthy"]; !ok {
+		t.Error("Expected the tool with a successful probe to be provided")
+	}
+	if _, ok := tools["unhealthy"]; ok {
+		t.Error("Expected the tool with a failing probe to be skipped")
+	}
+	if tool, ok := tools["fallback"]; !ok {
+		t.Error("Expected the tool with another runner to be provided")
+	} else if tool.SelectedRunner.Requirements.Probe != "" {
+		t.Errorf("Expected the runner without a probe to be selected, got %+v", tool.SelectedRunner)
+	}
+}
+
+func TestGetTools_DisabledRunners(t *testing.T) {
+	cfg := &ToolsConfig{
+		MCP: MCPConfig{
+			DisabledRunners: []string{"exec"},
+			Tools: []MCPToolConfig{
+				{
+					Name: "default_exec",
+					Run:  MCPToolRunConfig{Command: "echo 'default'"},
+				},
+				{
+					Name: "explicit_exec",
+					Run: MCPToolRunConfig{
+						Command: "echo 'explicit'",
+						Runners: []MCPToolRunner{{Name: "exec"}},
+					},
+				},
+				{
+					Name: "fallback",
+					Run: MCPToolRunConfig{
+						Command: "echo 'fallback'",
+						Runners: []MCPToolRunner{{Name: "exec"}, {Name: "firejail"}},
+					},
+				},
+			},
+		},
+	}
+
+	tools := cfg.GetTools()
+
+	if len(tools) != 1 {
+		t.Fatalf("Expected 1 tool, got %d", len(tools))
+	}
+	if tools[0].MCPTool.Name != "fallback" {
+		t.Errorf("Expected tool named 'fallback', got '%s'", tools[0].MCPTool.Name)
+	}
+	if got := tools[0].GetEffectiveRunner(); got != "firejail" {
+		t.Errorf("Expected runner 'firejail' to be selected, got '%s'", got)
+	}
+}
+
+func TestCreateMCPTool_IdempotentAnnotation(t *testing.T) {
+	tests := []struct {
+		name       string
+		idempotent bool
+	}{
+		{name: "idempotent tool", idempotent: true},
+		{name: "non-idempotent tool", idempotent: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := CreateMCPTool(MCPToolConfig{
+				Name:       "test_tool",
+				Idempotent: tt.idempotent,
+				Run:        MCPToolRunConfig{Command: "echo 'test'"},
+			})
+
+			hint := tool.Annotations.IdempotentHint
+			if hint == nil {
+				t.Fatal("Expected IdempotentHint annotation to be set")
+			}
+			if *hint != tt.idempotent {
+				t.Errorf("Expected IdempotentHint %v, got %v", tt.idempotent, *hint)
+			}
+		})
+	}
+}
+
+func TestCreateMCPTool_ParamBounds(t *testing.T) {
+	minimum, maximum, maxLength := 1.0, 100.0, 8
+	tool := CreateMCPTool(MCPToolConfig{
+		Name: "test_tool",
+		Params: map[string]common.ParamConfig{
+			"value": {Type: "number", Min: &minimum, Maximum: &maximum},
+			"name":  {Type: "string", MaxLength: &maxLength},
+		},
+		Run: MCPToolRunConfig{Command: "echo 'test'"},
+	})
+
+	value, _ := tool.InputSchema.Properties["value"].(map[string]any)
+	if value["minimum"] != 1.0 || value["maximum"] != 100.0 {
+		t.Errorf("Expected the bounds in the schema of 'value', got %v", value)
+	}
+	name, _ := tool.InputSchema.Properties["name"].(map[string]any)
+	if name["maxLength"] != 8 {
+		t.Errorf("Expected maxLength in the schema of 'name', got %v", name)
+	}
+	if _, ok := name["minLength"]; ok {
+		t.Errorf("Unexpected minLength in the schema of 'name': %v", name)
+	}
+}
+
+func TestCreateMCPTool_ReadOnlyDestructiveAnnotations(t *testing.T) {
+	tests := []struct {
+		name        string
+		readOnly    bool
+		destructive bool
+	}{
+		{name: "regular tool", readOnly: false, destructive: false},
+		{name: "read-only tool", readOnly: true, destructive: false},
+		{name: "destructive tool", readOnly: false, destructive: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := CreateMCPTool(MCPToolConfig{
+				Name:        "test_tool",
+				ReadOnly:    tt.readOnly,
+				Destructive: tt.destructive,
+				Run:         MCPToolRunConfig{Command: "echo 'test'"},
+			})
+
+			readOnlyHint := tool.Annotations.ReadOnlyHint
+			if readOnlyHint == nil || *readOnlyHint != tt.readOnly {
+				t.Errorf("Expected ReadOnlyHint %v, got %v", tt.readOnly, readOnlyHint)
+			}
+			destructiveHint := tool.Annotations.DestructiveHint
+			if destructiveHint == nil || *destructiveHint != tt.destructive {
+				t.Errorf("Expected DestructiveHint %v, got %v", tt.destructive, destructiveHint)
+			}
+		})
+	}
+}
+
+func TestCreateMCPTool_Deprecated(t *testing.T) {
+	tool := CreateMCPTool(MCPToolConfig{
+		Name:               "old_tool",
+		Description:        "Lists the files",
+		Deprecated:         true,
+		DeprecationMessage: "use 'list_files' instead",
+		Run:                MCPToolRunConfig{Command: "ls"},
+	})
+
+	want := "[DEPRECATED: use 'list_files' instead] Lists the files"
+	if tool.Description != want {
+		t.Errorf("Expected description %q, got %q", want, tool.Description)
+	}
+	if tool.Meta == nil || tool.Meta.AdditionalFields["deprecated"] != true {
+		t.Errorf("Expected the deprecation in the tool metadata, got %+v", tool.Meta)
+	}
+
+	tool = CreateMCPTool(MCPToolConfig{
+		Name:        "new_tool",
+		Description: "Lists the files",
+		Run:         MCPToolRunConfig{Command: "ls"},
+	})
+	if tool.Description != "Lists the files" || tool.Meta != nil {
+		t.Errorf("Expected no deprecation notice, got %q (meta %+v)", tool.Description, tool.Meta)
+	}
+}
+
+func TestGetTools_EnabledIf(t *testing.T) {
+	tools := []MCPToolConfig{
+		{
+			Name:      "prod_only",
+			EnabledIf: "context.stage == 'prod'",
+			Run:       MCPToolRunConfig{Command: "echo 'prod'"},
+		},
+		{
+			Name:      "not_prod",
+			EnabledIf: "!('stage' in context) || context.stage != 'prod'",
+			Run:       MCPToolRunConfig{Command: "echo 'not prod'"},
+		},
+		{
+			Name: "always",
+			Run:  MCPToolRunConfig{Command: "echo 'always'"},
+		},
+		{
+			Name:      "invalid",
+			EnabledIf: "context.stage ==",
+			Run:       MCPToolRunConfig{Command: "echo 'invalid'"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		context map[string]string
+		want    []string
+	}{
+		{
+			name:    "Production context",
+			context: map[string]string{"stage": "prod"},
+			want:    []string{"prod_only", "always"},
+		},
+		{
+			name:    "Development context",
+			context: map[string]string{"stage": "dev"},
+			want:    []string{"not_prod", "always"},
+		},
+		{
+			name: "No context",
+			want: []string{"not_prod", "always"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &ToolsConfig{MCP: MCPConfig{Context: tt.context, Tools: tools}}
+
+			var got []string
+			for _, tool := range cfg.GetTools() {
+				got = append(got, tool.MCPTool.Name)
+			}
+
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Expected tools %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGetTools_ShellFlags(t *testing.T) {
+	cfg := &ToolsConfig{
+		MCP: MCPConfig{
+			Run: MCPRunConfig{ShellFlags: "set -eu"},
+			Tools: []MCPToolConfig{
+				{Name: "inherited", Run: MCPToolRunConfig{Command: "echo 'inherited'"}},
+				{Name: "own", Run: MCPToolRunConfig{Command: "echo 'own'", ShellFlags: "set -euo pipefail"}},
+			},
+		},
+	}
+
+	flags := map[string]string{}
+	for _, tool := range cfg.GetTools() {
+		flags[tool.MCPTool.Name] = tool.Config.Run.ShellFlags
+	}
+
+	if flags["inherited"] != "set -eu" {
+		t.Errorf("Expected the default shell flags to be inherited, got %q", flags["inherited"])
+	}
+	if flags["own"] != "set -euo pipefail" {
+		t.Errorf("Expected the tool shell flags to be kept, got %q", flags["own"])
+	}
+}
+
+func TestGetTools_Timeout(t *testing.T) {
+	cfg := &ToolsConfig{
+		MCP: MCPConfig{
+			Run: MCPRunConfig{Timeout: "30s"},
+			Tools: []MCPToolConfig{
+				{Name: "inherited", Run: MCPToolRunConfig{Command: "echo 'inherited'"}},
+				{Name: "own", Run: MCPToolRunConfig{Command: "echo 'own'", Timeout: "5m"}},
+				{Name: "unlimited", Run: MCPToolRunConfig{Command: "echo 'unlimited'", Timeout: "0"}},
+			},
+		},
+	}
+
+	timeouts := map[string]string{}
+	for _, tool := range cfg.GetTools() {
+		timeouts[tool.MCPTool.Name] = tool.Config.Run.Timeout
+	}
+
+	want := map[string]string{"inherited": "30s", "own": "5m", "unlimited": "0"}
+	for name, timeout := range want {
+		if timeouts[name] != timeout {
+			t.Errorf("Tool '%s': expected timeout %q, got %q", name, timeout, timeouts[name])
+		}
+	}
+}
+
+func TestTool_GetFallbackRunners(t *testing.T) {
+	tool := Tool{
+		Config: MCPToolConfig{
+			Run: MCPToolRunConfig{
+				Runners: []MCPToolRunner{
+					{Name: "docker"},
+					{Name: "firejail", Requirements: MCPToolRequirements{Executables: []string{"non-existent-executable-12345"}}},
+					{Name: "sandbox-exec"},
+					{Name: "exec"},
+				},
+			},
+		},
+		DisabledRunners: []string{"sandbox-exec"},
+	}
+
+	if !tool.CheckToolRequirements() {
+		t.Fatalf("Expected a suitable runner")
+	}
+	if tool.SelectedRunner.Name != "docker" {
+		t.Fatalf("Expected the docker runner to be selected, got %s", tool.SelectedRunner.Name)
+	}
+
+	// runners not meeting their requirements, or disabled, are not fallbacks
+	fallbacks := tool.GetFallbackRunners()
+	if len(fallbacks) != 1 || fallbacks[0].Name != "exec" {
+		t.Errorf("GetFallbackRunners() = %+v, want only the exec runner", fallbacks)
+	}
+}
+
+func TestGetTools_OutputMaxBytes(t *testing.T) {
+	cfg := &ToolsConfig{
+		MCP: MCPConfig{
+			Output: MCPOutputConfig{MaxBytes: 1000},
+			Tools: []MCPToolConfig{
+				{Name: "inherited", Run: MCPToolRunConfig{Command: "echo 'inherited'"}},
+				{Name: "own", Run: MCPToolRunConfig{Command: "echo 'own'"}, Output: common.OutputConfig{MaxBytes: 50}},
+				{Name: "unlimited", Run: MCPToolRunConfig{Command: "echo 'unlimited'"}, Output: common.OutputConfig{MaxBytes: -1}},
+				{Name: "resource", Run: MCPToolRunConfig{Command: "echo 'resource'"}, Output: common.OutputConfig{AsResource: true}},
+			},
+		},
+	}
+
+	maxBytes := map[string]int{}
+	for _, tool := range cfg.GetTools() {
+		maxBytes[tool.MCPTool.Name] = tool.Config.Output.MaxBytes
+	}
+
+	want := map[string]int{"inherited": 1000, "own": 50, "unlimited": -1, "resource": 0}
+	for name, size := range want {
+		if maxBytes[name] != size {
+			t.Errorf("Tool '%s': expected max_bytes %d, got %d", name, size, maxBytes[name])
+		}
+	}
+}
+
+func TestGetTools_UnknownParams(t *testing.T) {
+	cfg := &ToolsConfig{
+		MCP: MCPConfig{
+			UnknownParams: UnknownParamsStrict,
+			Tools: []MCPToolConfig{
+				{Name: "inherited", Run: MCPToolRunConfig{Command: "echo 'inherited'"}},
+				{Name: "own", Run: MCPToolRunConfig{Command: "echo 'own'"}, UnknownParams: UnknownParamsIgnore},
+			},
+		},
+	}
+
+	policies := map[string]string{}
+	for _, tool := range cfg.GetTools() {
+		policies[tool.MCPTool.Name] = tool.Config.UnknownParams
+	}
+
+	want := map[string]string{"inherited": UnknownParamsStrict, "own": UnknownParamsIgnore}
+	for name, policy := range want {
+		if policies[name] != policy {
+			t.Errorf("Tool '%s': expected unknown_params %q, got %q", name, policy, policies[name])
+		}
+	}
+}
+
+func TestGetTools_GlobalConstraints(t *testing.T) {
+	cfg := &ToolsConfig{
+		MCP: MCPConfig{
+			GlobalConstraints: []string{"!path.contains(';')", "is_safe"},
+			Macros:            map[string]string{"is_safe": "!path.contains('..')"},
+			Tools: []MCPToolConfig{
+				{
+					Name:        "with_path",
+					Params:      map[string]common.ParamConfig{"path": {Type: "string"}},
+					Constraints: []string{"path.size() < 100"},
+					Run:         MCPToolRunConfig{Command: "ls {{ .path }}"},
+				},
+				{
+					Name:   "without_path",
+					Params: map[string]common.ParamConfig{"message": {Type: "string"}},
+					Run:    MCPToolRunConfig{Command: "echo {{ .message }}"},
+				},
+			},
+		},
+	}
+
+	constraints := map[string][]string{}
+	for _, tool := range cfg.GetTools() {
+		constraints[tool.MCPTool.Name] = tool.Config.Constraints
+	}
+
+	want := []string{"!path.contains(';')", "is_safe", "path.size() < 100"}
+	if !reflect.DeepEqual(constraints["with_path"], want) {
+		t.Errorf("Expected the global constraints before the tool ones %v, got %v", want, constraints["with_path"])
+	}
+	if len(constraints["without_path"]) != 0 {
+		t.Errorf("Expected the global constraints to be skipped, got %v", constraints["without_path"])
+	}
+	if len(cfg.MCP.Tools[0].Constraints) != 1 {
+		t.Errorf("The configuration should not be modified, got %v", cfg.MCP.Tools[0].Constraints)
+	}
+}
+
+func TestUnusedGlobalConstraints(t *testing.T) {
+	cfg := &ToolsConfig{
+		MCP: MCPConfig{
+			GlobalConstraints: []string{"!path.contains(';')", "!pth.contains('..')", "is_safe", "unknown_func(path)"},
+			Macros:            map[string]string{"is_safe": "!message.contains('`')"},
+			Tools: []MCPToolConfig{
+				{
+					Name:   "with_path",
+					Params: map[string]common.ParamConfig{"path": {Type: "string"}},
+					Run:    MCPToolRunConfig{Command: "ls {{ .path }}"},
+				},
+				{
+					// disabled tools count too, they could be enabled in other contexts
+					Name:      "disabled",
+					EnabledIf: "false",
+					Params:    map[string]common.ParamConfig{"message": {Type: "string"}},
+					Run:       MCPToolRunConfig{Command: "echo {{ .message }}"},
+				},
+			},
+		},
+	}
+
+	want := []string{"!pth.contains('..')"}
+	if got := cfg.UnusedGlobalConstraints(); !reflect.DeepEqual(got, want) {
+		t.Errorf("UnusedGlobalConstraints() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,6 @@
+// Package embedded provides the tools configuration embedded in the binary.
+//
+// Binaries built with the `embedded_tools` build tag include the YAML files
+// found in the tools directory of this package, so MCPShell can be distributed
+// as a single binary with its tools.
+package embedded
@@ -0,0 +1,21 @@
+//go:build embedded_tools
+
+package embedded
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed tools
+var toolsFS embed.FS
+
+// Tools returns the filesystem with the embedded tools configuration,
+// or nil when the binary was built without it.
+func Tools() fs.FS {
+	tools, err := fs.Sub(toolsFS, "tools")
+	if err != nil {
+		return nil
+	}
+	return tools
+}
@@ -0,0 +1,13 @@
+//go:build !embedded_tools
+
+package embedded
+
+import (
+	"io/fs"
+)
+
+// Tools returns the filesystem with the embedded tools configuration,
+// or nil when the binary was built without it.
+func Tools() fs.FS {
+	return nil
+}
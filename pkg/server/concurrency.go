@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+)
+
+// callSlots is a semaphore limiting the number of tool calls that run at the same time
+type callSlots chan struct{}
+
+// newCallSlots creates a semaphore for running at most max calls at the same time,
+// or nil (no limit) when max is 0
+func newCallSlots(max int) callSlots {
+	if max <= 0 {
+		return nil
+	}
+	return make(callSlots, max)
+}
+
+// globalCallSlots is the key of the slots of the global limit of concurrent calls
+// (tool names are never empty)
+const globalCallSlots = ""
+
+// callSlotsFor returns the semaphore for the limit of concurrent calls of a tool (or the global
+// one), reusing the semaphore created before when the limit did not change. The semaphores
+// are kept when the tools are loaded again, so the calls still running count for the limits.
+func (s *Server) callSlotsFor(key string, max int) callSlots {
+	if s.slots == nil {
+		s.slots = make(map[string]callSlots)
+	}
+	if slots, ok := s.slots[key]; ok && cap(slots) == max {
+		return slots
+	}
+
+	slots := newCallSlots(max)
+	if slots == nil {
+		delete(s.slots, key)
+	} else {
+		s.slots[key] = slots
+	}
+	return slots
+}
+
+// wrapHandlerWithConcurrencyLimit wraps a tool handler so it only runs when there is a free
+// slot in all the given semaphores (nil semaphores do not limit anything). Calls beyond the
+// limits wait for a free slot, unless they are cancelled while waiting.
+func (s *Server) wrapHandlerWithConcurrencyLimit(toolName string, handler mcpserver.ToolHandlerFunc, limits ...callSlots) mcpserver.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Slots are taken in order (the ones of the tool first), so calls waiting for
+		// the tool do not take the slots other tools could use
+		for _, slots := range limits {
+			if slots == nil {
+				continue
+			}
+
+			select {
+			case slots <- struct{}{}:
+				defer func(slots callSlots) { <-slots }(slots)
+			default:
+				s.logger.Debug("Tool '%s' is waiting for a free slot", toolName)
+				select {
+				case slots <- struct{}{}:
+					defer func(slots callSlots) { <-slots }(slots)
+				case <-ctx.Done():
+					return nil, fmt.Errorf("tool '%s' was cancelled while waiting for a free slot: %w", toolName, ctx.Err())
+				}
+			}
+		}
+
+		return handler(ctx, request)
+	}
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/inercia/MCPShell/pkg/common"
+)
+
+func TestServer_ConcurrencyLimit(t *testing.T) {
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+	srv := &Server{logger: logger}
+
+	const limit = 2
+	var running, maxRunning atomic.Int32
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			current := maxRunning.Load()
+			if n <= current || maxRunning.CompareAndSwap(current, n) {
+				break
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+		return mcp.NewToolResultText("done"), nil
+	}
+
+	// the tool limit is lower than the global one
+	limited := srv.wrapHandlerWithConcurrencyLimit("test-tool", handler, newCallSlots(limit), newCallSlots(limit+5))
+
+	var wg sync.WaitGroup
+	for i := 0; i < limit+1; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := limited(context.Background(), mcp.CallToolRequest{}); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := maxRunning.Load(); got > limit {
+		t.Errorf("Expected at most %d calls running at the same time, got %d", limit, got)
+	}
+}
+
+func TestServer_ConcurrencyLimitCancelled(t *testing.T) {
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+	srv := &Server{logger: logger}
+
+	release := make(chan struct{})
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		<-release
+		return mcp.NewToolResultText("done"), nil
+	}
+	limited := srv.wrapHandlerWithConcurrencyLimit("test-tool", handler, nil, newCallSlots(1))
+
+	// the first call takes the only slot
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = limited(context.Background(), mcp.CallToolRequest{})
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	// ... so the second one waits until it is cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := limited(ctx, mcp.CallToolRequest{}); err == nil {
+		t.Error("Expected an error for a call cancelled while waiting")
+	}
+
+	close(release)
+	<-done
+}
+
+func TestServer_ConcurrencyLimitReload(t *testing.T) {
+	testConfigFile := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig := func(max int) {
+		content := fmt.Sprintf(`mcp:
+  max_concurrent: 4
+  tools:
+    - name: "build"
+      description: "Builds the project"
+      max_concurrent: %d
+      run:
+        command: "echo built"
+`, max)
+		if err := os.WriteFile(testConfigFile, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test config file: %v", err)
+		}
+	}
+	writeConfig(1)
+
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+	srv := New(Config{ConfigFile: testConfigFile, Logger: logger})
+	if err := srv.CreateServer(); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+	toolSlots, globalSlots := srv.slots["build"], srv.slots[globalCallSlots]
+	if cap(toolSlots) != 1 || cap(globalSlots) != 4 {
+		t.Fatalf("Expected slots for 1 and 4 calls, got %d and %d", cap(toolSlots), cap(globalSlots))
+	}
+
+	// the semaphores are kept when the tools are loaded again, so running calls still count...
+	if err := srv.reloadTools(); err != nil {
+		t.Fatalf("reloadTools() error = %v", err)
+	}
+	if srv.slots["build"] != toolSlots || srv.slots[globalCallSlots] != globalSlots {
+		t.Errorf("Expected the semaphores to be kept when reloading")
+	}
+
+	// ... unless the limit changes
+	writeConfig(2)
+	if err := srv.reloadTools(); err != nil {
+		t.Fatalf("reloadTools() error = %v", err)
+	}
+	if cap(srv.slots["build"]) != 2 || srv.slots[globalCallSlots] != globalSlots {
+		t.Errorf("Expected a new semaphore for 2 calls, got one for %d calls", cap(srv.slots["build"]))
+	}
+}
@@ -9,9 +9,14 @@ import (
 	"github.com/inercia/MCPShell/pkg/config"
 )
 
+// DescriptionAuto is the description in the config file that makes the server generate
+// its description from the list of tools it provides
+const DescriptionAuto = "auto"
+
 // GetDescription returns the description for the MCP server
 // It can get the description from:
-// 1. The config file
+// 1. The config file (left out when it is DescriptionAuto, as the server generates
+// it from the tools it registers, see isAutoDescription)
 // 2. Command line flags
 // 3. Files
 // 4. URLs
@@ -28,6 +33,9 @@ func GetDescription(cfg Config) (string, error) {
 			if cfg.Logger != nil {
 				cfg.Logger.Debug("Found description in config file: %s", configDesc)
 			}
+			if strings.TrimSpace(configDesc) == DescriptionAuto {
+				configDesc = ""
+			}
 			finalDesc = configDesc
 			if cfg.Logger != nil {
 				cfg.Logger.Debug("Using description from config file: %s", configDesc)
@@ -111,3 +119,43 @@ func GetDescription(cfg Config) (string, error) {
 
 	return finalDesc, nil
 }
+
+// isAutoDescription returns true when the description of the server is generated
+// from the tools it registers (with DescriptionAuto in the config file)
+func isAutoDescription(cfg Config) bool {
+	if cfg.DescriptionOverride {
+		return false
+	}
+	loadedCfg, err := config.NewConfigFromFile(cfg.ConfigFile)
+	return err == nil && strings.TrimSpace(loadedCfg.MCP.Description) == DescriptionAuto
+}
+
+// describeTools generates a description of the server with the list of the tools
+// it registers, and the first line of their descriptions
+func describeTools(toolDefs []config.Tool) string {
+	var lines []string
+	for _, tool := range toolDefs {
+		line := "- " + tool.MCPTool.Name
+		if summary, _, _ := strings.Cut(strings.TrimSpace(tool.MCPTool.Description), "\n"); summary != "" {
+			line += ": " + summary
+		}
+		lines = append(lines, line)
+	}
+
+	if len(lines) == 0 {
+		return "This server does not provide any tools."
+	}
+	return "This server provides the following tools:\n" + strings.Join(lines, "\n")
+}
+
+// instructions returns the instructions of the server for the clients: the description,
+// after the list of the tools registered when the description is generated
+func (s *Server) instructions() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.description == "" {
+		return s.toolsDescription
+	}
+	return s.toolsDescription + "\n" + s.description
+}
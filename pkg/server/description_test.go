@@ -1,12 +1,15 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/mark3labs/mcp-go/mcp"
+
 	"github.com/inercia/MCPShell/pkg/common"
 	"github.com/inercia/MCPShell/pkg/config"
 )
@@ -342,3 +345,99 @@ func TestGetDescriptionWithURLs(t *testing.T) {
 		t.Logf("Result: %s", result)
 	})
 }
+
+func TestServer_DescriptionAuto(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	configContent := `mcp:
+  description: auto
+  tools:
+    - name: "list_files"
+      description: "Lists the files in a directory.\nThe directory must exist."
+      run:
+        command: "ls"
+    - name: "disk_usage"
+      description: "Shows the disk usage"
+      run:
+        command: "df -h"
+    - name: "old_tool"
+      description: "Old tool"
+      deprecated: true
+      run:
+        command: "true"
+    - name: "missing_tool"
+      description: "Tool with missing requirements"
+      run:
+        command: "true"
+        runners:
+          - name: exec
+            requirements:
+              executables: ["mcpshell-missing-executable"]
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+
+	srv := New(Config{
+		ConfigFile:     configPath,
+		Logger:         logger,
+		Descriptions:   []string{"Use these tools carefully."},
+		HideDeprecated: true,
+		EnableBuiltins: true,
+	})
+	if err := srv.CreateServer(); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+
+	initialize := func() string {
+		t.Helper()
+		resp := srv.mcpServer.HandleMessage(context.Background(), mustMarshalJSON(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"method":  "initialize",
+			"params": map[string]interface{}{
+				"protocolVersion": mcp.LATEST_PROTOCOL_VERSION,
+				"clientInfo":      map[string]interface{}{"name": "test", "version": "1.0"},
+			},
+		}))
+		rpcResp, ok := resp.(mcp.JSONRPCResponse)
+		if !ok {
+			t.Fatalf("initialize: unexpected response %#v", resp)
+		}
+		result, ok := rpcResp.Result.(mcp.InitializeResult)
+		if !ok {
+			t.Fatalf("initialize: unexpected result %#v", rpcResp.Result)
+		}
+		return result.Instructions
+	}
+
+	result := initialize()
+	for _, want := range []string{
+		"- list_files: Lists the files in a directory.\n",
+		"- disk_usage: Shows the disk usage\n",
+		"- __echo: ",
+		"\nUse these tools carefully.",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("Expected the description to contain %q, got:\n%s", want, result)
+		}
+	}
+	for _, unexpected := range []string{"old_tool", "missing_tool", "must exist", DescriptionAuto + "\n"} {
+		if strings.Contains(result, unexpected) {
+			t.Errorf("Unexpected %q in the generated description:\n%s", unexpected, result)
+		}
+	}
+
+	// the description is generated again when the tools are reloaded
+	configContent = strings.Replace(configContent, "Shows the disk usage", "Shows the free disk space", 1)
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	if err := srv.reloadTools(); err != nil {
+		t.Fatalf("reloadTools() error = %v", err)
+	}
+	if result := initialize(); !strings.Contains(result, "- disk_usage: Shows the free disk space\n") {
+		t.Errorf("Expected the description to be generated again, got:\n%s", result)
+	}
+}
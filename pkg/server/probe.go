@@ -0,0 +1,141 @@
+package server
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	mcpserver "github.com/mark3labs/mcp-go/server"
+
+	"github.com/inercia/MCPShell/pkg/config"
+)
+
+// loadedTools are the tools of the last configuration loaded, kept for running
+// the probes of their runners again
+type loadedTools struct {
+	cfg         *config.ToolsConfig
+	candidates  []config.Tool // the tools that can be registered, without their requirements checked
+	available   []config.Tool // the tools whose requirements were met (before the limit of tools)
+	globalSlots callSlots     // the slots of the global limit of concurrent calls
+}
+
+// probeTools starts running the probes of the tools every probe interval: tools are offered
+// when their dependencies become available, and withdrawn when they are not available
+// anymore (clients are notified of the changes).
+//
+// Returns:
+//   - A function for stopping the periodic probes
+func (s *Server) probeTools() func() {
+	s.logger.Info("Running the probes of the tools every %s", s.probeInterval)
+
+	ticker := time.NewTicker(s.probeInterval)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-ticker.C:
+				s.reprobeTools()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+		<-stopped
+	}
+}
+
+// reprobeTools checks again the requirements of the tools with probes, registering the tools
+// that became available (or that use another runner now) and removing the ones that are not
+// available anymore. The other tools (and their handlers, with their caches) are kept, and
+// nothing is registered again when the availability of the tools did not change.
+func (s *Server) reprobeTools() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := make(map[string]config.Tool, len(s.loaded.available))
+	for _, toolDef := range s.loaded.available {
+		previous[toolDef.MCPTool.Name] = toolDef
+	}
+
+	changed := make(map[string]bool)
+	available := make([]config.Tool, 0, len(s.loaded.candidates))
+	for _, toolDef := range s.loaded.candidates {
+		name := toolDef.MCPTool.Name
+		prev, wasAvailable := previous[name]
+		if !toolDef.HasProbes() {
+			if wasAvailable {
+				available = append(available, prev)
+			}
+			continue
+		}
+
+		isAvailable := toolDef.CheckToolRequirements()
+		switch {
+		case isAvailable && !wasAvailable:
+			s.logger.Info("Tool '%s' is available now", name)
+		case !isAvailable && wasAvailable:
+			s.logger.Info("Tool '%s' is not available anymore", name)
+		case isAvailable && toolDef.GetEffectiveRunner() != prev.GetEffectiveRunner():
+			s.logger.Info("Tool '%s' uses the '%s' runner now", name, toolDef.GetEffectiveRunner())
+		default:
+			if wasAvailable {
+				available = append(available, prev)
+			}
+			continue
+		}
+
+		changed[name] = true
+		if isAvailable {
+			available = append(available, toolDef)
+		}
+	}
+
+	if len(changed) == 0 {
+		s.logger.Debug("The probes did not change the available tools")
+		return
+	}
+
+	// Create the handlers of the new tools before changing anything
+	toolDefs := s.limitTools(available)
+	names := make(map[string]bool, len(toolDefs))
+	var added []mcpserver.ServerTool
+	for _, toolDef := range toolDefs {
+		name := toolDef.MCPTool.Name
+		names[name] = true
+		if s.registeredTools[name] && !changed[name] {
+			continue
+		}
+
+		serverTool, err := s.newServerTool(s.loaded.cfg, toolDef, s.loaded.globalSlots)
+		if err != nil {
+			s.logger.Error("Failed to register the probed tools, keeping the current tools: %v", err)
+			return
+		}
+		added = append(added, serverTool)
+	}
+
+	var removed []string
+	for name := range s.registeredTools {
+		if !names[name] {
+			removed = append(removed, name)
+		}
+	}
+	if len(removed) > 0 {
+		sort.Strings(removed)
+		s.logger.Info("Removing tools not available anymore: %s", strings.Join(removed, ", "))
+		s.mcpServer.DeleteTools(removed...)
+	}
+	if len(added) > 0 {
+		s.mcpServer.AddTools(added...)
+	}
+
+	s.registeredTools = names
+	s.updateToolsDescription(toolDefs)
+	s.loaded.available = available
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/inercia/MCPShell/pkg/common"
+)
+
+func TestServer_ProbeTools(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test on Windows")
+	}
+
+	dir := t.TempDir()
+	readyFile := filepath.Join(dir, "ready")
+	testConfigFile := filepath.Join(dir, "config.yaml")
+	content := fmt.Sprintf(`mcp:
+  run:
+    probe_interval: "20ms"
+  tools:
+    - name: "always"
+      description: "Tool always available"
+      cache:
+        ttl: "1h"
+      run:
+        command: "date +%%s%%N"
+    - name: "probed"
+      description: "Tool available when its dependency is ready"
+      run:
+        command: "echo probed"
+        runners:
+          - name: exec
+            requirements:
+              probe: "test -e %s"
+`, readyFile)
+	if err := os.WriteFile(testConfigFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+	srv := New(Config{ConfigFile: testConfigFile, Logger: logger})
+	if err := srv.CreateServer(); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+	if got := fmt.Sprint(registeredToolNames(srv)); got != "[always]" {
+		t.Fatalf("Expected tools [always] while the probe fails, got %s", got)
+	}
+
+	// the output of the tool that is always available is cached...
+	cached := callTool(t, srv, "always")
+
+	stop := srv.probeTools()
+	defer stop()
+
+	// the tool is offered once its probe succeeds
+	if err := os.WriteFile(readyFile, nil, 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	waitForTools(t, srv, "[always probed]")
+
+	// ... and the tools that did not change are not registered again, so they keep their cache
+	time.Sleep(100 * time.Millisecond)
+	if got := callTool(t, srv, "always"); got != cached {
+		t.Errorf("Expected the cached output %q after the probes, got %q", cached, got)
+	}
+
+	// ... and withdrawn when it fails again
+	if err := os.Remove(readyFile); err != nil {
+		t.Fatalf("Failed to remove file: %v", err)
+	}
+	waitForTools(t, srv, "[always]")
+}
+
+// callTool calls a tool registered with the MCP server, returning its text output
+func callTool(t *testing.T, srv *Server, name string) string {
+	t.Helper()
+	tool := srv.mcpServer.GetTool(name)
+	if tool == nil {
+		t.Fatalf("Tool '%s' is not registered", name)
+	}
+	result, err := tool.Handler(context.Background(), mcp.CallToolRequest{})
+	if err != nil || result.IsError {
+		t.Fatalf("Calling tool '%s' failed: %v, %+v", name, err, result)
+	}
+	return result.Content[0].(mcp.TextContent).Text
+}
+
+// waitForTools waits until the tools registered with the MCP server are the expected ones
+func waitForTools(t *testing.T, srv *Server, want string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		srv.mu.Lock()
+		got := fmt.Sprint(registeredToolNames(srv))
+		srv.mu.Unlock()
+		if got == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected tools %s, got %s", want, got)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
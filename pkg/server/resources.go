@@ -0,0 +1,93 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+)
+
+// outputResourcePrefix is the prefix of the URIs of the tool outputs stored as resources
+const outputResourcePrefix = "mcpshell://outputs/"
+
+// maxOutputResources is the number of tool outputs kept as resources: when more outputs
+// are stored, the oldest ones are removed from the server
+const maxOutputResources = 100
+
+// outputResources keeps track of the tool outputs registered as resources
+type outputResources struct {
+	mu   sync.Mutex
+	seq  int
+	uris []string
+}
+
+// add registers the output of a tool as a resource in the MCP server, returning its URI.
+// The resources are shared by all the clients of the server (with SSE or HTTP), so the
+// output can only be read in the session of the client that called the tool.
+func (o *outputResources) add(ctx context.Context, mcpServer *mcpserver.MCPServer, toolName string, output string) string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.seq++
+	uri := fmt.Sprintf("%s%s/%d", outputResourcePrefix, toolName, o.seq)
+	owner := sessionID(ctx)
+
+	resource := mcp.NewResource(uri, fmt.Sprintf("Output of '%s'", toolName), mcp.WithMIMEType("text/plain"))
+	mcpServer.AddResource(resource, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		if sessionID(ctx) != owner {
+			return nil, fmt.Errorf("resource %s not found", uri)
+		}
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{URI: uri, MIMEType: "text/plain", Text: output},
+		}, nil
+	})
+
+	o.uris = append(o.uris, uri)
+	if len(o.uris) > maxOutputResources {
+		mcpServer.RemoveResource(o.uris[0])
+		o.uris = o.uris[1:]
+	}
+
+	return uri
+}
+
+// sessionID returns the ID of the client session of a request, or an empty string
+// when there is no session (like with stdio, where there is only one client)
+func sessionID(ctx context.Context) string {
+	if session := mcpserver.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
+	}
+	return ""
+}
+
+// wrapHandlerAsResource wraps a tool handler so the output of the tool is stored
+// as a resource, and the result only contains a link to it.
+// Errors are returned as they are, so clients see them directly.
+func (s *Server) wrapHandlerAsResource(toolName string, handler mcpserver.ToolHandlerFunc) mcpserver.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, err := handler(ctx, request)
+		if err != nil || result == nil || result.IsError {
+			return result, err
+		}
+
+		var output strings.Builder
+		for _, content := range result.Content {
+			if text, ok := mcp.AsTextContent(content); ok {
+				output.WriteString(text.Text)
+			}
+		}
+
+		uri := s.outputs.add(ctx, s.mcpServer, toolName, output.String())
+		s.logger.Debug("Stored output of tool '%s' (%d bytes) as resource %s", toolName, output.Len(), uri)
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.NewTextContent(fmt.Sprintf("The output (%d bytes) is available as the resource %s", output.Len(), uri)),
+				mcp.NewResourceLink(uri, fmt.Sprintf("Output of '%s'", toolName), "", "text/plain"),
+			},
+		}, nil
+	}
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/inercia/MCPShell/pkg/common"
+)
+
+func TestServer_OutputAsResource(t *testing.T) {
+	testConfigFile := filepath.Join(t.TempDir(), "config.yaml")
+	configContent := `mcp:
+  tools:
+    - name: "report"
+      description: "Tool with a big output"
+      run:
+        command: "echo 'the full report'"
+      output:
+        as_resource: true
+`
+	if err := os.WriteFile(testConfigFile, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+	srv := New(Config{ConfigFile: testConfigFile, Logger: logger})
+	if err := srv.CreateServer(); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+
+	// the requests come from the session of a client
+	ctx := srv.mcpServer.WithContext(context.Background(), &testSession{id: "client-1"})
+	call := func(method string, params map[string]interface{}) json.RawMessage {
+		t.Helper()
+		resp := srv.mcpServer.HandleMessage(ctx, mustMarshalJSON(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"method":  method,
+			"params":  params,
+		}))
+		rpcResp, ok := resp.(mcp.JSONRPCResponse)
+		if !ok {
+			t.Fatalf("%s: unexpected response %#v", method, resp)
+		}
+		result, err := json.Marshal(rpcResp.Result)
+		if err != nil {
+			t.Fatalf("%s: failed to marshal result: %v", method, err)
+		}
+		return result
+	}
+
+	// the tool result only contains a link to the output
+	toolResult := call("tools/call", map[string]interface{}{"name": "report", "arguments": map[string]interface{}{}})
+	if strings.Contains(string(toolResult), "the full report") {
+		t.Errorf("Tool result should not contain the output: %s", toolResult)
+	}
+
+	var parsed struct {
+		Content []struct {
+			Type string `json:"type"`
+			URI  string `json:"uri"`
+		} `json:"content"`
+	}
+	if err := json.Unmarshal(toolResult, &parsed); err != nil {
+		t.Fatalf("Failed to parse tool result: %v", err)
+	}
+	uri := ""
+	for _, content := range parsed.Content {
+		if content.Type == mcp.ContentTypeLink {
+			uri = content.URI
+		}
+	}
+	if !strings.HasPrefix(uri, outputResourcePrefix+"report/") {
+		t.Fatalf("Expected a resource link under %s, got %s", outputResourcePrefix, toolResult)
+	}
+
+	// the output can be read from the resource
+	resource := call("resources/read", map[string]interface{}{"uri": uri})
+	if !strings.Contains(string(resource), "the full report") {
+		t.Errorf("Resource %s does not contain the output: %s", uri, resource)
+	}
+
+	// ... but not from the sessions of other clients
+	for _, other := range []context.Context{
+		srv.mcpServer.WithContext(context.Background(), &testSession{id: "client-2"}),
+		context.Background(),
+	} {
+		resp := srv.mcpServer.HandleMessage(other, mustMarshalJSON(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"method":  "resources/read",
+			"params":  map[string]interface{}{"uri": uri},
+		}))
+		if _, ok := resp.(mcp.JSONRPCError); !ok {
+			t.Errorf("Expected the output not to be readable from other sessions, got %#v", resp)
+		}
+	}
+
+	// only the most recent outputs are kept
+	for i := 0; i < maxOutputResources; i++ {
+		call("tools/call", map[string]interface{}{"name": "report", "arguments": map[string]interface{}{}})
+	}
+	resp := srv.mcpServer.HandleMessage(ctx, mustMarshalJSON(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "resources/read",
+		"params":  map[string]interface{}{"uri": uri},
+	}))
+	if _, ok := resp.(mcp.JSONRPCError); !ok {
+		t.Errorf("Expected the oldest output to be removed, got %#v", resp)
+	}
+}
+
+// testSession is a client session with a fixed ID
+type testSession struct {
+	id string
+}
+
+func (s *testSession) Initialize()       {}
+func (s *testSession) Initialized() bool { return true }
+func (s *testSession) SessionID() string { return s.id }
+func (s *testSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return make(chan mcp.JSONRPCNotification, 10)
+}
@@ -7,10 +7,18 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	mcpserver "github.com/mark3labs/mcp-go/server"
@@ -29,7 +37,36 @@ type Server struct {
 	version     string
 	description string
 
+	autoDescription  bool   // whether the description starts with the list of the registered tools
+	toolsDescription string // the list of the registered tools, when the description is generated
+
+	failOnWarnings bool // whether validation warnings should be reported as errors
+	strict         bool // whether validation should check the runner options
+
+	validateTool   string // the only tool to validate (all the tools if empty)
+	hideDeprecated bool   // whether deprecated tools are not registered
+	maxTools       int    // the maximum number of tools registered (0 for no limit)
+	enableBuiltins bool   // whether the built-in diagnostic tools are registered
+	outputDir      string // the default parent directory for the output directories of the tools
+	disableCache   bool   // whether the caches of the tool outputs are disabled
+
+	transport  string // the transport used for serving clients (stdio or sse)
+	listenAddr string // the address the server listens on (for the sse transport)
+
+	watch         bool                           // whether the configuration is reloaded when it changes
+	watchPaths    []string                       // the local files and directories watched for changes
+	resolveConfig func() (string, func(), error) // resolves the configuration again when reloading it
+	configCleanup func()                         // removes the temporary files of the reloaded configuration
+	probeInterval time.Duration                  // the interval for loading the tools again (0 when disabled)
+
+	mu              sync.Mutex      // protects the configuration file and the registered tools when reloading
+	registeredTools map[string]bool // the names of the tools registered with the MCP server
+	loaded          loadedTools     // the tools of the last configuration loaded
+
+	slots map[string]callSlots // the semaphores of the limits of concurrent calls, by tool name (kept across reloads)
+
 	mcpServer *mcpserver.MCPServer // MCP server instance
+	outputs   outputResources      // tool outputs stored as resources
 
 	logger *common.Logger
 }
@@ -43,8 +80,42 @@ type Config struct {
 	Descriptions        []string       // Descriptions shown to AI clients (can be specified multiple times)
 	DescriptionFiles    []string       // Paths to files containing descriptions (can be specified multiple times)
 	DescriptionOverride bool           // Whether to override the description in the config file
+	FailOnWarnings      bool           // Whether validation warnings should make validation fail
+	Strict              bool           // Whether validation should check the runner options of all tools
+	ValidateTool        string         // Name of the only tool to validate (all the tools if empty)
+	HideDeprecated      bool           // Whether deprecated tools should not be registered
+	MaxTools            int            // Maximum number of tools registered, by priority (0 for no limit)
+	EnableBuiltins      bool           // Whether the built-in diagnostic tools (like __echo) should be registered
+	OutputDir           string         // Default parent directory for the output directories of the tools
+	DisableCache        bool           // Disable the caches of the tool outputs (even for tools with a `cache`)
+	Transport           string         // Transport for serving clients: "stdio" (the default) or "sse"
+	ListenAddr          string         // Address to listen on with the "sse" transport (defaults to DefaultListenAddr)
+
+	// Watch makes the server reload the tools when the configuration changes
+	Watch bool
+
+	// WatchPaths are the local files and directories watched for changes (the
+	// configuration file when empty)
+	WatchPaths []string
+
+	// ResolveConfig resolves the configuration file again when reloading it, for
+	// configurations that are merged from several files (ConfigFile is reloaded when nil).
+	// The cleanup function is called when the configuration file is not used anymore.
+	ResolveConfig func() (string, func(), error)
 }
 
+// Transports for serving clients
+const (
+	// TransportStdio serves a single client through the standard input and output
+	TransportStdio = "stdio"
+
+	// TransportSSE serves multiple clients over HTTP with Server-Sent Events
+	TransportSSE = "sse"
+)
+
+// DefaultListenAddr is the address the server listens on with the SSE transport
+const DefaultListenAddr = ":8080"
+
 // New creates a new Server instance with the provided configuration
 //
 // Parameters:
@@ -75,11 +146,36 @@ func New(cfg Config) *Server {
 		logger:      cfg.Logger,
 		version:     cfg.Version,
 		description: finalDescription,
+
+		autoDescription: isAutoDescription(cfg),
+
+		failOnWarnings: cfg.FailOnWarnings,
+		strict:         cfg.Strict,
+
+		validateTool:   cfg.ValidateTool,
+		hideDeprecated: cfg.HideDeprecated,
+		maxTools:       cfg.MaxTools,
+		enableBuiltins: cfg.EnableBuiltins,
+		outputDir:      cfg.OutputDir,
+		disableCache:   cfg.DisableCache,
+
+		transport:  cfg.Transport,
+		listenAddr: cfg.ListenAddr,
+
+		watch:         cfg.Watch,
+		watchPaths:    cfg.WatchPaths,
+		resolveConfig: cfg.ResolveConfig,
 	}
 }
 
 // Validate verifies the configuration file without starting the server.
 // It loads the configuration, attempts to compile all constraints, and checks for errors.
+// Constraints that can never be true (or are always true) are reported as warnings,
+// or as errors when the server was created with FailOnWarnings.
+// In strict mode, the options of all the runners of every tool are also checked
+// against the runner schemas, and unknown option keys are reported as errors.
+// When the server was created with ValidateTool, only that tool is validated
+// (the rest of the tools are ignored).
 //
 // Returns:
 //   - nil if the configuration is valid
@@ -102,10 +198,55 @@ func (s *Server) Validate() error {
 
 	s.logger.Info("Found %d tools in configuration", len(cfg.MCP.Tools))
 
+	// Global constraints that apply to no tool are most probably a mistake (like a typo
+	// in the name of a parameter), as they are never evaluated
+	if unused := cfg.UnusedGlobalConstraints(); len(unused) > 0 {
+		s.logger.Error("Global constraints not applying to any tool: %s", strings.Join(unused, ", "))
+		return fmt.Errorf("global constraints not applying to any tool (they reference undeclared parameters): %s",
+			strings.Join(unused, ", "))
+	}
+
+	// Only keep the requested tool, if any
+	if s.validateTool != "" {
+		toolIndex := s.findToolByName(cfg.MCP.Tools, s.validateTool)
+		if toolIndex == -1 {
+			s.logger.Error("Tool '%s' not found in the configuration file", s.validateTool)
+			return fmt.Errorf("tool '%s' not found in the configuration file", s.validateTool)
+		}
+		s.logger.Info("Validating only tool '%s'", s.validateTool)
+		cfg.MCP.Tools = cfg.MCP.Tools[toolIndex : toolIndex+1]
+	}
+
 	// Use shell from config if present and no shell is explicitly set
 	shell := s.shell
 	if shell == "" && cfg.MCP.Run.Shell != "" {
 		s.logger.Debug("Using shell from config: %s", cfg.MCP.Run.Shell)
+		shell = cfg.MCP.Run.Shell
+	}
+
+	// In strict mode, check the options of all the runners (even the ones not usable here)
+	if s.strict {
+		for _, toolConfig := range cfg.MCP.Tools {
+			for _, runner := range toolConfig.Run.Runners {
+				s.logger.Debug("Validating options of runner '%s' for tool '%s'", runner.Name, toolConfig.Name)
+				err := command.ValidateRunnerOptions(command.RunnerType(runner.Name), command.RunnerOptions(runner.Options), true)
+				if err != nil {
+					s.logger.Error("Invalid runner '%s' for tool '%s': %v", runner.Name, toolConfig.Name, err)
+					return fmt.Errorf("invalid runner '%s' for tool '%s': %w", runner.Name, toolConfig.Name, err)
+				}
+			}
+		}
+	}
+
+	// Check the conditions of all the tools (even the ones disabled by them)
+	for _, toolConfig := range cfg.MCP.Tools {
+		if toolConfig.EnabledIf == "" {
+			continue
+		}
+		if err := common.ValidateCondition(toolConfig.EnabledIf); err != nil {
+			s.logger.Error("Invalid enabled_if condition for tool '%s': %v", toolConfig.Name, err)
+			return fmt.Errorf("invalid enabled_if condition for tool '%s': %w", toolConfig.Name, err)
+		}
 	}
 
 	// Get filtered tool definitions based on prerequisites
@@ -147,15 +288,68 @@ func (s *Server) Validate() error {
 		// Get parameter types for constraint validation
 		paramTypes := cfg.MCP.Tools[toolIndex].Params
 
+		// Validate the parameter declarations (like their patterns)
+		if err := command.ValidateParams(paramTypes); err != nil {
+			s.logger.Error("Invalid parameters for tool '%s': %v", toolDef.MCPTool.Name, err)
+			return fmt.Errorf("invalid parameters for tool '%s': %w", toolDef.MCPTool.Name, err)
+		}
+
 		// Validate constraints by attempting to compile them
 		if len(toolDef.Config.Constraints) > 0 {
 			s.logger.Debug("Compiling %d constraints for tool '%s'", len(toolDef.Config.Constraints), toolDef.MCPTool.Name)
-			_, err := common.NewCompiledConstraints(toolDef.Config.Constraints, paramTypes, s.logger)
+			_, err := common.NewCompiledConstraintsWithMacros(toolDef.Config.Constraints, toolDef.ConstraintMacros, paramTypes, s.logger)
 			if err != nil {
 				s.logger.Error("Failed to compile constraints for tool '%s': %v", toolDef.MCPTool.Name, err)
 				return fmt.Errorf("constraint compilation error for tool '%s': %w", toolDef.MCPTool.Name, err)
 			}
 			s.logger.Debug("All constraints for tool '%s' compiled successfully", toolDef.MCPTool.Name)
+
+			// Look for constraints that are obviously wrong
+			warnings, err := common.AnalyzeConstraints(toolDef.Config.Constraints, toolDef.ConstraintMacros, paramTypes)
+			if err != nil {
+				return fmt.Errorf("constraint analysis error for tool '%s': %w", toolDef.MCPTool.Name, err)
+			}
+			for _, warning := range warnings {
+				s.logger.Warn("Tool '%s': %s", toolDef.MCPTool.Name, warning)
+			}
+			if len(warnings) > 0 && s.failOnWarnings {
+				return fmt.Errorf("constraint warnings for tool '%s': %s", toolDef.MCPTool.Name, strings.Join(warnings, "; "))
+			}
+		}
+
+		// Validate the advisory constraints the same way
+		if len(toolDef.Config.Warnings) > 0 {
+			if _, err := common.NewCompiledConstraintsWithMacros(toolDef.Config.Warnings, toolDef.ConstraintMacros, paramTypes, s.logger); err != nil {
+				s.logger.Error("Failed to compile warnings for tool '%s': %v", toolDef.MCPTool.Name, err)
+				return fmt.Errorf("warning compilation error for tool '%s': %w", toolDef.MCPTool.Name, err)
+			}
+		}
+
+		// Validate annotations
+		if toolDef.Config.ReadOnly && toolDef.Config.Destructive {
+			s.logger.Error("Tool '%s' cannot be both read-only and destructive", toolDef.MCPTool.Name)
+			return fmt.Errorf("tool '%s' cannot be both read-only and destructive", toolDef.MCPTool.Name)
+		}
+
+		// Validate output summarization
+		if summarize := toolDef.Config.Output.Summarize; summarize != nil && strings.TrimSpace(summarize.Command) == "" {
+			s.logger.Error("Empty summarize command for tool '%s'", toolDef.MCPTool.Name)
+			return fmt.Errorf("empty summarize command for tool '%s'", toolDef.MCPTool.Name)
+		}
+
+		// Validate the regex applied to the output
+		if _, err := toolDef.Config.Output.GetRegex(); err != nil {
+			s.logger.Error("Invalid output regex for tool '%s': %v", toolDef.MCPTool.Name, err)
+			return fmt.Errorf("invalid output regex for tool '%s': %w", toolDef.MCPTool.Name, err)
+		}
+
+		// Validate the shell flags: pipefail is not supported by POSIX shells like dash
+		if flags := toolDef.Config.Run.ShellFlags; strings.Contains(flags, "pipefail") && isPOSIXShell(shell) {
+			warning := fmt.Sprintf("shell flags '%s' use pipefail, which is not supported by the shell '%s'", flags, shell)
+			s.logger.Warn("Tool '%s': %s", toolDef.MCPTool.Name, warning)
+			if s.failOnWarnings {
+				return fmt.Errorf("shell flags warning for tool '%s': %s", toolDef.MCPTool.Name, warning)
+			}
 		}
 
 		// Validate command template
@@ -179,12 +373,25 @@ func (s *Server) Validate() error {
 	return nil
 }
 
+// isPOSIXShell returns true for shells that only support the POSIX features (like dash)
+func isPOSIXShell(shell string) bool {
+	switch filepath.Base(shell) {
+	case "sh", "dash", "ash":
+		return true
+	}
+	return false
+}
+
 // Start initializes the MCP server, loads tools from the configuration file,
 // and starts listening for client connections.
 //
 // Returns:
 //   - An error if server initialization or startup fails
 func (s *Server) Start() error {
+	if s.transport != "" && s.transport != TransportStdio && s.transport != TransportSSE {
+		return fmt.Errorf("unknown transport '%s' (must be '%s' or '%s')", s.transport, TransportStdio, TransportSSE)
+	}
+
 	s.logger.Info("Initializing MCP server")
 
 	// Create and configure MCP server
@@ -192,6 +399,41 @@ func (s *Server) Start() error {
 		return err
 	}
 
+	// Remove the persistent containers of the docker runners when the server stops
+	defer command.StopPersistentContainers(s.logger)
+
+	// Report which constraints blocked the most calls when the server stops
+	defer s.logConstraintRejections()
+
+	// Reload the tools when the configuration changes
+	if s.watch {
+		stop, err := s.watchConfig()
+		if err != nil {
+			return err
+		}
+		defer stop()
+	}
+
+	// Run the probes of the runners again periodically
+	if s.probeInterval > 0 {
+		defer s.probeTools()()
+	}
+
+	if s.transport == TransportSSE {
+		addr := s.listenAddr
+		if addr == "" {
+			addr = DefaultListenAddr
+		}
+
+		s.logger.Info("Starting MCP server with SSE handler on %s", addr)
+		sseServer := s.newSSEServer()
+		if err := s.serveUntilSignal(func() error { return sseServer.Start(addr) }, sseServer.Shutdown); err != nil {
+			s.logger.Error("Server error: %v", err)
+			return fmt.Errorf("server error: %v", err)
+		}
+		return nil
+	}
+
 	s.logger.Info("Starting MCP server with stdio handler")
 
 	// Start the stdio server
@@ -203,6 +445,19 @@ func (s *Server) Start() error {
 	return nil
 }
 
+// logConstraintRejections logs the number of calls blocked by every constraint
+func (s *Server) logConstraintRejections() {
+	for _, rejection := range command.ConstraintRejections() {
+		s.logger.Info("Constraint of tool '%s' blocked %d call(s): %s", rejection.Tool, rejection.Count, rejection.Constraint)
+	}
+}
+
+// newSSEServer creates the SSE server for the MCP server, so multiple clients
+// can connect to it over HTTP (at the /sse and /message endpoints)
+func (s *Server) newSSEServer() *mcpserver.SSEServer {
+	return mcpserver.NewSSEServer(s.mcpServer)
+}
+
 // CreateServer initializes the MCP server instance
 func (s *Server) CreateServer() error {
 	// First create the MCP server
@@ -210,7 +465,7 @@ func (s *Server) CreateServer() error {
 	var options []mcpserver.ServerOption
 
 	// Load server configuration for description, shell, etc.
-	cfg, err := config.NewConfigFromFile(s.configFile)
+	cfg, err := s.loadConfig()
 	if err != nil {
 		s.logger.Error("Failed to load config: %v", err)
 		return fmt.Errorf("failed to load config: %w", err)
@@ -222,12 +477,42 @@ func (s *Server) CreateServer() error {
 		s.logger.Debug("Using shell from config: %s", s.shell)
 	}
 
-	// Add description if provided
-	if s.description != "" {
+	// Add description if provided. When it is generated from the tools, it is set in
+	// every initialization, so it is up to date after reloading the tools
+	if s.autoDescription {
+		s.logger.Debug("Generating the description for MCP server from the registered tools")
+		hooks := &mcpserver.Hooks{}
+		hooks.AddAfterInitialize(func(ctx context.Context, id any, request *mcp.InitializeRequest, result *mcp.InitializeResult) {
+			result.Instructions = s.instructions()
+		})
+		options = append(options, mcpserver.WithHooks(hooks))
+	} else if s.description != "" {
 		s.logger.Debug("Using description for MCP server: %s", s.description)
 		options = append(options, mcpserver.WithInstructions(s.description))
 	}
 
+	// Tools returning their output as a resource need the resources capability
+	for _, tool := range cfg.MCP.Tools {
+		if tool.Output.AsResource {
+			options = append(options, mcpserver.WithResourceCapabilities(false, true))
+			break
+		}
+	}
+
+	// Load the tools again periodically, so the probes of the runners are run again
+	if cfg.MCP.Run.ProbeInterval != "" {
+		s.probeInterval, err = time.ParseDuration(cfg.MCP.Run.ProbeInterval)
+		if err != nil || s.probeInterval <= 0 {
+			s.logger.Error("Invalid probe interval '%s'", cfg.MCP.Run.ProbeInterval)
+			return fmt.Errorf("invalid probe interval '%s'", cfg.MCP.Run.ProbeInterval)
+		}
+	}
+
+	// Clients are notified when the tools change after reloading the configuration
+	if s.watch || s.probeInterval > 0 {
+		options = append(options, mcpserver.WithToolCapabilities(true))
+	}
+
 	// Initialize the MCP server BEFORE loading tools
 	s.mcpServer = mcpserver.NewMCPServer(serverName, s.version, options...)
 
@@ -240,6 +525,34 @@ func (s *Server) CreateServer() error {
 	return nil
 }
 
+// loadConfig loads the configuration file, adding the built-in tools when they are enabled.
+// With the built-in tools enabled, the configuration file is optional.
+func (s *Server) loadConfig() (*config.ToolsConfig, error) {
+	s.mu.Lock()
+	configFile := s.configFile
+	s.mu.Unlock()
+
+	return s.loadConfigFile(configFile)
+}
+
+// loadConfigFile loads the given configuration file like loadConfig does
+func (s *Server) loadConfigFile(configFile string) (*config.ToolsConfig, error) {
+	cfg := &config.ToolsConfig{}
+	if configFile != "" || !s.enableBuiltins {
+		var err error
+		cfg, err = config.NewConfigFromFile(configFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if s.enableBuiltins {
+		cfg.MCP.Tools = append(cfg.MCP.Tools, config.BuiltinTools()...)
+	}
+
+	return cfg, nil
+}
+
 // loadTools loads tools from the configuration and registers them with the server
 func (s *Server) loadTools(cfg *config.ToolsConfig) error {
 	// Check if there are any tools defined
@@ -250,62 +563,139 @@ func (s *Server) loadTools(cfg *config.ToolsConfig) error {
 
 	s.logger.Info("Found %d tools in configuration", len(cfg.MCP.Tools))
 
-	// Create and register tools
-	toolDefs := cfg.GetTools()
+	if s.maxTools < 0 {
+		return fmt.Errorf("invalid maximum number of tools %d", s.maxTools)
+	}
 
-	// Check if some tools were filtered out due to prerequisites not met
-	if len(toolDefs) < len(cfg.MCP.Tools) {
-		skippedCount := len(cfg.MCP.Tools) - len(toolDefs)
+	// All the tools share the slots of the global limit of concurrent calls
+	if cfg.MCP.MaxConcurrent < 0 {
+		return fmt.Errorf("invalid max_concurrent %d", cfg.MCP.MaxConcurrent)
+	}
+	globalSlots := s.callSlotsFor(globalCallSlots, cfg.MCP.MaxConcurrent)
+
+	// Check the prerequisites of the tools, keeping the ones that can be used
+	candidates := s.filterDeprecated(cfg.GetConfiguredTools())
+	available := make([]config.Tool, 0, len(candidates))
+	for _, toolDef := range candidates {
+		if !toolDef.CheckToolRequirements() {
+			s.logger.Info("Tool '%s' was skipped due to unmet prerequisites", toolDef.MCPTool.Name)
+			continue
+		}
+		available = append(available, toolDef)
+	}
+	if skippedCount := len(candidates) - len(available); skippedCount > 0 {
 		s.logger.Info("Skipped %d tool(s) due to unmet prerequisites", skippedCount)
+	}
 
-		// Log which tools were skipped
-		for _, toolConfig := range cfg.MCP.Tools {
-			found := false
-			for _, toolDef := range toolDefs {
-				if toolDef.MCPTool.Name == toolConfig.Name {
-					found = true
-					break
-				}
-			}
+	toolDefs := s.limitTools(available)
 
-			if !found {
-				s.logger.Info("Tool '%s' was skipped due to unmet prerequisites", toolConfig.Name)
-			}
+	s.logger.Info("Registering %d tools after checking prerequisites", len(toolDefs))
+
+	// Create all the handlers before registering any tool, so a configuration with
+	// errors does not leave the server with only some of its tools
+	serverTools := make([]mcpserver.ServerTool, 0, len(toolDefs))
+	for _, toolDef := range toolDefs {
+		serverTool, err := s.newServerTool(cfg, toolDef, globalSlots)
+		if err != nil {
+			return err
 		}
+		serverTools = append(serverTools, serverTool)
 	}
 
-	s.logger.Info("Registering %d tools after checking prerequisites", len(toolDefs))
+	s.registerTools(serverTools)
+	s.updateToolsDescription(toolDefs)
+	s.loaded = loadedTools{cfg: cfg, candidates: candidates, available: available, globalSlots: globalSlots}
 
-	for _, toolDef := range toolDefs {
-		s.logger.Debug("Registering tool '%s'", toolDef.MCPTool.Name)
+	return nil
+}
 
-		// Get the parameter types for this tool
-		params := cfg.MCP.Tools[s.findToolByName(cfg.MCP.Tools, toolDef.MCPTool.Name)].Params
+// updateToolsDescription updates the list of the registered tools in the description of
+// the server, when it is generated from them
+func (s *Server) updateToolsDescription(toolDefs []config.Tool) {
+	if s.autoDescription {
+		s.toolsDescription = describeTools(toolDefs)
+	}
+}
 
-		// Create a new command handler instance
-		cmdHandler, err := command.NewCommandHandler(toolDef, params, s.shell, s.logger)
-		if err != nil {
-			s.logger.Error("Failed to create handler for tool '%s': %v", toolDef.MCPTool.Name, err)
-			return fmt.Errorf("failed to create handler for tool '%s': %w", toolDef.MCPTool.Name, err)
-		}
+// newServerTool creates the handler of a tool, returning the tool to be registered
+// with the MCP server
+func (s *Server) newServerTool(cfg *config.ToolsConfig, toolDef config.Tool, globalSlots callSlots) (mcpserver.ServerTool, error) {
+	s.logger.Debug("Registering tool '%s'", toolDef.MCPTool.Name)
 
-		// Get the MCP handler and wrap it with panic recovery
-		safeHandler := s.wrapHandlerWithPanicRecovery(cmdHandler.GetMCPHandler())
+	// Get the parameter types for this tool
+	params := cfg.MCP.Tools[s.findToolByName(cfg.MCP.Tools, toolDef.MCPTool.Name)].Params
 
-		// Add the tool to the server
-		s.mcpServer.AddTool(toolDef.MCPTool, safeHandler)
+	// Create the output directories of the tool in the default place, unless it has its own
+	if outputDir := toolDef.Config.Run.OutputDir; outputDir != nil && outputDir.Parent == "" && s.outputDir != "" {
+		withParent := *outputDir
+		withParent.Parent = s.outputDir
+		toolDef.Config.Run.OutputDir = &withParent
+	}
 
-		// Print whether constraints are enabled
-		if len(toolDef.Config.Constraints) > 0 {
-			msg := fmt.Sprintf("Registered tool: '%s' (with %d constraints)", toolDef.MCPTool.Name, len(toolDef.Config.Constraints))
-			s.logger.Info(msg)
-		} else {
-			msg := fmt.Sprintf("Registered tool: '%s'", toolDef.MCPTool.Name)
-			s.logger.Info(msg)
+	// Always run the commands when caching is disabled
+	if s.disableCache && toolDef.Config.Cache != nil {
+		s.logger.Debug("Caching disabled for tool '%s'", toolDef.MCPTool.Name)
+		toolDef.Config.Cache = nil
+	}
+
+	if toolDef.Config.MaxConcurrent < 0 {
+		return mcpserver.ServerTool{}, fmt.Errorf("invalid max_concurrent %d for tool '%s'", toolDef.Config.MaxConcurrent, toolDef.MCPTool.Name)
+	}
+
+	// Create a new command handler instance
+	cmdHandler, err := command.NewCommandHandler(toolDef, params, s.shell, s.logger)
+	if err != nil {
+		s.logger.Error("Failed to create handler for tool '%s': %v", toolDef.MCPTool.Name, err)
+		return mcpserver.ServerTool{}, fmt.Errorf("failed to create handler for tool '%s': %w", toolDef.MCPTool.Name, err)
+	}
+
+	// Warn about missing environment variables (the tool will fail when called)
+	for _, name := range command.MissingEnvVars(toolDef.Config.Run.RequireEnv) {
+		s.logger.Warn("Tool '%s' requires environment variable %s, which is not set", toolDef.MCPTool.Name, name)
+	}
+
+	// Get the MCP handler and wrap it with panic recovery
+	handler := cmdHandler.GetMCPHandler()
+	if toolDef.Config.Output.AsResource {
+		handler = s.wrapHandlerAsResource(toolDef.MCPTool.Name, handler)
+	}
+	safeHandler := s.wrapHandlerWithPanicRecovery(handler)
+	safeHandler = s.wrapHandlerWithConcurrencyLimit(toolDef.MCPTool.Name, safeHandler, s.callSlotsFor(toolDef.MCPTool.Name, toolDef.Config.MaxConcurrent), globalSlots)
+
+	// Print whether constraints are enabled
+	if len(toolDef.Config.Constraints) > 0 {
+		msg := fmt.Sprintf("Registered tool: '%s' (with %d constraints)", toolDef.MCPTool.Name, len(toolDef.Config.Constraints))
+		s.logger.Info(msg)
+	} else {
+		msg := fmt.Sprintf("Registered tool: '%s'", toolDef.MCPTool.Name)
+		s.logger.Info(msg)
+	}
+
+	return mcpserver.ServerTool{Tool: toolDef.MCPTool, Handler: safeHandler}, nil
+}
+
+// registerTools adds the tools to the MCP server, replacing the tools with the same
+// name and removing the tools registered before that are not in the list anymore
+func (s *Server) registerTools(tools []mcpserver.ServerTool) {
+	names := make(map[string]bool, len(tools))
+	for _, tool := range tools {
+		names[tool.Tool.Name] = true
+	}
+
+	var removed []string
+	for name := range s.registeredTools {
+		if !names[name] {
+			removed = append(removed, name)
 		}
 	}
+	if len(removed) > 0 {
+		sort.Strings(removed)
+		s.logger.Info("Removing tools not in the configuration anymore: %s", strings.Join(removed, ", "))
+		s.mcpServer.DeleteTools(removed...)
+	}
 
-	return nil
+	s.mcpServer.AddTools(tools...)
+	s.registeredTools = names
 }
 
 // wrapHandlerWithPanicRecovery adds panic recovery to a tool handler
@@ -342,6 +732,42 @@ func (s *Server) findToolByName(tools []config.MCPToolConfig, name string) int {
 	return -1
 }
 
+// filterDeprecated removes the deprecated tools from the list when the server
+// was created with HideDeprecated
+func (s *Server) filterDeprecated(toolDefs []config.Tool) []config.Tool {
+	if !s.hideDeprecated {
+		return toolDefs
+	}
+
+	filtered := make([]config.Tool, 0, len(toolDefs))
+	for _, toolDef := range toolDefs {
+		if toolDef.Config.Deprecated {
+			s.logger.Info("Tool '%s' is hidden because it is deprecated", toolDef.MCPTool.Name)
+			continue
+		}
+		filtered = append(filtered, toolDef)
+	}
+	return filtered
+}
+
+// limitTools keeps only the first MaxTools tools of the list when the server was
+// created with a limit, where tools with higher priorities come first and tools
+// with the same priority keep the order of the configuration
+func (s *Server) limitTools(toolDefs []config.Tool) []config.Tool {
+	if s.maxTools <= 0 || len(toolDefs) <= s.maxTools {
+		return toolDefs
+	}
+
+	sorted := append([]config.Tool(nil), toolDefs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Config.Priority > sorted[j].Config.Priority
+	})
+	for _, toolDef := range sorted[s.maxTools:] {
+		s.logger.Info("Tool '%s' is hidden because of the limit of %d tools", toolDef.MCPTool.Name, s.maxTools)
+	}
+	return sorted[:s.maxTools]
+}
+
 // GetTools returns all available MCP tools from the server
 // Used by the agent to get tools for the LLM
 func (s *Server) GetTools() ([]mcp.Tool, error) {
@@ -353,13 +779,13 @@ func (s *Server) GetTools() ([]mcp.Tool, error) {
 	// Create a slice to store the tools
 	// Since we don't have direct access to all tools, we'll need to extract them
 	// from the original configuration
-	cfg, err := config.NewConfigFromFile(s.configFile)
+	cfg, err := s.loadConfig()
 	if err != nil {
 		s.logger.Error("Failed to load config: %v", err)
 		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 
-	toolDefs := cfg.GetTools()
+	toolDefs := s.limitTools(s.filterDeprecated(cfg.GetTools()))
 	tools := make([]mcp.Tool, 0, len(toolDefs))
 
 	for _, toolDef := range toolDefs {
@@ -369,6 +795,15 @@ func (s *Server) GetTools() ([]mcp.Tool, error) {
 	return tools, nil
 }
 
+// GetContext returns the server context (the `context` map of the configuration)
+func (s *Server) GetContext() (map[string]string, error) {
+	cfg, err := s.loadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	return cfg.MCP.Context, nil
+}
+
 // convertMCPToolsToOpenAI converts MCP tools to OpenAI tool format
 func (s *Server) GetOpenAITools() ([]openai.Tool, error) {
 	mcpTools, err := s.GetTools()
@@ -560,36 +995,6 @@ func (s *Server) ExecuteTool(ctx context.Context, toolName string, args map[stri
 		}
 	}
 
-	// If we couldn't extract text content, try using the original text template from the tool config
-	if resultText == "" {
-		// Try to get the original tool config to access the output template
-		cfg, err := config.NewConfigFromFile(s.configFile)
-		if err == nil {
-			toolIndex := s.findToolByName(cfg.MCP.Tools, toolName)
-			if toolIndex >= 0 {
-				// Get the template from the YAML file structure directly
-				// This is a workaround for accessing custom fields that might not be in our structs
-				var toolConfig map[string]interface{}
-				toolBytes, err := json.Marshal(cfg.MCP.Tools[toolIndex])
-				if err == nil {
-					if err := json.Unmarshal(toolBytes, &toolConfig); err == nil {
-						if outputMap, ok := toolConfig["output"].(map[string]interface{}); ok {
-							if template, ok := outputMap["template"].(string); ok && template != "" {
-								// Simple variable substitution for ${variable} format
-								for argName, argValue := range args {
-									if strValue, ok := argValue.(string); ok {
-										template = strings.ReplaceAll(template, "${"+argName+"}", strValue)
-									}
-								}
-								resultText = template
-							}
-						}
-					}
-				}
-			}
-		}
-	}
-
 	return resultText, nil
 }
 
@@ -608,10 +1013,57 @@ func (s *Server) StartHTTP(port int) error {
 	if err := s.CreateServer(); err != nil {
 		return err
 	}
-	http.HandleFunc("/sse", s.handleMCPHTTP)
-	addr := fmt.Sprintf(":%d", port)
-	s.logger.Info("MCP HTTP server listening on http://localhost%s/sse", addr)
-	return http.ListenAndServe(addr, nil)
+
+	// Remove the persistent containers of the docker runners when the server stops
+	defer command.StopPersistentContainers(s.logger)
+
+	// Report which constraints blocked the most calls when the server stops
+	defer s.logConstraintRejections()
+
+	if s.watch {
+		stop, err := s.watchConfig()
+		if err != nil {
+			return err
+		}
+		defer stop()
+	}
+
+	// Run the probes of the runners again periodically
+	if s.probeInterval > 0 {
+		defer s.probeTools()()
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sse", s.handleMCPHTTP)
+	httpServer := &http.Server{Addr: fmt.Sprintf(":%d", port), Handler: mux}
+	s.logger.Info("MCP HTTP server listening on http://localhost%s/sse", httpServer.Addr)
+	return s.serveUntilSignal(httpServer.ListenAndServe, httpServer.Shutdown)
+}
+
+// shutdownTimeout is the maximum time for stopping the HTTP servers gracefully
+const shutdownTimeout = 5 * time.Second
+
+// serveUntilSignal runs the serve function until it fails or the process receives SIGINT
+// or SIGTERM, when the server is stopped with shutdown. This way the server returns (and
+// the deferred cleanups, like removing the persistent containers, are run) on these signals.
+func (s *Server) serveUntilSignal(serve func() error, shutdown func(context.Context) error) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- serve() }()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+		s.logger.Info("Received a termination signal, stopping the server")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		return shutdown(shutdownCtx)
+	}
 }
 
 // handleMCPHTTP handles HTTP POST requests for MCP protocol
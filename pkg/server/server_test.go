@@ -1,9 +1,17 @@
 package server
 
 import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/inercia/MCPShell/pkg/common"
 	"github.com/inercia/MCPShell/pkg/config"
@@ -135,3 +143,573 @@ func TestServer_loadTools(t *testing.T) {
 	// Skip actually loading the tools to avoid running commands
 	t.Skip("loadTools() is tested in integration tests")
 }
+
+func TestServer_ValidateConstraintWarnings(t *testing.T) {
+	tempDir := t.TempDir()
+
+	testConfigFile := filepath.Join(tempDir, "config.yaml")
+	configContent := `mcp:
+  tools:
+    - name: "contradictory_tool"
+      description: "Tool with a constraint that can never be true"
+      params:
+        value:
+          type: number
+          description: "Test parameter"
+      constraints:
+        - "value > 0.0 && value < 0.0"
+      run:
+        command: "echo {{ .value }}"
+`
+	if err := os.WriteFile(testConfigFile, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+
+	logFile := filepath.Join(tempDir, "test.log")
+	logger, err := common.NewLogger("", logFile, common.LogLevelInfo, true)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer func() { _ = logger.Close() }()
+
+	// by default, warnings do not make validation fail
+	srv := New(Config{ConfigFile: testConfigFile, Logger: logger})
+	if err := srv.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v, want nil", err)
+	}
+
+	logContent, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	if !strings.Contains(string(logContent), "[WARN] Tool 'contradictory_tool': constraint 'value > 0.0 && value < 0.0' can never be true") {
+		t.Errorf("Expected a warning about the contradictory constraint in the log, got:\n%s", logContent)
+	}
+
+	// with FailOnWarnings, the warning is an error
+	srv = New(Config{ConfigFile: testConfigFile, Logger: logger, FailOnWarnings: true})
+	if err := srv.Validate(); err == nil || !strings.Contains(err.Error(), "can never be true") {
+		t.Errorf("Validate() with FailOnWarnings error = %v, want error about the contradictory constraint", err)
+	}
+}
+
+func TestServer_ValidateStrictRunnerOptions(t *testing.T) {
+	testConfigFile := filepath.Join(t.TempDir(), "config.yaml")
+	configContent := `mcp:
+  tools:
+    - name: "docker_tool"
+      description: "Docker tool without an image"
+      run:
+        command: "echo 'hello'"
+        runners:
+          - name: docker
+            options:
+              allow_networking: false
+          - name: exec
+`
+	if err := os.WriteFile(testConfigFile, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+
+	// without strict mode, runner options are not checked
+	srv := New(Config{ConfigFile: testConfigFile, Logger: logger})
+	if err := srv.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v, want nil", err)
+	}
+
+	// in strict mode, the docker runner without an image is reported
+	srv = New(Config{ConfigFile: testConfigFile, Logger: logger, Strict: true})
+	err := srv.Validate()
+	if err == nil || !strings.Contains(err.Error(), "docker runner requires 'image' option") {
+		t.Errorf("Validate() in strict mode error = %v, want error about the missing image", err)
+	}
+}
+
+func TestServer_ValidateReadOnlyDestructive(t *testing.T) {
+	testConfigFile := filepath.Join(t.TempDir(), "config.yaml")
+	configContent := `mcp:
+  tools:
+    - name: "confused_tool"
+      description: "Tool that is both read-only and destructive"
+      read_only: true
+      destructive: true
+      run:
+        command: "echo 'hello'"
+`
+	if err := os.WriteFile(testConfigFile, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+
+	srv := New(Config{ConfigFile: testConfigFile, Logger: logger})
+	err := srv.Validate()
+	if err == nil || !strings.Contains(err.Error(), "cannot be both read-only and destructive") {
+		t.Errorf("Validate() error = %v, want error about conflicting annotations", err)
+	}
+}
+
+func TestServer_ValidateOutputRegex(t *testing.T) {
+	testConfigFile := filepath.Join(t.TempDir(), "config.yaml")
+	configContent := `mcp:
+  tools:
+    - name: "version_tool"
+      description: "Tool extracting a version from its output"
+      run:
+        command: "echo 'version 1.2.3'"
+      output:
+        regex: "[0-9]+(\\.[0-9]+"
+`
+	if err := os.WriteFile(testConfigFile, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+
+	srv := New(Config{ConfigFile: testConfigFile, Logger: logger})
+	err := srv.Validate()
+	if err == nil || !strings.Contains(err.Error(), "invalid output regex for tool 'version_tool'") {
+		t.Errorf("Validate() error = %v, want error about the invalid regex", err)
+	}
+}
+
+func TestServer_ValidateParamPattern(t *testing.T) {
+	testConfigFile := filepath.Join(t.TempDir(), "config.yaml")
+	configContent := `mcp:
+  tools:
+    - name: "branch_tool"
+      description: "Tool with a broken pattern"
+      params:
+        branch:
+          type: string
+          pattern: "^[a-z]+("
+      run:
+        command: "echo {{ .branch }}"
+`
+	if err := os.WriteFile(testConfigFile, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+
+	srv := New(Config{ConfigFile: testConfigFile, Logger: logger})
+	err := srv.Validate()
+	if err == nil || !strings.Contains(err.Error(), "invalid pattern for parameter 'branch'") {
+		t.Errorf("Validate() error = %v, want error about the invalid pattern", err)
+	}
+}
+
+func TestServer_ValidateUnusedGlobalConstraints(t *testing.T) {
+	testConfigFile := filepath.Join(t.TempDir(), "config.yaml")
+	configContent := `mcp:
+  global_constraints:
+    - "!path.contains(';')"
+    - "!pth.contains('..')"
+  tools:
+    - name: "list_files"
+      description: "List files"
+      params:
+        path:
+          type: string
+      run:
+        command: "ls {{ .path }}"
+`
+	if err := os.WriteFile(testConfigFile, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+
+	srv := New(Config{ConfigFile: testConfigFile, Logger: logger})
+	err := srv.Validate()
+	if err == nil || !strings.Contains(err.Error(), "!pth.contains('..')") || strings.Contains(err.Error(), "!path") {
+		t.Errorf("Validate() error = %v, want error about the unused global constraint", err)
+	}
+}
+
+func TestServer_ValidateSingleTool(t *testing.T) {
+	testConfigFile := filepath.Join(t.TempDir(), "config.yaml")
+	configContent := `mcp:
+  tools:
+    - name: "broken_constraints"
+      description: "Tool with a constraint that does not compile"
+      constraints:
+        - "this is not a valid expression"
+      run:
+        command: "echo 'broken'"
+    - name: "good_tool"
+      description: "A valid tool"
+      params:
+        name:
+          type: string
+          description: "Name to echo"
+      constraints:
+        - "name.size() < 10"
+      run:
+        command: "echo {{ .name }}"
+    - name: "broken_runner"
+      description: "Docker tool without an image"
+      run:
+        command: "echo 'broken'"
+        runners:
+          - name: docker
+`
+	if err := os.WriteFile(testConfigFile, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+
+	// the whole configuration is invalid...
+	srv := New(Config{ConfigFile: testConfigFile, Logger: logger, Strict: true})
+	if err := srv.Validate(); err == nil {
+		t.Fatal("Validate() error = nil, want an error for the broken tools")
+	}
+
+	// ... but the good tool is valid on its own
+	srv = New(Config{ConfigFile: testConfigFile, Logger: logger, Strict: true, ValidateTool: "good_tool"})
+	if err := srv.Validate(); err != nil {
+		t.Errorf("Validate() for 'good_tool' error = %v, want nil", err)
+	}
+
+	// a broken tool is still reported when it is the one validated
+	srv = New(Config{ConfigFile: testConfigFile, Logger: logger, ValidateTool: "broken_constraints"})
+	if err := srv.Validate(); err == nil || !strings.Contains(err.Error(), "broken_constraints") {
+		t.Errorf("Validate() for 'broken_constraints' error = %v, want a constraint error", err)
+	}
+
+	// unknown tools are reported
+	srv = New(Config{ConfigFile: testConfigFile, Logger: logger, ValidateTool: "missing_tool"})
+	if err := srv.Validate(); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Validate() for 'missing_tool' error = %v, want a not found error", err)
+	}
+}
+
+func TestServer_HideDeprecated(t *testing.T) {
+	testConfigFile := filepath.Join(t.TempDir(), "config.yaml")
+	configContent := `mcp:
+  tools:
+    - name: "old_tool"
+      description: "Old tool"
+      deprecated: true
+      deprecation_message: "use 'new_tool' instead"
+      run:
+        command: "echo 'old'"
+    - name: "new_tool"
+      description: "New tool"
+      run:
+        command: "echo 'new'"
+`
+	if err := os.WriteFile(testConfigFile, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+
+	for _, hide := range []bool{false, true} {
+		srv := New(Config{ConfigFile: testConfigFile, Logger: logger, HideDeprecated: hide})
+		if err := srv.CreateServer(); err != nil {
+			t.Fatalf("CreateServer() error = %v", err)
+		}
+
+		tools, err := srv.GetTools()
+		if err != nil {
+			t.Fatalf("GetTools() error = %v", err)
+		}
+
+		var names []string
+		for _, tool := range tools {
+			names = append(names, tool.Name)
+		}
+		wantCount := 2
+		if hide {
+			wantCount = 1
+		}
+		if len(tools) != wantCount {
+			t.Errorf("With HideDeprecated=%v, expected %d tools, got %v", hide, wantCount, names)
+		}
+		if !hide && !strings.HasPrefix(tools[0].Description, "[DEPRECATED: use 'new_tool' instead]") {
+			t.Errorf("Expected a deprecation notice in the description, got %q", tools[0].Description)
+		}
+	}
+}
+
+func TestServer_MaxTools(t *testing.T) {
+	testConfigFile := filepath.Join(t.TempDir(), "config.yaml")
+	configContent := `mcp:
+  tools:
+    - name: "tool_a"
+      description: "Tool A"
+      run:
+        command: "echo 'a'"
+    - name: "tool_b"
+      description: "Tool B"
+      priority: 10
+      run:
+        command: "echo 'b'"
+    - name: "tool_c"
+      description: "Tool C"
+      run:
+        command: "echo 'c'"
+    - name: "tool_d"
+      description: "Tool D"
+      priority: 5
+      run:
+        command: "echo 'd'"
+`
+	if err := os.WriteFile(testConfigFile, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+
+	tests := []struct {
+		maxTools int
+		want     []string
+	}{
+		{maxTools: 0, want: []string{"tool_a", "tool_b", "tool_c", "tool_d"}},
+		{maxTools: 1, want: []string{"tool_b"}},
+		{maxTools: 3, want: []string{"tool_b", "tool_d", "tool_a"}},
+		{maxTools: 10, want: []string{"tool_a", "tool_b", "tool_c", "tool_d"}},
+	}
+
+	for _, tt := range tests {
+		srv := New(Config{ConfigFile: testConfigFile, Logger: logger, MaxTools: tt.maxTools})
+		if err := srv.CreateServer(); err != nil {
+			t.Fatalf("CreateServer() error = %v", err)
+		}
+
+		tools, err := srv.GetTools()
+		if err != nil {
+			t.Fatalf("GetTools() error = %v", err)
+		}
+
+		var names []string
+		for _, tool := range tools {
+			names = append(names, tool.Name)
+		}
+		if strings.Join(names, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("With MaxTools=%d, expected tools %v, got %v", tt.maxTools, tt.want, names)
+		}
+
+		// only the selected tools are registered with the MCP server
+		if registered := srv.mcpServer.ListTools(); len(registered) != len(tt.want) {
+			t.Errorf("With MaxTools=%d, expected %d registered tools, got %d", tt.maxTools, len(tt.want), len(registered))
+		}
+	}
+
+	srv := New(Config{ConfigFile: testConfigFile, Logger: logger, MaxTools: -1})
+	if err := srv.CreateServer(); err == nil {
+		t.Errorf("CreateServer() with a negative MaxTools should fail")
+	}
+}
+
+func TestServer_ValidateShellFlags(t *testing.T) {
+	testConfigFile := filepath.Join(t.TempDir(), "config.yaml")
+	configContent := `mcp:
+  run:
+    shell: "/bin/sh"
+  tools:
+    - name: "pipeline"
+      description: "Tool using bash-only shell flags"
+      run:
+        command: "ls | wc -l"
+        shell_flags: "set -euo pipefail"
+`
+	if err := os.WriteFile(testConfigFile, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+
+	// pipefail with a POSIX shell is just a warning...
+	srv := New(Config{ConfigFile: testConfigFile, Logger: logger})
+	if err := srv.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v, want only a warning", err)
+	}
+
+	// ... unless warnings are errors
+	srv = New(Config{ConfigFile: testConfigFile, Logger: logger, FailOnWarnings: true})
+	if err := srv.Validate(); err == nil || !strings.Contains(err.Error(), "pipefail") {
+		t.Errorf("Validate() error = %v, want a pipefail error", err)
+	}
+
+	// a shell supporting pipefail is fine
+	srv = New(Config{ConfigFile: testConfigFile, Logger: logger, Shell: "bash", FailOnWarnings: true})
+	if err := srv.Validate(); err != nil {
+		t.Errorf("Validate() with bash error = %v", err)
+	}
+}
+
+func TestServer_Builtins(t *testing.T) {
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+
+	// the built-in tools do not need any configuration file
+	srv := New(Config{Logger: logger, EnableBuiltins: true})
+	if err := srv.CreateServer(); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+
+	tools, err := srv.GetTools()
+	if err != nil {
+		t.Fatalf("GetTools() error = %v", err)
+	}
+	if len(tools) != len(config.BuiltinTools()) {
+		t.Errorf("Expected %d built-in tools, got %d", len(config.BuiltinTools()), len(tools))
+	}
+
+	output, err := srv.ExecuteTool(context.Background(), "__echo", map[string]interface{}{"message": "hello builtins"})
+	if err != nil {
+		t.Fatalf("ExecuteTool(__echo) error = %v", err)
+	}
+	if strings.TrimSpace(output) != "hello builtins" {
+		t.Errorf("ExecuteTool(__echo) = %q, want %q", output, "hello builtins")
+	}
+
+	// without the built-in tools, the configuration file is required
+	srv = New(Config{Logger: logger})
+	if err := srv.CreateServer(); err == nil {
+		t.Errorf("CreateServer() without a configuration file should fail")
+	}
+}
+
+func TestServer_Transport(t *testing.T) {
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+
+	// an unknown transport is rejected before starting anything
+	srv := New(Config{Logger: logger, EnableBuiltins: true, Transport: "carrier-pigeon"})
+	if err := srv.Start(); err == nil || !strings.Contains(err.Error(), "unknown transport") {
+		t.Errorf("Start() with an unknown transport error = %v, want an 'unknown transport' error", err)
+	}
+
+	// the SSE server announces the endpoint for posting messages
+	srv = New(Config{Logger: logger, EnableBuiltins: true, Transport: TransportSSE})
+	if err := srv.CreateServer(); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+	ts := httptest.NewServer(srv.newSSEServer())
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/sse", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /sse error = %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("GET /sse Content-Type = %q, want %q", ct, "text/event-stream")
+	}
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil {
+		t.Fatalf("Failed to read the SSE stream: %v", err)
+	}
+	if strings.TrimSpace(line) != "event: endpoint" {
+		t.Errorf("First SSE line = %q, want %q", line, "event: endpoint")
+	}
+}
+
+func TestServer_ServeUntilSignal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping signal test on Windows")
+	}
+
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+	srv := &Server{logger: logger}
+
+	started, stopped := make(chan struct{}), make(chan struct{})
+	serve := func() error {
+		close(started)
+		<-stopped
+		return http.ErrServerClosed
+	}
+	shutdown := func(ctx context.Context) error {
+		close(stopped)
+		return nil
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- srv.serveUntilSignal(serve, shutdown) }()
+	<-started
+
+	// the server is stopped (instead of the process being killed) on SIGTERM
+	process, _ := os.FindProcess(os.Getpid())
+	if err := process.Signal(syscall.SIGTERM); err != nil {
+		t.Fatalf("Failed to send the signal: %v", err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("serveUntilSignal() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("The server was not stopped on SIGTERM")
+	}
+}
+
+func TestServer_GlobalConstraints(t *testing.T) {
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+
+	testConfigFile := filepath.Join(t.TempDir(), "config.yaml")
+	configContent := `mcp:
+  global_constraints:
+    - "!path.contains(';')"
+  tools:
+    - name: "list_files"
+      description: "List files"
+      params:
+        path:
+          type: string
+          required: true
+      run:
+        command: "echo 'listing {{ .path }}'"
+    - name: "count_lines"
+      description: "Count lines"
+      params:
+        path:
+          type: string
+          required: true
+      constraints:
+        - "path.size() < 100"
+      run:
+        command: "echo 'counting {{ .path }}'"
+    - name: "say"
+      description: "Say something"
+      params:
+        message:
+          type: string
+          required: true
+      run:
+        command: "echo '{{ .message }}'"
+`
+	if err := os.WriteFile(testConfigFile, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+
+	srv := New(Config{ConfigFile: testConfigFile, Logger: logger})
+	if err := srv.CreateServer(); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+
+	// the global constraint blocks the calls of all the tools with a 'path'...
+	for _, tool := range []string{"list_files", "count_lines"} {
+		output, err := srv.ExecuteTool(context.Background(), tool, map[string]interface{}{"path": "/tmp; rm -rf /"})
+		if err == nil && !strings.Contains(output, "blocked by constraints") {
+			t.Errorf("ExecuteTool(%s) = %q, should be blocked by the global constraint", tool, output)
+		}
+		output, err = srv.ExecuteTool(context.Background(), tool, map[string]interface{}{"path": "/tmp"})
+		if err != nil || !strings.HasSuffix(strings.TrimSpace(output), "/tmp") {
+			t.Errorf("ExecuteTool(%s) = %q, %v", tool, output, err)
+		}
+	}
+
+	// ... and it is skipped for the tools without it
+	output, err := srv.ExecuteTool(context.Background(), "say", map[string]interface{}{"message": "a; b"})
+	if err != nil || strings.TrimSpace(output) != "a; b" {
+		t.Errorf("ExecuteTool(say) = %q, %v, want it not to be blocked", output, err)
+	}
+}
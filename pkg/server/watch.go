@@ -0,0 +1,159 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is the time waited after a change in the configuration before reloading
+// it, so the several events of a single save (like editors writing a temporary file and
+// renaming it) only reload the configuration once
+var watchDebounce = 500 * time.Millisecond
+
+// watchConfig starts watching the configuration for changes, reloading the tools when
+// it changes. Files are watched through their parent directory, so they are still watched
+// after editors replace them. In watched directories, any change in a YAML file reloads
+// the configuration.
+//
+// Returns:
+//   - A function for stopping the watcher
+//   - An error if the watcher cannot be started
+func (s *Server) watchConfig() (func(), error) {
+	paths := s.watchPaths
+	if len(paths) == 0 && s.configFile != "" {
+		paths = []string{s.configFile}
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no local configuration files to watch")
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the configuration watcher: %w", err)
+	}
+
+	files := map[string]bool{}
+	dirs := map[string]bool{}
+	for _, path := range paths {
+		path, err = filepath.Abs(path)
+		if err != nil {
+			_ = watcher.Close()
+			return nil, fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+
+		dir := path
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			dirs[path] = true
+		} else {
+			files[path] = true
+			dir = filepath.Dir(path)
+		}
+
+		if err := watcher.Add(dir); err != nil {
+			_ = watcher.Close()
+			return nil, fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+		s.logger.Info("Watching %s for configuration changes", path)
+	}
+
+	// isConfigChange returns true when the event is about one of the configuration files
+	isConfigChange := func(event fsnotify.Event) bool {
+		if event.Op == fsnotify.Chmod {
+			return false
+		}
+		name := filepath.Clean(event.Name)
+		if files[name] {
+			return true
+		}
+		ext := strings.ToLower(filepath.Ext(name))
+		return dirs[filepath.Dir(name)] && (ext == ".yaml" || ext == ".yml")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		var reload <-chan time.Time
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if isConfigChange(event) {
+					s.logger.Debug("Configuration change detected: %s", event)
+					reload = time.After(watchDebounce)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				s.logger.Error("Configuration watcher error: %v", err)
+			case <-reload:
+				reload = nil
+				_ = s.reloadTools()
+			}
+		}
+	}()
+
+	stop := func() {
+		_ = watcher.Close()
+		<-done
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.configCleanup != nil {
+			s.configCleanup()
+			s.configCleanup = nil
+		}
+	}
+	return stop, nil
+}
+
+// reloadTools loads the configuration again and updates the tools registered with the
+// MCP server: new tools are added, existing tools are replaced and tools that are not in
+// the configuration anymore are removed. When the new configuration cannot be loaded,
+// the server keeps the tools of the last good configuration.
+func (s *Server) reloadTools() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.logger.Info("Reloading the tools configuration")
+
+	configFile, cleanup := s.configFile, func() {}
+	if s.resolveConfig != nil {
+		var err error
+		configFile, cleanup, err = s.resolveConfig()
+		if err != nil {
+			s.logger.Error("Failed to resolve the configuration, keeping the current tools: %v", err)
+			return fmt.Errorf("failed to resolve config: %w", err)
+		}
+	}
+
+	cfg, err := s.loadConfigFile(configFile)
+	if err != nil {
+		cleanup()
+		s.logger.Error("Failed to reload the configuration, keeping the current tools: %v", err)
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if err := s.loadTools(cfg); err != nil {
+		cleanup()
+		s.logger.Error("Failed to reload the tools, keeping the current tools: %v", err)
+		return err
+	}
+
+	// The new configuration file replaces the previous one
+	if s.configCleanup != nil {
+		s.configCleanup()
+	}
+	s.configFile, s.configCleanup = configFile, cleanup
+
+	s.logger.Info("Reloaded the tools configuration")
+	return nil
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/inercia/MCPShell/pkg/common"
+)
+
+// writeToolsConfig writes a configuration with a tool for each of the names
+func writeToolsConfig(t *testing.T, path string, names ...string) {
+	t.Helper()
+	content := "mcp:\n  tools:\n"
+	for _, name := range names {
+		content += fmt.Sprintf("    - name: %q\n      description: \"Tool %s\"\n      run:\n        command: \"echo %s\"\n", name, name, name)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+}
+
+// registeredToolNames returns the sorted names of the tools registered with the MCP server
+func registeredToolNames(srv *Server) []string {
+	var names []string
+	for name := range srv.mcpServer.ListTools() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestServer_ReloadTools(t *testing.T) {
+	testConfigFile := filepath.Join(t.TempDir(), "config.yaml")
+	writeToolsConfig(t, testConfigFile, "first", "second")
+
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+	srv := New(Config{ConfigFile: testConfigFile, Logger: logger, Watch: true})
+	if err := srv.CreateServer(); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+	if got := fmt.Sprint(registeredToolNames(srv)); got != "[first second]" {
+		t.Fatalf("Expected tools [first second], got %s", got)
+	}
+
+	// new tools are added and removed tools are dropped
+	writeToolsConfig(t, testConfigFile, "first", "third")
+	if err := srv.reloadTools(); err != nil {
+		t.Fatalf("reloadTools() error = %v", err)
+	}
+	if got := fmt.Sprint(registeredToolNames(srv)); got != "[first third]" {
+		t.Errorf("Expected tools [first third] after reloading, got %s", got)
+	}
+
+	// the last good configuration is kept when the new one is invalid
+	if err := os.WriteFile(testConfigFile, []byte("mcp: [invalid"), 0644); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+	if err := srv.reloadTools(); err == nil {
+		t.Error("Expected reloadTools() to fail with an invalid configuration")
+	}
+	if got := fmt.Sprint(registeredToolNames(srv)); got != "[first third]" {
+		t.Errorf("Expected tools [first third] to be kept, got %s", got)
+	}
+}
+
+func TestServer_ReloadToolsResolveConfig(t *testing.T) {
+	dir := t.TempDir()
+	firstFile := filepath.Join(dir, "first.yaml")
+	secondFile := filepath.Join(dir, "second.yaml")
+	writeToolsConfig(t, firstFile, "first")
+	writeToolsConfig(t, secondFile, "second")
+
+	cleanups := 0
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+	srv := New(Config{
+		ConfigFile: firstFile,
+		Logger:     logger,
+		ResolveConfig: func() (string, func(), error) {
+			return secondFile, func() { cleanups++ }, nil
+		},
+	})
+	if err := srv.CreateServer(); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := srv.reloadTools(); err != nil {
+			t.Fatalf("reloadTools() error = %v", err)
+		}
+	}
+	if got := fmt.Sprint(registeredToolNames(srv)); got != "[second]" {
+		t.Errorf("Expected tools [second] after reloading, got %s", got)
+	}
+	if cleanups != 1 {
+		t.Errorf("Expected the first resolved configuration to be cleaned up, got %d cleanups", cleanups)
+	}
+}
+
+func TestServer_WatchConfig(t *testing.T) {
+	defer func(debounce time.Duration) { watchDebounce = debounce }(watchDebounce)
+	watchDebounce = 10 * time.Millisecond
+
+	dir := t.TempDir()
+	testConfigFile := filepath.Join(dir, "config.yaml")
+	writeToolsConfig(t, testConfigFile, "first")
+
+	logger, _ := common.NewLogger("", "", common.LogLevelNone, false)
+	srv := New(Config{ConfigFile: testConfigFile, Logger: logger, Watch: true})
+	if err := srv.CreateServer(); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+
+	stop, err := srv.watchConfig()
+	if err != nil {
+		t.Fatalf("watchConfig() error = %v", err)
+	}
+	defer stop()
+
+	// replace the file like editors do, writing a new file and renaming it
+	tmpFile := filepath.Join(dir, "config.yaml.tmp")
+	writeToolsConfig(t, tmpFile, "first", "second")
+	if err := os.Rename(tmpFile, testConfigFile); err != nil {
+		t.Fatalf("Failed to replace the config file: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		srv.mu.Lock()
+		got := fmt.Sprint(registeredToolNames(srv))
+		srv.mu.Unlock()
+		if got == "[first second]" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected tools [first second] after the config changed, got %s", got)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
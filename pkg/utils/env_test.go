@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// unsetForTest unsets an environment variable for the duration of a test,
+// restoring its original state afterwards
+func unsetForTest(t *testing.T, key string) {
+	t.Helper()
+	t.Setenv(key, "")
+	_ = os.Unsetenv(key)
+}
+
+func TestLoadEnvFile(t *testing.T) {
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, ".env")
+	content := `# a comment
+MCPSHELL_TEST_PLAIN=plain
+
+export MCPSHELL_TEST_EXPORTED=exported
+MCPSHELL_TEST_DOUBLE="double quoted"
+MCPSHELL_TEST_SINGLE='single quoted'
+MCPSHELL_TEST_EXISTING=from-file
+`
+	if err := os.WriteFile(envFile, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+
+	for _, key := range []string{"MCPSHELL_TEST_PLAIN", "MCPSHELL_TEST_EXPORTED", "MCPSHELL_TEST_DOUBLE", "MCPSHELL_TEST_SINGLE"} {
+		unsetForTest(t, key)
+	}
+	t.Setenv("MCPSHELL_TEST_EXISTING", "from-env")
+
+	if err := LoadEnvFile(envFile); err != nil {
+		t.Fatalf("LoadEnvFile() error = %v", err)
+	}
+
+	expected := map[string]string{
+		"MCPSHELL_TEST_PLAIN":    "plain",
+		"MCPSHELL_TEST_EXPORTED": "exported",
+		"MCPSHELL_TEST_DOUBLE":   "double quoted",
+		"MCPSHELL_TEST_SINGLE":   "single quoted",
+		"MCPSHELL_TEST_EXISTING": "from-env",
+	}
+	for key, want := range expected {
+		if got := os.Getenv(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestLoadEnvFile_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := LoadEnvFile(filepath.Join(dir, "missing.env")); err == nil {
+		t.Error("Expected error for a missing env file")
+	}
+
+	invalid := filepath.Join(dir, "invalid.env")
+	if err := os.WriteFile(invalid, []byte("NOT_AN_ASSIGNMENT\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+	if err := LoadEnvFile(invalid); err == nil {
+		t.Error("Expected error for a line without '='")
+	}
+}